@@ -0,0 +1,702 @@
+// Package builder implements a fluent API to construct Avro schemas.
+//
+// Errors are collected while building and returned together from Build, and
+// named types can be referenced by name, including from within themselves:
+//
+//	schema, err := builder.Record("User").Namespace("acme").
+//		Field("id", builder.Long()).
+//		Field("tags", builder.Array(builder.String())).
+//		Optional("email", builder.String()).
+//		Field("friends", builder.Array(builder.Ref("User"))).
+//		Build()
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// Type is a schema under construction.
+type Type interface {
+	build(ctx *buildContext) avro.Schema
+}
+
+// Build builds the schema of the given type.
+func Build(typ Type) (avro.Schema, error) {
+	ctx := newContext()
+	schema := typ.build(ctx)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// MustBuild builds the schema of the given type, panicking if there is an error.
+func MustBuild(typ Type) avro.Schema {
+	schema, err := Build(typ)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+type buildContext struct {
+	namespace string
+	named     map[string]avro.NamedSchema
+	redefined []redefinition
+	errs      []error
+}
+
+// redefinition is a named schema defined again under the name of an existing schema.
+type redefinition struct {
+	existing avro.NamedSchema
+	schema   avro.NamedSchema
+}
+
+func newContext() *buildContext {
+	return &buildContext{named: map[string]avro.NamedSchema{}}
+}
+
+func (c *buildContext) errorf(format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("builder: "+format, args...))
+}
+
+func (c *buildContext) err() error {
+	// Redefinitions are checked once all schemas are built, as they can be
+	// defined within the schema they redefine.
+	if len(c.errs) == 0 {
+		for _, r := range c.redefined {
+			if !identical(r.existing, r.schema, map[string]bool{}) {
+				c.errorf("conflicting redefinition of %s", r.schema.FullName())
+			}
+		}
+	}
+	c.redefined = nil
+	return errors.Join(c.errs...)
+}
+
+// define registers a named schema, returning a reference to the existing schema
+// if the name was already defined. Redefinitions must be identical to the
+// existing schema.
+func (c *buildContext) define(schema avro.NamedSchema) (avro.Schema, bool) {
+	if existing, ok := c.named[schema.FullName()]; ok {
+		c.redefined = append(c.redefined, redefinition{existing: existing, schema: schema})
+		return avro.NewRefSchema(existing), false
+	}
+	c.named[schema.FullName()] = schema
+	for _, alias := range schema.Aliases() {
+		c.named[alias] = schema
+	}
+	return schema, true
+}
+
+func (c *buildContext) lookup(name string) avro.NamedSchema {
+	if c.namespace != "" && !strings.ContainsRune(name, '.') {
+		if schema, ok := c.named[c.namespace+"."+name]; ok {
+			return schema
+		}
+	}
+	return c.named[name]
+}
+
+// withNamespace runs fn with the given namespace as the enclosing namespace.
+func (c *buildContext) withNamespace(namespace string, fn func()) {
+	prev := c.namespace
+	c.namespace = namespace
+	defer func() { c.namespace = prev }()
+	fn()
+}
+
+// PrimitiveBuilder builds a primitive schema.
+type PrimitiveBuilder struct {
+	typ     avro.Type
+	logical avro.LogicalSchema
+	props   map[string]any
+}
+
+// Null returns a null type.
+func Null() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Null}
+}
+
+// Boolean returns a boolean type.
+func Boolean() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Boolean}
+}
+
+// Int returns an int type.
+func Int() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Int}
+}
+
+// Long returns a long type.
+func Long() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Long}
+}
+
+// Float returns a float type.
+func Float() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Float}
+}
+
+// Double returns a double type.
+func Double() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Double}
+}
+
+// Bytes returns a bytes type.
+func Bytes() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Bytes}
+}
+
+// String returns a string type.
+func String() *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.String}
+}
+
+// Decimal returns a bytes type with a decimal logical type.
+func Decimal(precision, scale int) *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: avro.Bytes, logical: avro.NewDecimalLogicalSchema(precision, scale)}
+}
+
+// UUID returns a string type with a uuid logical type.
+func UUID() *PrimitiveBuilder {
+	return logical(avro.String, avro.UUID)
+}
+
+// Date returns an int type with a date logical type.
+func Date() *PrimitiveBuilder {
+	return logical(avro.Int, avro.Date)
+}
+
+// TimeMillis returns an int type with a time-millis logical type.
+func TimeMillis() *PrimitiveBuilder {
+	return logical(avro.Int, avro.TimeMillis)
+}
+
+// TimeMicros returns a long type with a time-micros logical type.
+func TimeMicros() *PrimitiveBuilder {
+	return logical(avro.Long, avro.TimeMicros)
+}
+
+// TimestampMillis returns a long type with a timestamp-millis logical type.
+func TimestampMillis() *PrimitiveBuilder {
+	return logical(avro.Long, avro.TimestampMillis)
+}
+
+// TimestampMicros returns a long type with a timestamp-micros logical type.
+func TimestampMicros() *PrimitiveBuilder {
+	return logical(avro.Long, avro.TimestampMicros)
+}
+
+// LocalTimestampMillis returns a long type with a local-timestamp-millis logical type.
+func LocalTimestampMillis() *PrimitiveBuilder {
+	return logical(avro.Long, avro.LocalTimestampMillis)
+}
+
+// LocalTimestampMicros returns a long type with a local-timestamp-micros logical type.
+func LocalTimestampMicros() *PrimitiveBuilder {
+	return logical(avro.Long, avro.LocalTimestampMicros)
+}
+
+func logical(typ avro.Type, lt avro.LogicalType) *PrimitiveBuilder {
+	return &PrimitiveBuilder{typ: typ, logical: avro.NewPrimitiveLogicalSchema(lt)}
+}
+
+// Prop sets a custom property on the type.
+func (b *PrimitiveBuilder) Prop(name string, value any) *PrimitiveBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+func (b *PrimitiveBuilder) build(ctx *buildContext) avro.Schema {
+	if d, ok := b.logical.(*avro.DecimalLogicalSchema); ok {
+		if d.Precision() <= 0 || d.Scale() < 0 || d.Scale() > d.Precision() {
+			ctx.errorf("invalid decimal precision %d and scale %d", d.Precision(), d.Scale())
+		}
+	}
+
+	if b.typ == avro.Null {
+		return avro.NewNullSchema(avro.WithProps(b.props))
+	}
+	return avro.NewPrimitiveSchema(b.typ, b.logical, avro.WithProps(b.props))
+}
+
+// ArrayBuilder builds an array schema.
+type ArrayBuilder struct {
+	items Type
+	props map[string]any
+}
+
+// Array returns an array type of the given items.
+func Array(items Type) *ArrayBuilder {
+	return &ArrayBuilder{items: items}
+}
+
+// Prop sets a custom property on the type.
+func (b *ArrayBuilder) Prop(name string, value any) *ArrayBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+func (b *ArrayBuilder) build(ctx *buildContext) avro.Schema {
+	return avro.NewArraySchema(buildType(ctx, b.items), avro.WithProps(b.props))
+}
+
+// MapBuilder builds a map schema.
+type MapBuilder struct {
+	values Type
+	props  map[string]any
+}
+
+// Map returns a map type of the given values.
+func Map(values Type) *MapBuilder {
+	return &MapBuilder{values: values}
+}
+
+// Prop sets a custom property on the type.
+func (b *MapBuilder) Prop(name string, value any) *MapBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+func (b *MapBuilder) build(ctx *buildContext) avro.Schema {
+	return avro.NewMapSchema(buildType(ctx, b.values), avro.WithProps(b.props))
+}
+
+type unionBuilder struct {
+	types []Type
+}
+
+// Union returns a union of the given types.
+func Union(types ...Type) Type {
+	return &unionBuilder{types: types}
+}
+
+// Nullable returns a union of null and the given type.
+func Nullable(typ Type) Type {
+	return Union(Null(), typ)
+}
+
+func (b *unionBuilder) build(ctx *buildContext) avro.Schema {
+	types := make([]avro.Schema, len(b.types))
+	for i, typ := range b.types {
+		types[i] = buildType(ctx, typ)
+	}
+
+	union, err := avro.NewUnionSchema(types)
+	if err != nil {
+		ctx.errorf("%w", err)
+		return avro.NewNullSchema()
+	}
+	return union
+}
+
+type refBuilder struct {
+	name string
+}
+
+// Ref returns a reference to a named type.
+//
+// The name is resolved relative to the enclosing namespace, and must be defined
+// before the reference, or enclose it.
+func Ref(name string) Type {
+	return &refBuilder{name: name}
+}
+
+func (b *refBuilder) build(ctx *buildContext) avro.Schema {
+	schema := ctx.lookup(b.name)
+	if schema == nil {
+		ctx.errorf("unknown type %q", b.name)
+		return avro.NewNullSchema()
+	}
+	return avro.NewRefSchema(schema)
+}
+
+type schemaBuilder struct {
+	schema avro.Schema
+}
+
+// Schema returns a type of an existing schema.
+//
+// The named types of the schema can be referenced by name afterwards.
+func Schema(schema avro.Schema) Type {
+	return &schemaBuilder{schema: schema}
+}
+
+func (b *schemaBuilder) build(ctx *buildContext) avro.Schema {
+	if b.schema == nil {
+		ctx.errorf("schema cannot be nil")
+		return avro.NewNullSchema()
+	}
+
+	named, ok := b.schema.(avro.NamedSchema)
+	if !ok {
+		return b.schema
+	}
+	schema, _ := ctx.define(named)
+	return schema
+}
+
+type named struct {
+	name      string
+	namespace string
+	aliases   []string
+	props     map[string]any
+}
+
+// resolve returns the namespace of the type, falling back to the enclosing namespace.
+func (n named) resolve(ctx *buildContext) string {
+	if n.namespace != "" || strings.ContainsRune(n.name, '.') {
+		return n.namespace
+	}
+	return ctx.namespace
+}
+
+type field struct {
+	name string
+	typ  Type
+	opts []avro.SchemaOption
+}
+
+// RecordBuilder builds a record schema.
+type RecordBuilder struct {
+	named
+
+	doc     string
+	isError bool
+	fields  []field
+}
+
+// Record returns a record type with the given name.
+func Record(name string) *RecordBuilder {
+	return &RecordBuilder{named: named{name: name}}
+}
+
+// ErrorRecord returns an error record type with the given name.
+func ErrorRecord(name string) *RecordBuilder {
+	return &RecordBuilder{named: named{name: name}, isError: true}
+}
+
+// Namespace sets the namespace of the record.
+func (b *RecordBuilder) Namespace(namespace string) *RecordBuilder {
+	b.namespace = namespace
+	return b
+}
+
+// Doc sets the doc of the record.
+func (b *RecordBuilder) Doc(doc string) *RecordBuilder {
+	b.doc = doc
+	return b
+}
+
+// Aliases sets the aliases of the record.
+func (b *RecordBuilder) Aliases(aliases ...string) *RecordBuilder {
+	b.aliases = aliases
+	return b
+}
+
+// Prop sets a custom property on the record.
+func (b *RecordBuilder) Prop(name string, value any) *RecordBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+// Field adds a field to the record.
+func (b *RecordBuilder) Field(name string, typ Type, opts ...avro.SchemaOption) *RecordBuilder {
+	b.fields = append(b.fields, field{name: name, typ: typ, opts: opts})
+	return b
+}
+
+// Optional adds a field to the record that is a union of null and the given type,
+// with a null default.
+func (b *RecordBuilder) Optional(name string, typ Type, opts ...avro.SchemaOption) *RecordBuilder {
+	opts = append([]avro.SchemaOption{avro.WithDefault(nil)}, opts...)
+	return b.Field(name, Nullable(typ), opts...)
+}
+
+// Build builds the record schema.
+func (b *RecordBuilder) Build() (*avro.RecordSchema, error) {
+	ctx := newContext()
+	schema := b.build(ctx)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return schema.(*avro.RecordSchema), nil
+}
+
+// MustBuild builds the record schema, panicking if there is an error.
+func (b *RecordBuilder) MustBuild() *avro.RecordSchema {
+	schema, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func (b *RecordBuilder) build(ctx *buildContext) avro.Schema {
+	newRecord := avro.NewRecordSchema
+	if b.isError {
+		newRecord = avro.NewErrorRecordSchema
+	}
+
+	// The fields are set once the record is defined, so they can reference it.
+	fields := make([]*avro.Field, len(b.fields))
+	rec, err := newRecord(b.name, b.resolve(ctx), fields,
+		avro.WithDoc(b.doc), avro.WithAliases(b.aliases), avro.WithProps(b.props),
+	)
+	if err != nil {
+		ctx.errorf("record %q: %w", b.name, err)
+		return avro.NewNullSchema()
+	}
+	// Redefined records are built as well, to be compared to the existing record.
+	schema, ok := ctx.define(rec)
+
+	names := map[string]struct{}{}
+	ctx.withNamespace(rec.Namespace(), func() {
+		for i, f := range b.fields {
+			if _, exists := names[f.name]; exists {
+				ctx.errorf("record %q: duplicate field name %q", rec.FullName(), f.name)
+				continue
+			}
+			names[f.name] = struct{}{}
+
+			nerrs := len(ctx.errs)
+			typ := buildType(ctx, f.typ)
+			if len(ctx.errs) > nerrs {
+				continue
+			}
+
+			field, err := avro.NewField(f.name, typ, f.opts...)
+			if err != nil {
+				ctx.errorf("record %q field %q: %w", rec.FullName(), f.name, err)
+				continue
+			}
+			fields[i] = field
+		}
+	})
+	if !ok {
+		return schema
+	}
+	return rec
+}
+
+// EnumBuilder builds an enum schema.
+type EnumBuilder struct {
+	named
+
+	doc     string
+	symbols []string
+	def     string
+}
+
+// Enum returns an enum type with the given name and symbols.
+func Enum(name string, symbols ...string) *EnumBuilder {
+	return &EnumBuilder{named: named{name: name}, symbols: symbols}
+}
+
+// Namespace sets the namespace of the enum.
+func (b *EnumBuilder) Namespace(namespace string) *EnumBuilder {
+	b.namespace = namespace
+	return b
+}
+
+// Doc sets the doc of the enum.
+func (b *EnumBuilder) Doc(doc string) *EnumBuilder {
+	b.doc = doc
+	return b
+}
+
+// Aliases sets the aliases of the enum.
+func (b *EnumBuilder) Aliases(aliases ...string) *EnumBuilder {
+	b.aliases = aliases
+	return b
+}
+
+// Default sets the default symbol of the enum.
+func (b *EnumBuilder) Default(symbol string) *EnumBuilder {
+	b.def = symbol
+	return b
+}
+
+// Prop sets a custom property on the enum.
+func (b *EnumBuilder) Prop(name string, value any) *EnumBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+// Build builds the enum schema.
+func (b *EnumBuilder) Build() (*avro.EnumSchema, error) {
+	ctx := newContext()
+	schema := b.build(ctx)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return schema.(*avro.EnumSchema), nil
+}
+
+func (b *EnumBuilder) build(ctx *buildContext) avro.Schema {
+	enum, err := avro.NewEnumSchema(b.name, b.resolve(ctx), b.symbols,
+		avro.WithDoc(b.doc), avro.WithAliases(b.aliases), avro.WithDefault(b.def), avro.WithProps(b.props),
+	)
+	if err != nil {
+		ctx.errorf("enum %q: %w", b.name, err)
+		return avro.NewNullSchema()
+	}
+	schema, _ := ctx.define(enum)
+	return schema
+}
+
+// FixedBuilder builds a fixed schema.
+type FixedBuilder struct {
+	named
+
+	size    int
+	logical avro.LogicalSchema
+}
+
+// Fixed returns a fixed type with the given name and size.
+func Fixed(name string, size int) *FixedBuilder {
+	return &FixedBuilder{named: named{name: name}, size: size}
+}
+
+// Duration returns a fixed type of size 12 with a duration logical type.
+func Duration(name string) *FixedBuilder {
+	return &FixedBuilder{named: named{name: name}, size: 12, logical: avro.NewPrimitiveLogicalSchema(avro.Duration)}
+}
+
+// Namespace sets the namespace of the fixed.
+func (b *FixedBuilder) Namespace(namespace string) *FixedBuilder {
+	b.namespace = namespace
+	return b
+}
+
+// Aliases sets the aliases of the fixed.
+func (b *FixedBuilder) Aliases(aliases ...string) *FixedBuilder {
+	b.aliases = aliases
+	return b
+}
+
+// Decimal sets a decimal logical type on the fixed.
+func (b *FixedBuilder) Decimal(precision, scale int) *FixedBuilder {
+	b.logical = avro.NewDecimalLogicalSchema(precision, scale)
+	return b
+}
+
+// Prop sets a custom property on the fixed.
+func (b *FixedBuilder) Prop(name string, value any) *FixedBuilder {
+	b.props = setProp(b.props, name, value)
+	return b
+}
+
+// Build builds the fixed schema.
+func (b *FixedBuilder) Build() (*avro.FixedSchema, error) {
+	ctx := newContext()
+	schema := b.build(ctx)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return schema.(*avro.FixedSchema), nil
+}
+
+func (b *FixedBuilder) build(ctx *buildContext) avro.Schema {
+	fixed, err := avro.NewFixedSchema(b.name, b.resolve(ctx), b.size, b.logical,
+		avro.WithAliases(b.aliases), avro.WithProps(b.props),
+	)
+	if err != nil {
+		ctx.errorf("fixed %q: %w", b.name, err)
+		return avro.NewNullSchema()
+	}
+	schema, _ := ctx.define(fixed)
+	return schema
+}
+
+func buildType(ctx *buildContext, typ Type) avro.Schema {
+	if typ == nil {
+		ctx.errorf("type cannot be nil")
+		return avro.NewNullSchema()
+	}
+	return typ.build(ctx)
+}
+
+// identical reports whether the schemas are structurally identical. Named
+// schemas already seen are compared by name only.
+func identical(a, b avro.Schema, seen map[string]bool) bool {
+	if ref, ok := a.(*avro.RefSchema); ok {
+		a = ref.Schema()
+	}
+	if ref, ok := b.(*avro.RefSchema); ok {
+		b = ref.Schema()
+	}
+	if a == b {
+		return true
+	}
+	if a.Type() != b.Type() || logicalType(a) != logicalType(b) {
+		return false
+	}
+
+	if na, ok := a.(avro.NamedSchema); ok {
+		name := na.FullName()
+		if name != b.(avro.NamedSchema).FullName() {
+			return false
+		}
+		if seen[name] {
+			return true
+		}
+		seen[name] = true
+	}
+
+	switch a := a.(type) {
+	case *avro.RecordSchema:
+		b := b.(*avro.RecordSchema)
+		if len(a.Fields()) != len(b.Fields()) {
+			return false
+		}
+		for i, fa := range a.Fields() {
+			fb := b.Fields()[i]
+			if fa.Name() != fb.Name() || fa.HasDefault() != fb.HasDefault() ||
+				!reflect.DeepEqual(fa.Default(), fb.Default()) || !identical(fa.Type(), fb.Type(), seen) {
+				return false
+			}
+		}
+	case *avro.EnumSchema:
+		return slices.Equal(a.Symbols(), b.(*avro.EnumSchema).Symbols())
+	case *avro.FixedSchema:
+		return a.Size() == b.(*avro.FixedSchema).Size()
+	case *avro.ArraySchema:
+		return identical(a.Items(), b.(*avro.ArraySchema).Items(), seen)
+	case *avro.MapSchema:
+		return identical(a.Values(), b.(*avro.MapSchema).Values(), seen)
+	case *avro.UnionSchema:
+		ta, tb := a.Types(), b.(*avro.UnionSchema).Types()
+		if len(ta) != len(tb) {
+			return false
+		}
+		for i := range ta {
+			if !identical(ta[i], tb[i], seen) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func logicalType(schema avro.Schema) string {
+	if s, ok := schema.(avro.LogicalTypeSchema); ok && s.Logical() != nil {
+		return s.Logical().String()
+	}
+	return ""
+}
+
+func setProp(props map[string]any, name string, value any) map[string]any {
+	if props == nil {
+		props = map[string]any{}
+	}
+	props[name] = value
+	return props
+}
@@ -0,0 +1,185 @@
+package builder_test
+
+import (
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	schema, err := builder.Record("User").Namespace("acme").Doc("A user.").
+		Field("id", builder.Long()).
+		Field("tags", builder.Array(builder.String())).
+		Optional("email", builder.String()).
+		Field("created", builder.TimestampMillis(), avro.WithDoc("Creation time.")).
+		Field("balance", builder.Decimal(10, 2)).
+		Field("status", builder.Enum("Status", "ACTIVE", "DISABLED").Default("ACTIVE"), avro.WithDefault("ACTIVE")).
+		Field("hash", builder.Fixed("Hash", 16).Namespace("acme.crypto")).
+		Field("friends", builder.Map(builder.Ref("User"))).
+		Build()
+	require.NoError(t, err)
+
+	want := avro.MustParse(`{"type":"record","name":"User","namespace":"acme","fields":[
+		{"name":"id","type":"long"},
+		{"name":"tags","type":{"type":"array","items":"string"}},
+		{"name":"email","type":["null","string"],"default":null},
+		{"name":"created","type":{"type":"long","logicalType":"timestamp-millis"}},
+		{"name":"balance","type":{"type":"bytes","logicalType":"decimal","precision":10,"scale":2}},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["ACTIVE","DISABLED"],"default":"ACTIVE"},"default":"ACTIVE"},
+		{"name":"hash","type":{"type":"fixed","name":"Hash","namespace":"acme.crypto","size":16}},
+		{"name":"friends","type":{"type":"map","values":"User"}}
+	]}`)
+	assert.Equal(t, want.String(), schema.String())
+	assert.Equal(t, "A user.", schema.Doc())
+	assert.Equal(t, "Creation time.", schema.Fields()[3].Doc())
+	assert.Equal(t, "acme.Status", schema.Fields()[5].Type().(*avro.EnumSchema).FullName())
+	assert.Equal(t, avro.Ref, schema.Fields()[7].Type().(*avro.MapSchema).Values().Type())
+}
+
+func TestRecord_EncodesRecursiveSchema(t *testing.T) {
+	schema := builder.Record("Node").
+		Field("value", builder.Int()).
+		Optional("next", builder.Ref("Node")).
+		MustBuild()
+
+	type node struct {
+		Value int   `avro:"value"`
+		Next  *node `avro:"next"`
+	}
+	in := node{Value: 1, Next: &node{Value: 2}}
+
+	b, err := avro.Marshal(schema, in)
+	require.NoError(t, err)
+
+	var out node
+	err = avro.Unmarshal(schema, b, &out)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestRecord_ReusedNamedTypeIsReferenced(t *testing.T) {
+	addr := builder.Record("Address").Field("street", builder.String())
+
+	schema, err := builder.Record("Person").
+		Field("home", addr).
+		Field("work", addr).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Record, schema.Fields()[0].Type().Type())
+	assert.Equal(t, avro.Ref, schema.Fields()[1].Type().Type())
+}
+
+func TestRecord_IdenticalRedefinitionIsReferenced(t *testing.T) {
+	schema, err := builder.Record("Person").
+		Field("home", builder.Record("Address").Field("street", builder.String())).
+		Field("work", builder.Record("Address").Field("street", builder.String())).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Ref, schema.Fields()[1].Type().Type())
+}
+
+func TestBuild_ConflictingRedefinition(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  builder.Type
+	}{
+		{
+			name: "record",
+			typ: builder.Record("P").
+				Field("a", builder.Record("A").Field("x", builder.Int())).
+				Field("b", builder.Record("A").Field("y", builder.String())),
+		},
+		{
+			name: "enum and fixed",
+			typ: builder.Record("P").
+				Field("a", builder.Enum("A", "X")).
+				Field("b", builder.Fixed("A", 3)),
+		},
+		{
+			name: "nested record",
+			typ: builder.Record("A").
+				Field("self", builder.Nullable(builder.Record("A").Field("x", builder.Int()))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := builder.Build(test.typ)
+
+			assert.EqualError(t, err, "builder: conflicting redefinition of A")
+		})
+	}
+}
+
+func TestRecord_CollectsErrors(t *testing.T) {
+	_, err := builder.Record("Bad").
+		Field("a", builder.Ref("Missing")).
+		Field("b", builder.Int(), avro.WithDefault("nope")).
+		Field("b", builder.Int()).
+		Field("c", builder.Union(builder.Int(), builder.Int())).
+		Field("d", builder.Enum("E")).
+		Field("e", builder.Decimal(2, 4)).
+		Field("1f", builder.Int()).
+		Build()
+
+	require.Error(t, err)
+	assert.Equal(t, `builder: unknown type "Missing"
+builder: record "Bad" field "b": avro: invalid default for field b. nope not a int
+builder: record "Bad": duplicate field name "b"
+builder: avro: union type must be unique
+builder: enum "E": avro: enum must have a non-empty array of symbols
+builder: invalid decimal precision 2 and scale 4
+builder: record "Bad" field "1f": invalid name 1f`, err.Error())
+}
+
+func TestRecord_MustBuildPanicsOnError(t *testing.T) {
+	assert.Panics(t, func() {
+		builder.Record("").MustBuild()
+	})
+}
+
+func TestErrorRecord(t *testing.T) {
+	schema, err := builder.ErrorRecord("Oops").Field("message", builder.String()).Build()
+	require.NoError(t, err)
+
+	assert.True(t, schema.IsError())
+}
+
+func TestBuild(t *testing.T) {
+	existing := avro.MustParse(`{"type":"enum","name":"org.Color","symbols":["RED"]}`)
+
+	schema, err := builder.Build(builder.Union(
+		builder.Null(),
+		builder.Schema(existing),
+		builder.Array(builder.Ref("org.Color")).Prop("x", 1),
+		builder.Duration("Period").Aliases("Span"),
+	))
+	require.NoError(t, err)
+
+	want := `["null",{"name":"org.Color","type":"enum","symbols":["RED"]},` +
+		`{"type":"array","items":"org.Color"},` +
+		`{"name":"Period","type":"fixed","size":12,"logicalType":"duration"}]`
+	assert.Equal(t, want, schema.String())
+	assert.Equal(t, 1, schema.(*avro.UnionSchema).Types()[2].(*avro.ArraySchema).Prop("x"))
+}
+
+func TestEnum_Build(t *testing.T) {
+	schema, err := builder.Enum("Suit", "SPADES", "HEARTS").Namespace("cards").Doc("A suit.").Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "cards.Suit", schema.FullName())
+	assert.Equal(t, "A suit.", schema.Doc())
+}
+
+func TestFixed_Build(t *testing.T) {
+	schema, err := builder.Fixed("Money", 8).Decimal(12, 4).Prop("currency", "EUR").Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Decimal, schema.Logical().Type())
+	assert.Equal(t, "EUR", schema.Prop("currency"))
+}
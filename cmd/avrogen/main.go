@@ -64,7 +64,7 @@ func realMain(args []string, stdout, stderr io.Writer) int {
 	flgs.BoolVar(&cfg.StrictTypes, "strict-types", false, "Use strict type sizes (e.g. int32) during generation.")
 	flgs.StringVar(&cfg.Initialisms, "initialisms", "", "Custom initialisms <VAL>[,...] for struct and field names.")
 	flgs.StringVar(&cfg.TemplateFileName, "template-filename", "", "Override output template with one loaded from file.")
-	flgs.StringVar(&cfg.SchemaRegistry, "schemaregistry", "", "The URL to schema registry, e.g.: http://localhost:8081.")
+	flgs.StringVar(&cfg.SchemaRegistry, "schemaregistry", "", "The URL to schema registry, e.g.: http://localhost:8081, or the directory of a registry snapshot.")
 	flgs.BoolVar(&cfg.EnumsGen, "enums", false, "Generate Go enums for Avro enums.")
 	var lt logicalTypes
 	flgs.Var(&lt, "logicaltype",
@@ -122,13 +122,26 @@ func realMain(args []string, stdout, stderr io.Writer) int {
 		opts = append(opts, gen.WithLogicalType(logicalType))
 	}
 
+	// IDL and schema files share a cache, so schemas can reference named types
+	// defined in earlier entries.
+	parser := avro.NewSchemaParser(avro.WithParserCache(avro.DefaultSchemaCache))
 	g := gen.NewGenerator(cfg.Pkg, tags, opts...)
 	for _, entry := range flgs.Args() {
 		var schema avro.Schema
 
-		switch cfg.SchemaRegistry {
-		case "":
-			schema, err = avro.ParseFiles(filepath.Clean(entry))
+		switch {
+		case cfg.SchemaRegistry == "" && filepath.Ext(entry) == ".avdl":
+			idl, err := parser.ParseIDLFile(filepath.Clean(entry))
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 2
+			}
+			for _, typ := range idl.Types() {
+				g.Parse(typ)
+			}
+			continue
+		case cfg.SchemaRegistry == "":
+			schema, err = parser.ParseFiles(filepath.Clean(entry))
 			if err != nil {
 				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
 				return 2
@@ -297,8 +310,15 @@ func splitParameterEntry(entry string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
+func newRegistry(schemaRegistry string) (registry.Registry, error) {
+	if fi, err := os.Stat(schemaRegistry); err == nil && fi.IsDir() {
+		return registry.NewFileRegistry(schemaRegistry)
+	}
+	return registry.NewClient(schemaRegistry)
+}
+
 func schemaFromRegistry(schemaRegistry, entry string) (avro.Schema, error) {
-	client, err := registry.NewClient(schemaRegistry)
+	client, err := newRegistry(schemaRegistry)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +74,54 @@ func TestAvroGen_GeneratesSchemaStdout(t *testing.T) {
 	assert.Equal(t, want, buf.Bytes())
 }
 
+func TestAvroGen_GeneratesSchemaFromIDL(t *testing.T) {
+	var buf bytes.Buffer
+
+	args := []string{"avrogen", "-pkg", "testpkg", "-pkgdoc", "package testpkg is generated from schema.avsc", "testdata/schema.avdl"}
+	gotCode := realMain(args, &buf, io.Discard)
+	require.Equal(t, 0, gotCode)
+
+	want, err := os.ReadFile("testdata/golden.go")
+	require.NoError(t, err)
+	assert.Equal(t, want, buf.Bytes())
+}
+
+func TestAvroGen_GeneratesSchemaFromIDLAndSchemaFiles(t *testing.T) {
+	dir := t.TempDir()
+	idl := filepath.Join(dir, "inner.avdl")
+	err := os.WriteFile(idl, []byte(`@namespace("mixed") protocol P { record Inner { int id; } }`), 0o600)
+	require.NoError(t, err)
+	schema := filepath.Join(dir, "outer.avsc")
+	err = os.WriteFile(schema, []byte(`{"type":"record","name":"mixed.Outer","fields":[{"name":"inner","type":"Inner"}]}`), 0o600)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+
+	args := []string{"avrogen", "-pkg", "testpkg", idl, schema}
+	gotCode := realMain(args, &buf, io.Discard)
+
+	require.Equal(t, 0, gotCode)
+	assert.Contains(t, buf.String(), "type Outer struct")
+	assert.Contains(t, buf.String(), "Inner Inner `avro:\"inner\"`")
+}
+
+func TestAvroGen_GeneratesSchemaFromRegistrySnapshot(t *testing.T) {
+	tests := []string{"test-value:latest", "test-value:1"}
+
+	for _, entry := range tests {
+		t.Run(entry, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			args := []string{"avrogen", "-pkg", "testpkg", "-pkgdoc", "package testpkg is generated from schema.avsc", "-schemaregistry", "testdata/snapshot", entry}
+			gotCode := realMain(args, &buf, io.Discard)
+			require.Equal(t, 0, gotCode)
+
+			want, err := os.ReadFile("testdata/golden.go")
+			require.NoError(t, err)
+			assert.Equal(t, want, buf.Bytes())
+		})
+	}
+}
+
 func TestAvroGen_GeneratesSchema(t *testing.T) {
 	path, err := os.MkdirTemp("./", "avrogen")
 	require.NoError(t, err)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aryehlev/avro/v2/ocf"
+)
+
+func main() {
+	os.Exit(realMain(os.Args, os.Stdout, os.Stderr))
+}
+
+func realMain(args []string, stdout, stderr io.Writer) int {
+	flgs := flag.NewFlagSet("avrosalvage", flag.ExitOnError)
+	flgs.SetOutput(stderr)
+	flgs.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "Usage: avrosalvage input output")
+		_, _ = fmt.Fprintln(stderr, "\nWrites the records that can be recovered from a corrupt or truncated container file to a new file.")
+	}
+	if err := flgs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if flgs.NArg() != 2 {
+		_, _ = fmt.Fprintln(stderr, "Error: an input and an output file are required")
+		return 1
+	}
+
+	res, err := salvage(flgs.Arg(0), flgs.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	for _, c := range res.Corrupt {
+		_, _ = fmt.Fprintf(stdout, "skipped bytes %d-%d: %v\n", c.Start, c.End, c.Err)
+	}
+	_, _ = fmt.Fprintf(stdout, "recovered %d records\n", res.Records)
+
+	return 0
+}
+
+func salvage(input, output string) (ocf.SalvageResult, error) {
+	in, err := os.Open(input)
+	if err != nil {
+		return ocf.SalvageResult{}, err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(output)
+	if err != nil {
+		return ocf.SalvageResult{}, err
+	}
+
+	res, err := ocf.Salvage(out, in, nil)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return res, err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvroSalvage_RequiredArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+	}{
+		{
+			name:         "validates files are set",
+			args:         []string{"avrosalvage"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "validates output is set",
+			args:         []string{"avrosalvage", "some/file"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "validates input exists",
+			args:         []string{"avrosalvage", "some/file", filepath.Join(t.TempDir(), "out.avro")},
+			wantExitCode: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := realMain(test.args, io.Discard, io.Discard)
+
+			assert.Equal(t, test.wantExitCode, got)
+		})
+	}
+}
+
+func TestAvroSalvage_RecoversTruncatedFile(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(`"string"`, buf, ocf.WithBlockLength(2))
+	require.NoError(t, err)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, enc.Encode(s))
+	}
+	require.NoError(t, enc.Close())
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.avro")
+	output := filepath.Join(dir, "out.avro")
+	b := buf.Bytes()
+	require.NoError(t, os.WriteFile(input, b[:len(b)-3], 0o600))
+
+	stdout := &bytes.Buffer{}
+	got := realMain([]string{"avrosalvage", input, output}, stdout, io.Discard)
+
+	require.Equal(t, 0, got)
+	assert.Contains(t, stdout.String(), "recovered 4 records")
+
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	dec, err := ocf.NewDecoder(f)
+	require.NoError(t, err)
+	var vals []string
+	for dec.HasNext() {
+		var v string
+		require.NoError(t, dec.Decode(&v))
+		vals = append(vals, v)
+	}
+	require.NoError(t, dec.Error())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, vals)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/aryehlev/avro/v2"
 )
@@ -26,7 +28,7 @@ func realMain(args []string, stdout, stderr io.Writer) int {
 		_, _ = fmt.Fprintln(stderr, "Usage: avrosv [options] schemas")
 		_, _ = fmt.Fprintln(stderr, "Options:")
 		flgs.PrintDefaults()
-		_, _ = fmt.Fprintln(stderr, "\nSchemas are processed in the order they appear.")
+		_, _ = fmt.Fprintln(stderr, "\nSchemas are processed in the order they appear. Avro IDL (.avdl) files are accepted.")
 	}
 	if err := flgs.Parse(args[1:]); err != nil {
 		return 1
@@ -36,7 +38,7 @@ func realMain(args []string, stdout, stderr io.Writer) int {
 		return 1
 	}
 
-	schema, err := avro.ParseFiles(flgs.Args()...)
+	schema, err := parseFiles(flgs.Args()...)
 	if err != nil {
 		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
 		return 2
@@ -48,3 +50,47 @@ func realMain(args []string, stdout, stderr io.Writer) int {
 
 	return 0
 }
+
+// parseFiles parses the schema and IDL files in order, returning the last schema or protocol.
+func parseFiles(paths ...string) (fmt.Stringer, error) {
+	p := avro.NewSchemaParser(avro.WithParserCache(avro.DefaultSchemaCache))
+
+	var last fmt.Stringer
+	for _, path := range paths {
+		if filepath.Ext(path) != ".avdl" {
+			schema, err := p.ParseFiles(path)
+			if err != nil {
+				return nil, err
+			}
+			last = schema
+			continue
+		}
+
+		idl, err := p.ParseIDLFile(path)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case idl.Schema() != nil:
+			last = idl.Schema()
+		case idl.Protocol() != nil:
+			last = idl.Protocol()
+		default:
+			last = namedTypes(idl.Types())
+		}
+	}
+
+	return last, nil
+}
+
+// namedTypes are the named types declared by an IDL file without a protocol or
+// main schema, printed one per line.
+type namedTypes []avro.NamedSchema
+
+func (t namedTypes) String() string {
+	lines := make([]string, len(t))
+	for i, schema := range t {
+		lines[i] = schema.String()
+	}
+	return strings.Join(lines, "\n")
+}
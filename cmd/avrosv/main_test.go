@@ -73,6 +73,16 @@ func TestAvroSv_ValidatesSchema(t *testing.T) {
 			args:         []string{"avrosv", "testdata/schema.avsc", "testdata/withref-schema.avsc"},
 			wantExitCode: 0,
 		},
+		{
+			name:         "validates an idl file",
+			args:         []string{"avrosv", "testdata/schema.avdl"},
+			wantExitCode: 0,
+		},
+		{
+			name:         "validates a schema with a reference to a type from an idl file",
+			args:         []string{"avrosv", "testdata/schema.avdl", "testdata/withref-schema.avsc"},
+			wantExitCode: 0,
+		},
 	}
 
 	for _, test := range tests {
@@ -105,6 +115,18 @@ func TestAvroSv_Verbose(t *testing.T) {
 			wantStdout:   "{\"name\":\"testref\",\"type\":\"record\",\"fields\":[{\"name\":\"someref\",\"type\":{\"name\":\"test\",\"type\":\"record\",\"fields\":[{\"name\":\"someString\",\"type\":\"string\"}]}}]}\n",
 			wantExitCode: 0,
 		},
+		{
+			name:         "dumps the main schema of an idl file",
+			args:         []string{"avrosv", "-v", "testdata/schema.avdl"},
+			wantStdout:   "{\"name\":\"test\",\"type\":\"record\",\"fields\":[{\"name\":\"someString\",\"type\":\"string\"}]}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "dumps the types of an idl file without a main schema",
+			args:         []string{"avrosv", "-v", "testdata/types.avdl"},
+			wantStdout:   "{\"name\":\"org.example.Kind\",\"type\":\"enum\",\"symbols\":[\"A\",\"B\"]}\n{\"name\":\"org.example.Id\",\"type\":\"fixed\",\"size\":2}\n",
+			wantExitCode: 0,
+		},
 		{
 			name:         "does not dump any schema when the schema file is invalid",
 			args:         []string{"avrosv", "-v", "testdata/bad-schema.avsc"},
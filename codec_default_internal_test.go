@@ -48,6 +48,7 @@ func TestDecoder_InvalidDefault(t *testing.T) {
 	schema.(*RecordSchema).Fields()[1].action = FieldSetDefault
 	// alter default value to force encoding failure
 	schema.(*RecordSchema).fields[1].def = "invalid value"
+	schema.(*RecordSchema).fields[1].encDef = "invalid value"
 
 	dec := NewDecoderForSchema(schema, bytes.NewReader(data))
 
@@ -448,6 +449,56 @@ func TestDecoder_DefaultUnion(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, TestRecord{B: map[string]any{"string": "bar"}, A: "foo"}, got)
 	})
+
+	t.Run("non first type default", func(t *testing.T) {
+		type TestRecord struct {
+			A string `avro:"a"`
+			B *int64 `avro:"b"`
+		}
+
+		p := NewSchemaParser(WithParserUnionDefaultMode(UnionDefaultAnyType))
+		schema := p.MustParse(`{
+			"type": "record",
+			"name": "test",
+			"fields" : [
+				{"name": "a", "type": "string"},
+				{"name": "b", "type": ["null", "long"], "default": 5}
+			]
+		}`)
+
+		schema.(*RecordSchema).Fields()[1].action = FieldSetDefault
+
+		var got TestRecord
+		err := NewDecoderForSchema(schema, bytes.NewReader(data)).Decode(&got)
+
+		require.NoError(t, err)
+		want := int64(5)
+		assert.Equal(t, TestRecord{B: &want, A: "foo"}, got)
+	})
+
+	t.Run("record default", func(t *testing.T) {
+		type TestRecord struct {
+			A string `avro:"a"`
+			B any    `avro:"b"`
+		}
+
+		schema := MustParse(`{
+			"type": "record",
+			"name": "test",
+			"fields" : [
+				{"name": "a", "type": "string"},
+				{"name": "b", "type": [{"type": "record", "name": "sub", "fields": [{"name": "c", "type": "int"}]}, "null"], "default": {"c": 3}}
+			]
+		}`)
+
+		schema.(*RecordSchema).Fields()[1].action = FieldSetDefault
+
+		var got TestRecord
+		err := NewDecoderForSchema(schema, bytes.NewReader(data)).Decode(&got)
+
+		require.NoError(t, err)
+		assert.Equal(t, TestRecord{B: map[string]any{"sub": map[string]any{"c": 3}}, A: "foo"}, got)
+	})
 }
 
 func TestDecoder_DefaultArray(t *testing.T) {
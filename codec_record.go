@@ -201,6 +201,10 @@ func encoderOfStruct(e *encoderContext, rec *RecordSchema, typ reflect2.Type) Va
 			}
 		}
 
+		if field.Type().Type() == Union {
+			def = field.encDef
+		}
+
 		defaultType := reflect2.TypeOf(def)
 		defaultEncoder := encoderOfType(e, field.Type(), defaultType)
 		if defaultType.LikePtr() {
@@ -345,10 +349,7 @@ func encoderOfRecord(e *encoderContext, rec *RecordSchema, typ reflect2.Type) Va
 		if field.HasDefault() {
 			switch {
 			case field.Type().Type() == Union:
-				union := field.Type().(*UnionSchema)
-				fields[i].def = map[string]any{
-					string(union.Types()[0].Type()): field.Default(),
-				}
+				fields[i].def = field.encDef
 			case field.Default() == nil:
 				continue
 			}
@@ -3,6 +3,7 @@ module github.com/aryehlev/avro/v2
 go 1.24.0
 
 require (
+	github.com/dsnet/compress v0.0.1
 	github.com/ettle/strcase v0.2.0
 	github.com/go-viper/mapstructure/v2 v2.4.0
 	github.com/golang/snappy v1.0.0
@@ -10,6 +11,7 @@ require (
 	github.com/klauspost/compress v1.18.2
 	github.com/modern-go/reflect2 v1.0.2
 	github.com/stretchr/testify v1.9.0
+	github.com/ulikunitz/xz v0.5.17
 	golang.org/x/tools v0.39.0
 )
 
@@ -0,0 +1,931 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// IDL is a parsed Avro IDL file.
+//
+// An IDL file either declares a protocol, or, using the schema syntax, a set of
+// named types and optionally a main schema.
+type IDL struct {
+	protocol *Protocol
+	schema   Schema
+	types    []NamedSchema
+}
+
+// Protocol returns the protocol declared by the IDL or nil if the IDL uses the schema syntax.
+func (i *IDL) Protocol() *Protocol {
+	return i.protocol
+}
+
+// Schema returns the main schema declared by the IDL or nil if there is none.
+func (i *IDL) Schema() Schema {
+	return i.schema
+}
+
+// Types returns the named types declared or imported by the IDL.
+func (i *IDL) Types() []NamedSchema {
+	return i.types
+}
+
+// ParseIDL parses an Avro IDL document.
+//
+// Imports are resolved relative to the working directory.
+func ParseIDL(idl string) (*IDL, error) {
+	p := newIDLParser(&SchemaCache{}, UnionDefaultFirstType)
+	return p.parse([]byte(idl), "", ".")
+}
+
+// MustParseIDL parses an Avro IDL document, panicking if there is an error.
+func MustParseIDL(idl string) *IDL {
+	parsed, err := ParseIDL(idl)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+// ParseIDLFile parses an Avro IDL file.
+//
+// Imports are resolved relative to the directory of the file.
+func ParseIDLFile(path string) (*IDL, error) {
+	return parseIDLFile(path, &SchemaCache{}, UnionDefaultFirstType)
+}
+
+// ParseIDLFile parses an Avro IDL file, resolving names from the parser cache.
+//
+// Unless registration is disabled, the named types of the IDL are added to the parser cache.
+func (p *SchemaParser) ParseIDLFile(path string) (*IDL, error) {
+	cache := &SchemaCache{}
+	cache.AddAll(p.cache)
+
+	idl, err := parseIDLFile(path, cache, p.unionDefaults)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.noRegister {
+		p.cache.AddAll(cache)
+	}
+	return idl, nil
+}
+
+func parseIDLFile(path string, cache *SchemaCache, unionDefaults UnionDefaultMode) (*IDL, error) {
+	path = filepath.Clean(path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newIDLParser(cache, unionDefaults)
+	if abs, err := filepath.Abs(path); err == nil {
+		p.imported[abs] = struct{}{}
+	}
+	return p.parse(b, path, filepath.Dir(path))
+}
+
+type idlParser struct {
+	cache    *SchemaCache
+	seen     seenCache
+	imported map[string]struct{}
+}
+
+func newIDLParser(cache *SchemaCache, unionDefaults UnionDefaultMode) *idlParser {
+	return &idlParser{
+		cache:    cache,
+		seen:     newSeenCache(unionDefaults),
+		imported: map[string]struct{}{},
+	}
+}
+
+func (p *idlParser) parse(src []byte, file, dir string) (*IDL, error) {
+	toks, err := tokenizeIDL(src, file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &idlFile{idlParser: p, toks: toks, file: file, dir: dir}
+	return f.parse()
+}
+
+type idlFile struct {
+	*idlParser
+
+	toks []idlToken
+	pos  int
+	file string
+	dir  string
+
+	namespace string
+	types     []NamedSchema
+	messages  map[string]*Message
+}
+
+type idlAnnotation struct {
+	name  string
+	value any
+}
+
+func (f *idlFile) parse() (*IDL, error) {
+	doc := f.peek().doc
+	annots, err := f.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.peek().isKeyword("protocol") {
+		proto, err := f.parseProtocol(doc, annots)
+		if err != nil {
+			return nil, err
+		}
+		if tok := f.peek(); tok.kind != idlEOF {
+			return nil, f.errorf(tok, "unexpected %s after protocol", tok)
+		}
+		return &IDL{protocol: proto, types: proto.types}, nil
+	}
+
+	// Annotations belong to the first declaration, rewind to parse them again.
+	f.pos = 0
+	return f.parseSchemaSyntax()
+}
+
+func (f *idlFile) parseProtocol(doc string, annots []idlAnnotation) (*Protocol, error) {
+	f.next()
+
+	name, err := f.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	props := map[string]any{}
+	for _, a := range annots {
+		if a.name == "namespace" {
+			ns, ok := a.value.(string)
+			if !ok {
+				return nil, fmt.Errorf("avro: idl: protocol %s namespace must be a string", name)
+			}
+			f.namespace = ns
+			continue
+		}
+		props[a.name] = a.value
+	}
+	if idx := strings.LastIndexByte(name, '.'); idx > -1 && f.namespace == "" {
+		f.namespace = name[:idx]
+	}
+
+	if err = f.expect("{"); err != nil {
+		return nil, err
+	}
+
+	f.messages = map[string]*Message{}
+	for !f.peek().is("}") {
+		if f.peek().kind == idlEOF {
+			return nil, f.errorf(f.peek(), "unexpected end of file in protocol %s", name)
+		}
+		if err = f.parseDeclaration(true); err != nil {
+			return nil, err
+		}
+	}
+	f.next()
+
+	return NewProtocol(name, f.namespace, f.types, f.messages, WithProtoDoc(doc), WithProtoProps(props))
+}
+
+func (f *idlFile) parseSchemaSyntax() (*IDL, error) {
+	if f.peek().isKeyword("namespace") {
+		f.next()
+		ns, err := f.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err = f.expect(";"); err != nil {
+			return nil, err
+		}
+		f.namespace = ns
+	}
+
+	var main *idlType
+	if f.peek().isKeyword("schema") {
+		f.next()
+		typ, err := f.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err = f.expect(";"); err != nil {
+			return nil, err
+		}
+		main = typ
+	}
+
+	for f.peek().kind != idlEOF {
+		if err := f.parseDeclaration(false); err != nil {
+			return nil, err
+		}
+	}
+
+	if main == nil && len(f.types) == 0 {
+		return nil, errors.New("avro: idl: no protocol, schema or named types declared")
+	}
+
+	idl := &IDL{types: f.types}
+	if main != nil {
+		schema, err := parseType(f.namespace, main.json(false), f.seen, f.cache)
+		if err != nil {
+			return nil, err
+		}
+		idl.schema = derefSchema(schema)
+	}
+	return idl, nil
+}
+
+func (f *idlFile) parseDeclaration(inProtocol bool) error {
+	if f.peek().isKeyword("import") {
+		return f.parseImport()
+	}
+
+	doc := f.peek().doc
+	annots, err := f.parseAnnotations()
+	if err != nil {
+		return err
+	}
+
+	tok := f.peek()
+	switch {
+	case tok.isKeyword("record"), tok.isKeyword("error"):
+		return f.parseRecord(doc, annots)
+	case tok.isKeyword("enum"):
+		return f.parseEnum(doc, annots)
+	case tok.isKeyword("fixed"):
+		return f.parseFixed(doc, annots)
+	case inProtocol:
+		return f.parseMessage(doc, annots)
+	default:
+		return f.errorf(tok, "unexpected %s, expected a named type declaration", tok)
+	}
+}
+
+func (f *idlFile) parseImport() error {
+	f.next()
+
+	kind, err := f.expectIdent()
+	if err != nil {
+		return err
+	}
+	tok := f.next()
+	if tok.kind != idlString {
+		return f.errorf(tok, "expected import path, found %s", tok)
+	}
+	var path string
+	if err = jsoniter.Unmarshal([]byte(tok.text), &path); err != nil {
+		return f.errorf(tok, "invalid import path %s", tok.text)
+	}
+	if err = f.expect(";"); err != nil {
+		return err
+	}
+
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.dir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.imported[abs]; ok {
+		return nil
+	}
+	f.imported[abs] = struct{}{}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "idl":
+		toks, err := tokenizeIDL(b, path)
+		if err != nil {
+			return err
+		}
+		imp := &idlFile{idlParser: f.idlParser, toks: toks, file: path, dir: filepath.Dir(path)}
+		idl, err := imp.parse()
+		if err != nil {
+			return err
+		}
+		f.types = append(f.types, idl.types...)
+		if idl.protocol != nil {
+			f.addMessages(idl.protocol.messages)
+		}
+
+	case "protocol":
+		var m map[string]any
+		if err = jsoniter.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("avro: idl: invalid protocol %s: %w", path, err)
+		}
+		proto, err := parseProtocol(m, f.seen, f.cache)
+		if err != nil {
+			return err
+		}
+		f.types = append(f.types, proto.types...)
+		f.addMessages(proto.messages)
+
+	case "schema":
+		var json any
+		if err = jsoniter.Unmarshal(b, &json); err != nil {
+			return fmt.Errorf("avro: idl: invalid schema %s: %w", path, err)
+		}
+		schema, err := parseType("", json, f.seen, f.cache)
+		if err != nil {
+			return err
+		}
+		if named, ok := schema.(NamedSchema); ok {
+			f.types = append(f.types, named)
+		}
+
+	default:
+		return f.errorf(tok, "unknown import kind %q", kind)
+	}
+	return nil
+}
+
+func (f *idlFile) addMessages(msgs map[string]*Message) {
+	if f.messages == nil {
+		return
+	}
+	for k, msg := range msgs {
+		f.messages[k] = msg
+	}
+}
+
+func (f *idlFile) parseRecord(doc string, annots []idlAnnotation) error {
+	typ := f.next().text
+
+	name, err := f.expectIdent()
+	if err != nil {
+		return err
+	}
+	if err = f.expect("{"); err != nil {
+		return err
+	}
+
+	fields := []any{}
+	for !f.peek().is("}") {
+		if f.peek().kind == idlEOF {
+			return f.errorf(f.peek(), "unexpected end of file in record %s", name)
+		}
+		fs, err := f.parseFields()
+		if err != nil {
+			return err
+		}
+		fields = append(fields, fs...)
+	}
+	f.next()
+
+	m := namedTypeJSON(typ, name, doc, annots)
+	m["fields"] = fields
+	return f.addType(m)
+}
+
+func (f *idlFile) parseFields() ([]any, error) {
+	doc := f.peek().doc
+	typ, err := f.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []any
+	for {
+		field, err := f.parseVariable(typ, doc)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+
+		if !f.peek().is(",") {
+			break
+		}
+		f.next()
+	}
+
+	if err = f.expect(";"); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (f *idlFile) parseVariable(typ *idlType, doc string) (map[string]any, error) {
+	if d := f.peek().doc; d != "" {
+		doc = d
+	}
+	annots, err := f.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := f.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	field := map[string]any{"name": name}
+	for _, a := range annots {
+		field[a.name] = a.value
+	}
+	if doc != "" {
+		field["doc"] = doc
+	}
+
+	nonNullDefault := false
+	if f.peek().is("=") {
+		f.next()
+		def, err := f.parseJSON()
+		if err != nil {
+			return nil, err
+		}
+		field["default"] = def
+		nonNullDefault = def != nil
+	}
+	field["type"] = typ.json(nonNullDefault)
+
+	return field, nil
+}
+
+func (f *idlFile) parseEnum(doc string, annots []idlAnnotation) error {
+	f.next()
+
+	name, err := f.expectIdent()
+	if err != nil {
+		return err
+	}
+	if err = f.expect("{"); err != nil {
+		return err
+	}
+
+	symbols := []any{}
+	for !f.peek().is("}") {
+		sym, err := f.expectIdent()
+		if err != nil {
+			return err
+		}
+		symbols = append(symbols, sym)
+
+		if !f.peek().is(",") {
+			break
+		}
+		f.next()
+	}
+	if err = f.expect("}"); err != nil {
+		return err
+	}
+
+	m := namedTypeJSON("enum", name, doc, annots)
+	m["symbols"] = symbols
+	if f.peek().is("=") {
+		f.next()
+		def, err := f.expectIdent()
+		if err != nil {
+			return err
+		}
+		m["default"] = def
+		if err = f.expect(";"); err != nil {
+			return err
+		}
+	} else if f.peek().is(";") {
+		f.next()
+	}
+
+	return f.addType(m)
+}
+
+func (f *idlFile) parseFixed(doc string, annots []idlAnnotation) error {
+	f.next()
+
+	name, err := f.expectIdent()
+	if err != nil {
+		return err
+	}
+	if err = f.expect("("); err != nil {
+		return err
+	}
+	size, err := f.expectInt()
+	if err != nil {
+		return err
+	}
+	if err = f.expect(")"); err != nil {
+		return err
+	}
+	if err = f.expect(";"); err != nil {
+		return err
+	}
+
+	m := namedTypeJSON("fixed", name, doc, annots)
+	m["size"] = size
+	return f.addType(m)
+}
+
+func (f *idlFile) addType(m map[string]any) error {
+	schema, err := parseType(f.namespace, m, f.seen, f.cache)
+	if err != nil {
+		return err
+	}
+
+	named, ok := schema.(NamedSchema)
+	if !ok {
+		return errors.New("avro: idl: declared types must be named schemas")
+	}
+	f.types = append(f.types, named)
+	return nil
+}
+
+func (f *idlFile) parseMessage(doc string, annots []idlAnnotation) error {
+	var resp any = string(Null)
+	if f.peek().isKeyword("void") {
+		f.next()
+	} else {
+		typ, err := f.parseType()
+		if err != nil {
+			return err
+		}
+		resp = typ.json(false)
+	}
+
+	tok := f.peek()
+	name, err := f.expectIdent()
+	if err != nil {
+		return err
+	}
+	if err = f.expect("("); err != nil {
+		return err
+	}
+
+	req := []any{}
+	for !f.peek().is(")") {
+		doc := f.peek().doc
+		typ, err := f.parseType()
+		if err != nil {
+			return err
+		}
+		param, err := f.parseVariable(typ, doc)
+		if err != nil {
+			return err
+		}
+		req = append(req, param)
+
+		if !f.peek().is(",") {
+			break
+		}
+		f.next()
+	}
+	if err = f.expect(")"); err != nil {
+		return err
+	}
+
+	m := map[string]any{
+		"request":  req,
+		"response": resp,
+	}
+	for _, a := range annots {
+		m[a.name] = a.value
+	}
+	if doc != "" {
+		m["doc"] = doc
+	}
+
+	switch {
+	case f.peek().isKeyword("oneway"):
+		f.next()
+		m["one-way"] = true
+	case f.peek().isKeyword("throws"):
+		f.next()
+		var errs []any
+		for {
+			e, err := f.expectIdent()
+			if err != nil {
+				return err
+			}
+			errs = append(errs, e)
+
+			if !f.peek().is(",") {
+				break
+			}
+			f.next()
+		}
+		m["errors"] = errs
+	}
+	if err = f.expect(";"); err != nil {
+		return err
+	}
+
+	if _, ok := f.messages[name]; ok {
+		return f.errorf(tok, "duplicate message %q", name)
+	}
+	msg, err := parseMessage(f.namespace, m, f.seen, f.cache)
+	if err != nil {
+		return err
+	}
+	f.messages[name] = msg
+	return nil
+}
+
+// idlType is a type reference in an IDL file, in its JSON form.
+type idlType struct {
+	typ      any
+	nullable bool
+}
+
+// json returns the JSON form of the type. Nullable types become a union with
+// null, which comes first unless the field has a non-null default.
+func (t *idlType) json(nonNullDefault bool) any {
+	if !t.nullable {
+		return t.typ
+	}
+	if nonNullDefault {
+		return []any{t.typ, string(Null)}
+	}
+	return []any{string(Null), t.typ}
+}
+
+var idlLogicalTypes = map[string]map[string]any{
+	"date":               {"type": string(Int), "logicalType": string(Date)},
+	"time_ms":            {"type": string(Int), "logicalType": string(TimeMillis)},
+	"timestamp_ms":       {"type": string(Long), "logicalType": string(TimestampMillis)},
+	"local_timestamp_ms": {"type": string(Long), "logicalType": string(LocalTimestampMillis)},
+	"uuid":               {"type": string(String), "logicalType": string(UUID)},
+}
+
+func (f *idlFile) parseType() (*idlType, error) {
+	annots, err := f.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+
+	typ, err := f.parseBaseType()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(annots) > 0 {
+		m, ok := typ.(map[string]any)
+		if !ok {
+			m = map[string]any{"type": typ}
+		}
+		for _, a := range annots {
+			m[a.name] = a.value
+		}
+		typ = m
+	}
+
+	t := &idlType{typ: typ}
+	if f.peek().is("?") {
+		f.next()
+		t.nullable = true
+	}
+	return t, nil
+}
+
+func (f *idlFile) parseBaseType() (any, error) {
+	tok := f.next()
+	if tok.kind != idlIdent {
+		return nil, f.errorf(tok, "expected a type, found %s", tok)
+	}
+	if tok.escaped {
+		return tok.text, nil
+	}
+
+	switch tok.text {
+	case "boolean", "int", "long", "float", "double", "bytes", "string", "null":
+		return tok.text, nil
+
+	case "array", "map":
+		if err := f.expect("<"); err != nil {
+			return nil, err
+		}
+		inner, err := f.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err = f.expect(">"); err != nil {
+			return nil, err
+		}
+		if tok.text == "array" {
+			return map[string]any{"type": "array", "items": inner.json(false)}, nil
+		}
+		return map[string]any{"type": "map", "values": inner.json(false)}, nil
+
+	case "union":
+		if err := f.expect("{"); err != nil {
+			return nil, err
+		}
+		var types []any
+		for {
+			typ, err := f.parseType()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, typ.json(false))
+
+			if !f.peek().is(",") {
+				break
+			}
+			f.next()
+		}
+		if err := f.expect("}"); err != nil {
+			return nil, err
+		}
+		return types, nil
+
+	case "decimal":
+		if err := f.expect("("); err != nil {
+			return nil, err
+		}
+		prec, err := f.expectInt()
+		if err != nil {
+			return nil, err
+		}
+		scale := 0
+		if f.peek().is(",") {
+			f.next()
+			if scale, err = f.expectInt(); err != nil {
+				return nil, err
+			}
+		}
+		if err = f.expect(")"); err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"type":        string(Bytes),
+			"logicalType": string(Decimal),
+			"precision":   prec,
+			"scale":       scale,
+		}, nil
+	}
+
+	if lt, ok := idlLogicalTypes[tok.text]; ok {
+		m := make(map[string]any, len(lt))
+		for k, v := range lt {
+			m[k] = v
+		}
+		return m, nil
+	}
+
+	return tok.text, nil
+}
+
+func (f *idlFile) parseAnnotations() ([]idlAnnotation, error) {
+	var annots []idlAnnotation
+	for f.peek().kind == idlAnnot {
+		tok := f.next()
+		if err := f.expect("("); err != nil {
+			return nil, err
+		}
+		v, err := f.parseJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err = f.expect(")"); err != nil {
+			return nil, err
+		}
+		annots = append(annots, idlAnnotation{name: tok.text, value: v})
+	}
+	return annots, nil
+}
+
+func (f *idlFile) parseJSON() (any, error) {
+	tok := f.next()
+	switch tok.kind {
+	case idlString, idlNumber:
+		var v any
+		if err := jsoniter.Unmarshal([]byte(tok.text), &v); err != nil {
+			return nil, f.errorf(tok, "invalid JSON value %s", tok.text)
+		}
+		return v, nil
+
+	case idlIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+
+	case idlPunct:
+		switch tok.text {
+		case "[":
+			arr := []any{}
+			for !f.peek().is("]") {
+				v, err := f.parseJSON()
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+
+				if !f.peek().is(",") {
+					break
+				}
+				f.next()
+			}
+			if err := f.expect("]"); err != nil {
+				return nil, err
+			}
+			return arr, nil
+
+		case "{":
+			obj := map[string]any{}
+			for !f.peek().is("}") {
+				key := f.next()
+				if key.kind != idlString {
+					return nil, f.errorf(key, "expected JSON object key, found %s", key)
+				}
+				var k string
+				if err := jsoniter.Unmarshal([]byte(key.text), &k); err != nil {
+					return nil, f.errorf(key, "invalid JSON object key %s", key.text)
+				}
+				if err := f.expect(":"); err != nil {
+					return nil, err
+				}
+				v, err := f.parseJSON()
+				if err != nil {
+					return nil, err
+				}
+				obj[k] = v
+
+				if !f.peek().is(",") {
+					break
+				}
+				f.next()
+			}
+			if err := f.expect("}"); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		}
+	}
+
+	return nil, f.errorf(tok, "expected a JSON value, found %s", tok)
+}
+
+func namedTypeJSON(typ, name, doc string, annots []idlAnnotation) map[string]any {
+	m := map[string]any{"type": typ, "name": name}
+	if doc != "" {
+		m["doc"] = doc
+	}
+	for _, a := range annots {
+		m[a.name] = a.value
+	}
+	return m
+}
+
+func (f *idlFile) peek() idlToken {
+	return f.toks[f.pos]
+}
+
+func (f *idlFile) next() idlToken {
+	tok := f.toks[f.pos]
+	if tok.kind != idlEOF {
+		f.pos++
+	}
+	return tok
+}
+
+func (f *idlFile) expect(punct string) error {
+	tok := f.next()
+	if !tok.is(punct) {
+		return f.errorf(tok, "expected %q, found %s", punct, tok)
+	}
+	return nil
+}
+
+func (f *idlFile) expectIdent() (string, error) {
+	tok := f.next()
+	if tok.kind != idlIdent {
+		return "", f.errorf(tok, "expected an identifier, found %s", tok)
+	}
+	return tok.text, nil
+}
+
+func (f *idlFile) expectInt() (int, error) {
+	tok := f.next()
+	if tok.kind != idlNumber {
+		return 0, f.errorf(tok, "expected an integer, found %s", tok)
+	}
+	i, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return 0, f.errorf(tok, "expected an integer, found %s", tok)
+	}
+	return i, nil
+}
+
+func (f *idlFile) errorf(tok idlToken, format string, args ...any) error {
+	return idlErrorf(f.file, tok.line, format, args...)
+}
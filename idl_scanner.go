@@ -0,0 +1,228 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+type idlTokenKind int
+
+const (
+	idlEOF idlTokenKind = iota
+	idlIdent
+	idlString
+	idlNumber
+	idlAnnot
+	idlPunct
+)
+
+// idlToken is a lexical token of an IDL file.
+type idlToken struct {
+	kind idlTokenKind
+	text string
+	line int
+
+	// escaped is set for identifiers quoted with backticks, which are never keywords.
+	escaped bool
+	// doc is the doc comment directly preceding the token.
+	doc string
+}
+
+func (t idlToken) is(punct string) bool {
+	return t.kind == idlPunct && t.text == punct
+}
+
+func (t idlToken) isKeyword(kw string) bool {
+	return t.kind == idlIdent && !t.escaped && t.text == kw
+}
+
+// String returns a description of the token for error messages.
+func (t idlToken) String() string {
+	switch t.kind {
+	case idlEOF:
+		return "end of file"
+	case idlAnnot:
+		return `"@` + t.text + `"`
+	default:
+		return `"` + t.text + `"`
+	}
+}
+
+type idlScanner struct {
+	src  []byte
+	pos  int
+	line int
+	file string
+}
+
+func tokenizeIDL(src []byte, file string) ([]idlToken, error) {
+	s := &idlScanner{src: src, line: 1, file: file}
+
+	var toks []idlToken
+	for {
+		doc, err := s.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+
+		tok, err := s.scan()
+		if err != nil {
+			return nil, err
+		}
+		tok.doc = doc
+		toks = append(toks, tok)
+
+		if tok.kind == idlEOF {
+			return toks, nil
+		}
+	}
+}
+
+// skipSpace skips whitespace and comments, returning the last doc comment seen.
+func (s *idlScanner) skipSpace() (string, error) {
+	var doc string
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\n':
+			s.line++
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case c == '/' && s.peekByte(1) == '/':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '/' && s.peekByte(1) == '*':
+			start := s.pos
+			end := strings.Index(string(s.src[s.pos+2:]), "*/")
+			if end < 0 {
+				return "", s.errorf("unterminated comment")
+			}
+			s.pos += end + 4
+			comment := string(s.src[start:s.pos])
+			s.line += strings.Count(comment, "\n")
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = cleanIDLDoc(comment[3 : len(comment)-2])
+			}
+		default:
+			return doc, nil
+		}
+	}
+	return doc, nil
+}
+
+func (s *idlScanner) scan() (idlToken, error) {
+	if s.pos >= len(s.src) {
+		return idlToken{kind: idlEOF, line: s.line}, nil
+	}
+
+	start := s.pos
+	c := s.src[s.pos]
+	switch {
+	case isIDLIdentStart(c):
+		for s.pos < len(s.src) && isIDLIdentPart(s.src[s.pos]) {
+			s.pos++
+		}
+		return idlToken{kind: idlIdent, text: string(s.src[start:s.pos]), line: s.line}, nil
+
+	case c == '`':
+		s.pos++
+		for s.pos < len(s.src) && s.src[s.pos] != '`' {
+			if s.src[s.pos] == '\n' {
+				return idlToken{}, s.errorf("unterminated identifier")
+			}
+			s.pos++
+		}
+		if s.pos >= len(s.src) {
+			return idlToken{}, s.errorf("unterminated identifier")
+		}
+		s.pos++
+		return idlToken{kind: idlIdent, text: string(s.src[start+1 : s.pos-1]), line: s.line, escaped: true}, nil
+
+	case c == '@':
+		s.pos++
+		for s.pos < len(s.src) && (isIDLIdentPart(s.src[s.pos]) || s.src[s.pos] == '-') {
+			s.pos++
+		}
+		if s.pos == start+1 {
+			return idlToken{}, s.errorf("annotation requires a name")
+		}
+		return idlToken{kind: idlAnnot, text: string(s.src[start+1 : s.pos]), line: s.line}, nil
+
+	case c == '"':
+		s.pos++
+		for s.pos < len(s.src) && s.src[s.pos] != '"' {
+			switch s.src[s.pos] {
+			case '\\':
+				s.pos++
+			case '\n':
+				return idlToken{}, s.errorf("unterminated string")
+			}
+			s.pos++
+		}
+		if s.pos >= len(s.src) {
+			return idlToken{}, s.errorf("unterminated string")
+		}
+		s.pos++
+		return idlToken{kind: idlString, text: string(s.src[start:s.pos]), line: s.line}, nil
+
+	case isDigit(c) || (c == '-' && isDigit(s.peekByte(1))):
+		s.pos++
+		for s.pos < len(s.src) && isIDLNumberPart(s.src[s.pos]) {
+			s.pos++
+		}
+		return idlToken{kind: idlNumber, text: string(s.src[start:s.pos]), line: s.line}, nil
+
+	case strings.IndexByte("{}()<>[],;:=?", c) > -1:
+		s.pos++
+		return idlToken{kind: idlPunct, text: string(c), line: s.line}, nil
+	}
+
+	return idlToken{}, s.errorf("unexpected character %q", c)
+}
+
+func (s *idlScanner) peekByte(n int) byte {
+	if s.pos+n >= len(s.src) {
+		return 0
+	}
+	return s.src[s.pos+n]
+}
+
+func (s *idlScanner) errorf(format string, args ...any) error {
+	return idlErrorf(s.file, s.line, format, args...)
+}
+
+func idlErrorf(file string, line int, format string, args ...any) error {
+	if file == "" {
+		return fmt.Errorf("avro: idl: line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("avro: idl: %s:%d: %s", file, line, fmt.Sprintf(format, args...))
+}
+
+func isIDLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIDLIdentPart(c byte) bool {
+	return isIDLIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func isIDLNumberPart(c byte) bool {
+	return isDigit(c) || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// cleanIDLDoc strips the leading asterisks and indentation of a doc comment.
+func cleanIDLDoc(doc string) string {
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,239 @@
+package avro_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDLFile_Protocol(t *testing.T) {
+	idl, err := avro.ParseIDLFile("testdata/idl/simple.avdl")
+	require.NoError(t, err)
+
+	proto := idl.Protocol()
+	require.NotNil(t, proto)
+	assert.Nil(t, idl.Schema())
+	assert.Equal(t, "Simple", proto.Name())
+	assert.Equal(t, "org.example.simple", proto.Namespace())
+	assert.Equal(t, "An example protocol in Avro IDL.", proto.Doc())
+
+	var names []string
+	for _, typ := range idl.Types() {
+		names = append(names, typ.FullName())
+	}
+	assert.Equal(t, []string{
+		"org.example.common.Common",
+		"org.example.simple.Status",
+		"org.example.simple.Kind",
+		"org.example.simple.MD5",
+		"org.example.simple.TestRecord",
+		"org.example.simple.TestError",
+	}, names)
+
+	kind := idl.Types()[2].(*avro.EnumSchema)
+	assert.Equal(t, "A kind of record.", kind.Doc())
+	assert.Equal(t, []string{"org.foo.KindOf"}, kind.Aliases())
+	assert.Equal(t, "FOO", kind.Default())
+
+	rec := idl.Types()[4].(*avro.RecordSchema)
+	assert.Equal(t, "An example record.", rec.Doc())
+	want := `{"name":"org.example.simple.TestRecord","type":"record","fields":[` +
+		`{"name":"error","type":"string"},` +
+		`{"name":"kind","type":"org.example.simple.Kind"},` +
+		`{"name":"hash","type":"org.example.simple.MD5"},` +
+		`{"name":"nullableHash","type":["org.example.simple.MD5","null"]},` +
+		`{"name":"nickname","type":["null","string"]},` +
+		`{"name":"count","type":["long","null"]},` +
+		`{"name":"arrayOfLongs","type":{"type":"array","items":"long"}},` +
+		`{"name":"commons","type":{"type":"map","values":"org.example.common.Common"}},` +
+		`{"name":"value","type":"string"},` +
+		`{"name":"amount","type":{"type":"bytes","logicalType":"decimal","precision":9,"scale":2}},` +
+		`{"name":"birthday","type":{"type":"int","logicalType":"date"}},` +
+		`{"name":"alarm","type":{"type":"int","logicalType":"time-millis"}},` +
+		`{"name":"created","type":{"type":"long","logicalType":"timestamp-millis"}},` +
+		`{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+		`{"name":"status","type":"org.example.simple.Status"}]}`
+	assert.Equal(t, want, rec.String())
+
+	escaped := rec.Fields()[0]
+	assert.Equal(t, "Tests that keywords can be escaped.", escaped.Doc())
+	assert.Equal(t, avro.Ignore, escaped.Order())
+	assert.Equal(t, "none", escaped.Default())
+	assert.Equal(t, []string{"hash2"}, rec.Fields()[3].Aliases())
+	assert.Equal(t, int64(5), rec.Fields()[5].Default())
+	assert.Equal(t, "java.math.BigDecimal", rec.Fields()[8].Type().(*avro.PrimitiveSchema).Prop("java-class"))
+
+	hello := proto.Message("hello")
+	require.NotNil(t, hello)
+	assert.Equal(t, "Says hello.", hello.Doc())
+	assert.Equal(t, avro.String, hello.Response().Type())
+
+	add := proto.Message("add")
+	require.NotNil(t, add)
+	assert.Equal(t, 2, add.Request().Fields()[1].Default())
+
+	errMsg := proto.Message("error")
+	require.NotNil(t, errMsg)
+	assert.Nil(t, errMsg.Response())
+	assert.Len(t, errMsg.Errors().Types(), 2)
+
+	assert.True(t, proto.Message("ping").OneWay())
+	assert.NotNil(t, proto.Message("describe"))
+}
+
+func TestParseIDLFile_SchemaSyntax(t *testing.T) {
+	idl, err := avro.ParseIDLFile("testdata/idl/schema.avdl")
+	require.NoError(t, err)
+
+	assert.Nil(t, idl.Protocol())
+	assert.Len(t, idl.Types(), 3)
+	require.NotNil(t, idl.Schema())
+
+	want := `{"name":"org.example.schema.User","type":"record","fields":[` +
+		`{"name":"id","type":"long"},` +
+		`{"name":"name","type":"string"},` +
+		`{"name":"address","type":["null",{"name":"org.example.schema.Address","type":"record","fields":[{"name":"street","type":"string"}]}]},` +
+		`{"name":"tags","type":{"type":"array","items":{"name":"org.example.common.Common","type":"record","fields":[{"name":"name","type":"string"}]}}}]}`
+	assert.Equal(t, want, idl.Schema().String())
+	assert.Equal(t, "A user of the system.", idl.Schema().(*avro.RecordSchema).Doc())
+}
+
+func TestParseIDLFile_MissingImport(t *testing.T) {
+	_, err := avro.ParseIDLFile("testdata/idl/badimport.avdl")
+
+	assert.Error(t, err)
+}
+
+func TestParseIDLFile_AbsoluteImport(t *testing.T) {
+	status, err := filepath.Abs("testdata/idl/status.avsc")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "abs.avdl")
+	idl := "namespace org.example;\nimport schema " + strconv.Quote(status) + ";\nrecord R { org.example.simple.Status s; }\n"
+	require.NoError(t, os.WriteFile(path, []byte(idl), 0o600))
+
+	got, err := avro.ParseIDLFile(path)
+
+	require.NoError(t, err)
+	assert.Len(t, got.Types(), 2)
+}
+
+func TestParseIDLFile_FileDoesntExist(t *testing.T) {
+	_, err := avro.ParseIDLFile("testdata/idl/nonexistent.avdl")
+
+	assert.Error(t, err)
+}
+
+func TestParseIDL(t *testing.T) {
+	idl, err := avro.ParseIDL(`
+schema array<org.shop.Item>;
+
+@namespace("org.shop")
+@aliases(["Product"])
+record Item {
+	string name;
+	@logicalType("timestamp-micros") long updated;
+	union { null, string } note = null;
+}
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"type":"array","items":{"name":"org.shop.Item","type":"record","fields":[`+
+		`{"name":"name","type":"string"},`+
+		`{"name":"updated","type":{"type":"long","logicalType":"timestamp-micros"}},`+
+		`{"name":"note","type":["null","string"]}]}}`, idl.Schema().String())
+	assert.Equal(t, []string{"org.shop.Product"}, idl.Types()[0].Aliases())
+}
+
+func TestParseIDL_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		idl     string
+		wantErr string
+	}{
+		{
+			name:    "unknown type",
+			idl:     `protocol P { record R { Unknown u; } }`,
+			wantErr: "avro: unknown type: Unknown",
+		},
+		{
+			name:    "missing semicolon",
+			idl:     "protocol P {\n record R { int a } }",
+			wantErr: `avro: idl: line 2: expected ";", found "}"`,
+		},
+		{
+			name:    "unterminated protocol",
+			idl:     `protocol P { record R { int a; }`,
+			wantErr: "avro: idl: line 1: unexpected end of file in protocol P",
+		},
+		{
+			name:    "unterminated comment",
+			idl:     `protocol P { /* record R { int a; } }`,
+			wantErr: "avro: idl: line 1: unterminated comment",
+		},
+		{
+			name:    "invalid character",
+			idl:     `protocol P { record R { int# a; } }`,
+			wantErr: `avro: idl: line 1: unexpected character '#'`,
+		},
+		{
+			name:    "invalid default",
+			idl:     `protocol P { record R { int a = "b"; } }`,
+			wantErr: "avro: invalid default for field a. b not a int",
+		},
+		{
+			name:    "message outside protocol",
+			idl:     `string hello();`,
+			wantErr: `avro: idl: line 1: unexpected "string", expected a named type declaration`,
+		},
+		{
+			name:    "duplicate message",
+			idl:     `protocol P { void a(); void a(); }`,
+			wantErr: `avro: idl: line 1: duplicate message "a"`,
+		},
+		{
+			name:    "duplicate type",
+			idl:     `protocol P { fixed F(1); fixed F(2); }`,
+			wantErr: `duplicate name "F"`,
+		},
+		{
+			name:    "empty",
+			idl:     "// A comment.\n",
+			wantErr: "avro: idl: no protocol, schema or named types declared",
+		},
+		{
+			name:    "throws a non error record",
+			idl:     `protocol P { record R { int a; } void a() throws R; }`,
+			wantErr: "avro: errors record schema must be of type error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := avro.ParseIDL(test.idl)
+
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestMustParseIDL_PanicsOnError(t *testing.T) {
+	assert.Panics(t, func() {
+		avro.MustParseIDL("protocol")
+	})
+}
+
+func TestSchemaParser_ParseIDLFile(t *testing.T) {
+	p := avro.NewSchemaParser()
+
+	_, err := p.ParseIDLFile("testdata/idl/schema.avdl")
+	require.NoError(t, err)
+
+	schema, err := p.Parse(`{"type":"array","items":"org.example.schema.User"}`)
+	require.NoError(t, err)
+	assert.Equal(t, avro.Array, schema.Type())
+}
@@ -0,0 +1,362 @@
+package ocf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// Block is a block of values in a container file.
+type Block struct {
+	// Count is the number of values in the block.
+	Count int64
+	// Offset is the position of the block in the file, in bytes.
+	Offset int64
+	// CompressedSize is the size of the block data in the file, in bytes.
+	CompressedSize int64
+	// Data is the encoded values, compressed with the codec of the file.
+	Data []byte
+}
+
+// BlockReader reads the blocks of a container file without decoding their values.
+type BlockReader struct {
+	reader  *avro.Reader
+	counter *countingReader
+	meta    map[string][]byte
+	sync    [16]byte
+	schema  avro.Schema
+
+	codec     Codec
+	codecName CodecName
+	t         *transformer
+	// transformed is set when the file declares block transforms that are not configured.
+	transformed bool
+
+	err error
+}
+
+// NewBlockReader returns a new block reader that reads from reader r.
+func NewBlockReader(r io.Reader, opts ...DecoderFunc) (*BlockReader, error) {
+	cfg := computeDecoderConfig(opts)
+
+	counter := &countingReader{r: r}
+	reader := avro.NewReader(counter, 1024)
+
+	h, err := readHeader(reader, cfg.SchemaCache, cfg.CodecOptions, codecModeDecode)
+	if err != nil {
+		return nil, fmt.Errorf("block reader: %w", err)
+	}
+
+	br := &BlockReader{
+		reader:      reader,
+		counter:     counter,
+		meta:        h.Meta,
+		sync:        h.Sync,
+		schema:      h.Schema,
+		codec:       h.Codec,
+		codecName:   CodecName(h.Meta[codecKey]),
+		transformed: len(h.Meta[transformsKey]) > 0,
+	}
+	if err = br.setTransforms(cfg.Transforms); err != nil {
+		_ = br.Close()
+		return nil, fmt.Errorf("block reader: %w", err)
+	}
+	return br, nil
+}
+
+// setTransforms sets the block transforms reversed when reading blocks. Blocks
+// with transforms that are not configured are read as they are.
+func (r *BlockReader) setTransforms(ts []BlockTransform) error {
+	if len(ts) == 0 && r.transformed {
+		return nil
+	}
+
+	decodeFns, err := decodeTransforms(ts, r.meta)
+	if err != nil {
+		return err
+	}
+	r.t = newTransformer(r.sync, r.meta, nil, decodeFns)
+	r.transformed = false
+	return nil
+}
+
+// transformedError returns the error of reading blocks with transforms that are not configured.
+func (r *BlockReader) transformedError() error {
+	return fmt.Errorf("blocks are transformed with %s, which must be configured to read them", r.meta[transformsKey])
+}
+
+// Metadata returns the header metadata.
+func (r *BlockReader) Metadata() map[string][]byte {
+	return r.meta
+}
+
+// Schema returns the schema of the file.
+func (r *BlockReader) Schema() avro.Schema {
+	return r.schema
+}
+
+// Sync returns the sync marker of the file.
+func (r *BlockReader) Sync() [16]byte {
+	return r.sync
+}
+
+// Codec returns the codec of the file, used to decompress block data.
+func (r *BlockReader) Codec() Codec {
+	return r.codec
+}
+
+// ReadBlock reads the next block. It returns io.EOF when there are no more blocks.
+//
+// The block transforms declared by the file are reversed only if they are
+// configured, in which case the final block of the file is checked rather than
+// returned. Otherwise the block data is returned as it is stored, and the
+// blocks cannot be written to another file.
+func (r *BlockReader) ReadBlock() (Block, error) {
+	if r.err != nil {
+		return Block{}, r.err
+	}
+
+	_ = r.reader.Peek()
+	if errors.Is(r.reader.Error, io.EOF) && r.t != nil {
+		if err := r.t.end(); err != nil {
+			r.reader.Error = fmt.Errorf("block reader: %w", err)
+		}
+	}
+	if r.reader.Error != nil {
+		r.err = r.reader.Error
+		return Block{}, r.err
+	}
+
+	offset := r.counter.n - int64(r.reader.Buffered())
+	count := r.reader.ReadLong()
+	size := r.reader.ReadLong()
+	if r.reader.Error == nil && (count < 0 || size < 0) {
+		r.reader.Error = errors.New("invalid block header")
+	}
+	if r.reader.Error != nil {
+		r.err = fmt.Errorf("block reader: %w", r.reader.Error)
+		return Block{}, r.err
+	}
+
+	data := make([]byte, size)
+	r.reader.Read(data)
+
+	var sync [16]byte
+	r.reader.Read(sync[:])
+	if r.reader.Error == nil && r.sync != sync {
+		r.reader.Error = errors.New("invalid block")
+	}
+	if r.reader.Error != nil {
+		r.err = fmt.Errorf("block reader: %w", r.reader.Error)
+		return Block{}, r.err
+	}
+
+	if r.t != nil {
+		index, decoded, err := r.t.decodeBlock(data, count)
+		if err == nil {
+			_, err = r.t.check(index, count)
+		}
+		if err != nil {
+			r.err = fmt.Errorf("block reader: %w", err)
+			return Block{}, r.err
+		}
+		if count == 0 {
+			r.err = io.EOF
+			return Block{}, r.err
+		}
+		data = decoded
+	}
+
+	return Block{
+		Count:          count,
+		Offset:         offset,
+		CompressedSize: size,
+		Data:           data,
+	}, nil
+}
+
+// Close releases codec resources.
+func (r *BlockReader) Close() error {
+	if c, ok := r.codec.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// BlockWriter writes pre-encoded, compressed blocks to a container file.
+type BlockWriter struct {
+	writer *avro.Writer
+	schema avro.Schema
+	sync   [16]byte
+
+	codec     Codec
+	codecName CodecName
+	t         *transformer
+	// blocks is the number of blocks written to the file.
+	blocks int64
+	// transforms are reversed when reading the files blocks are copied from.
+	transforms []BlockTransform
+	closed     bool
+}
+
+// NewBlockWriter returns a new block writer that writes a container file
+// with the given schema to w.
+//
+// The block data must be compressed with the codec of the writer, as set by WithCodec.
+// The block transforms of the writer are applied as blocks are written.
+func NewBlockWriter(schema avro.Schema, w io.Writer, opts ...EncoderFunc) (*BlockWriter, error) {
+	if w == nil {
+		return nil, errors.New("writer cannot be nil")
+	}
+	cfg := computeEncoderConfig(opts)
+
+	encodeFns, err := newFileTransforms(cfg.Transforms, cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	header, err := newHeader(schema, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := resolveCodec(cfg.CodecName, cfg.CodecOptions, codecModeEncode)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := avro.NewWriter(w, 512, avro.WithWriterConfig(cfg.EncodingConfig))
+	writer.WriteVal(HeaderSchema, header)
+	if err = writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return &BlockWriter{
+		writer:     writer,
+		schema:     schema,
+		sync:       header.Sync,
+		codec:      codec,
+		codecName:  cfg.CodecName,
+		t:          newTransformer(header.Sync, header.Meta, encodeFns, nil),
+		transforms: cfg.Transforms,
+	}, nil
+}
+
+// Codec returns the codec of the file, used to compress block data.
+func (w *BlockWriter) Codec() Codec {
+	return w.codec
+}
+
+// WriteBlock writes the count and data of the block. The offset and
+// compressed size of the block are ignored. Empty blocks are not written to
+// files with block transforms.
+func (w *BlockWriter) WriteBlock(b Block) error {
+	if b.Count < 0 {
+		return fmt.Errorf("block writer: invalid block count %d", b.Count)
+	}
+
+	data := b.Data
+	if w.t != nil {
+		if b.Count == 0 {
+			return nil
+		}
+		data = w.t.encodeBlock(data, w.blocks, b.Count)
+		w.blocks++
+	}
+	return w.writeBlock(b.Count, data)
+}
+
+func (w *BlockWriter) writeBlock(count int64, data []byte) error {
+	w.writer.WriteLong(count)
+	w.writer.WriteLong(int64(len(data)))
+	_, _ = w.writer.Write(data)
+	_, _ = w.writer.Write(w.sync[:])
+
+	return w.writer.Flush()
+}
+
+// AppendAllFrom writes all remaining blocks of r, which must have the same schema.
+// Blocks are copied without decoding their values, unless the codecs of the files
+// differ, in which case the blocks are decompressed and compressed again. The block
+// transforms of r must be configured to copy its blocks, if it has any.
+func (w *BlockWriter) AppendAllFrom(r *BlockReader) error {
+	if r.Schema().Fingerprint() != w.schema.Fingerprint() {
+		return errors.New("block writer: schema of the file does not match")
+	}
+
+	for {
+		b, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if b, err = w.convertBlock(r, b); err != nil {
+			return err
+		}
+		if err = w.WriteBlock(b); err != nil {
+			return err
+		}
+	}
+}
+
+// convertBlock recompresses a block read from r with the codec of the writer,
+// if the codecs of the files differ. Blocks with block transforms cannot be
+// converted, as they are bound to their file.
+func (w *BlockWriter) convertBlock(r *BlockReader, b Block) (Block, error) {
+	if r.transformed {
+		return Block{}, fmt.Errorf("block writer: %w", r.transformedError())
+	}
+	if normalizeCodecName(r.codecName) == normalizeCodecName(w.codecName) {
+		return b, nil
+	}
+
+	data, err := r.Codec().Decode(b.Data)
+	if err != nil {
+		return Block{}, fmt.Errorf("block writer: %w", err)
+	}
+	b.Data = w.Codec().Encode(data)
+	return b, nil
+}
+
+// Close writes the final block of a file with block transforms, and releases
+// codec resources. Closing the writer again does nothing.
+func (w *BlockWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var err error
+	if w.t != nil {
+		err = w.writeBlock(0, w.t.final(w.blocks))
+	}
+	if c, ok := w.codec.(io.Closer); ok {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func normalizeCodecName(name CodecName) CodecName {
+	if name == "" {
+		return Null
+	}
+	return name
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,166 @@
+package ocf_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeLongs(t *testing.T, n int64, opts ...ocf.EncoderFunc) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(`"long"`, buf, opts...)
+	require.NoError(t, err)
+	for i := range n {
+		require.NoError(t, enc.Encode(i))
+	}
+	require.NoError(t, enc.Close())
+
+	return buf.Bytes()
+}
+
+func TestBlockReader(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(3), ocf.WithCodec(ocf.Deflate))
+
+	r, err := ocf.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+
+	assert.Equal(t, []byte(ocf.Deflate), r.Metadata()["avro.codec"])
+	assert.Equal(t, avro.Long, r.Schema().Type())
+
+	var counts []int64
+	for {
+		block, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		counts = append(counts, block.Count)
+
+		assert.Equal(t, int64(len(block.Data)), block.CompressedSize)
+
+		reader := avro.NewReader(bytes.NewReader(b[block.Offset:]), 16)
+		assert.Equal(t, block.Count, reader.ReadLong())
+		assert.Equal(t, block.CompressedSize, reader.ReadLong())
+
+		data, err := r.Codec().Decode(block.Data)
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+
+	assert.Equal(t, []int64{3, 3, 3, 1}, counts)
+}
+
+func TestBlockReader_InvalidBlock(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(3))
+	b[len(b)-1]++
+
+	r, err := ocf.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = r.ReadBlock()
+		require.NoError(t, err)
+	}
+	_, err = r.ReadBlock()
+	assert.EqualError(t, err, "block reader: invalid block")
+}
+
+func TestBlockWriter(t *testing.T) {
+	schema := avro.MustParse(`"long"`)
+	buf := &bytes.Buffer{}
+	w, err := ocf.NewBlockWriter(schema, buf, ocf.WithCodec(ocf.Snappy))
+	require.NoError(t, err)
+
+	data := []byte{0x02, 0x04}
+	err = w.WriteBlock(ocf.Block{Count: 2, Data: w.Codec().Encode(data)})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	dec, err := ocf.NewDecoder(buf)
+	require.NoError(t, err)
+
+	var got []int64
+	for dec.HasNext() {
+		var v int64
+		require.NoError(t, dec.Decode(&v))
+		got = append(got, v)
+	}
+	require.NoError(t, dec.Error())
+	assert.Equal(t, []int64{1, 2}, got)
+}
+
+func TestBlockWriter_InvalidCount(t *testing.T) {
+	w, err := ocf.NewBlockWriter(avro.MustParse(`"long"`), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	err = w.WriteBlock(ocf.Block{Count: -1})
+
+	assert.Error(t, err)
+}
+
+func TestBlockWriter_AppendAllFrom(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec ocf.CodecName
+	}{
+		{
+			name:  "same codec",
+			codec: ocf.Deflate,
+		},
+		{
+			name:  "different codec",
+			codec: ocf.ZStandard,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema := avro.MustParse(`"long"`)
+			buf := &bytes.Buffer{}
+			w, err := ocf.NewBlockWriter(schema, buf, ocf.WithCodec(test.codec))
+			require.NoError(t, err)
+
+			for range 2 {
+				b := encodeLongs(t, 5, ocf.WithBlockLength(2), ocf.WithCodec(ocf.Deflate))
+				r, err := ocf.NewBlockReader(bytes.NewReader(b))
+				require.NoError(t, err)
+
+				require.NoError(t, w.AppendAllFrom(r))
+			}
+			require.NoError(t, w.Close())
+
+			dec, err := ocf.NewDecoder(buf)
+			require.NoError(t, err)
+
+			var got []int64
+			for dec.HasNext() {
+				var v int64
+				require.NoError(t, dec.Decode(&v))
+				got = append(got, v)
+			}
+			require.NoError(t, dec.Error())
+			assert.Equal(t, []int64{0, 1, 2, 3, 4, 0, 1, 2, 3, 4}, got)
+		})
+	}
+}
+
+func TestBlockWriter_AppendAllFromSchemaMismatch(t *testing.T) {
+	w, err := ocf.NewBlockWriter(avro.MustParse(`"string"`), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	r, err := ocf.NewBlockReader(bytes.NewReader(encodeLongs(t, 1)))
+	require.NoError(t, err)
+
+	err = w.AppendAllFrom(r)
+
+	assert.Error(t, err)
+}
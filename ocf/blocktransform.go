@@ -0,0 +1,522 @@
+package ocf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const (
+	transformsKey = "ocf.transforms"
+	// transformKeyPrefix prefixes the header metadata of block transforms.
+	transformKeyPrefix = "ocf."
+)
+
+// BlockTransform transforms the compressed data of blocks, such as to encrypt or
+// checksum them. The transforms of a file are declared in its header.
+type BlockTransform interface {
+	// Name returns the name the transform is declared with.
+	Name() string
+	// NewEncoder returns the function transforming the blocks of the file with the
+	// given header metadata. Metadata needed to reverse the transform is added to meta.
+	NewEncoder(meta map[string][]byte) (func(data []byte, b BlockInfo) []byte, error)
+	// NewDecoder returns the function reversing the transform of the blocks of the
+	// file with the given header metadata.
+	NewDecoder(meta map[string][]byte) (func(data []byte, b BlockInfo) ([]byte, error), error)
+}
+
+type (
+	encodeFunc = func([]byte, BlockInfo) []byte
+	decodeFunc = func([]byte, BlockInfo) ([]byte, error)
+)
+
+// BlockInfo identifies a block within its file. Transforms authenticating blocks
+// authenticate it along with the block data, so that blocks cannot be altered,
+// reordered, dropped or moved to another file unnoticed.
+type BlockInfo struct {
+	// File is the SHA-256 digest of the sync marker and header metadata of the file.
+	File [32]byte
+	// Index is the index of the block in the file, counting from zero.
+	Index int64
+	// Count is the number of values in the block.
+	Count int64
+	// Final is set for the block ending the file, which holds no values. Its
+	// index is the number of blocks before it.
+	Final bool
+}
+
+// AdditionalData returns the encoding of the block info that is authenticated
+// along with the block data.
+func (b BlockInfo) AdditionalData() []byte {
+	data := make([]byte, 0, len(b.File)+17)
+	data = append(data, b.File[:]...)
+	data = binary.BigEndian.AppendUint64(data, uint64(b.Index))
+	data = binary.BigEndian.AppendUint64(data, uint64(b.Count))
+	if b.Final {
+		return append(data, 1)
+	}
+	return append(data, 0)
+}
+
+// WithEncoderBlockTransforms sets the transforms applied to blocks after they are
+// compressed, in the given order.
+func WithEncoderBlockTransforms(ts ...BlockTransform) EncoderFunc {
+	return func(cfg *encoderConfig) {
+		cfg.Transforms = ts
+	}
+}
+
+// WithDecoderBlockTransforms sets the transforms reversed before blocks are
+// decompressed. They must be the transforms declared by the file.
+func WithDecoderBlockTransforms(ts ...BlockTransform) DecoderFunc {
+	return func(cfg *decoderConfig) {
+		cfg.Transforms = ts
+	}
+}
+
+// transformNames returns the names of the transforms, as declared in a header.
+func transformNames(ts []BlockTransform) string {
+	names := make([]string, len(ts))
+	for i, t := range ts {
+		names[i] = t.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// encodeTransforms returns the functions applying the transforms, declaring them
+// in the header metadata.
+func encodeTransforms(ts []BlockTransform, meta map[string][]byte) ([]encodeFunc, error) {
+	if len(ts) == 0 {
+		return nil, nil
+	}
+
+	fns := make([]encodeFunc, 0, len(ts))
+	for _, t := range ts {
+		fn, err := t.NewEncoder(meta)
+		if err != nil {
+			return nil, fmt.Errorf("block transform %s: %w", t.Name(), err)
+		}
+		fns = append(fns, fn)
+	}
+	meta[transformsKey] = []byte(transformNames(ts))
+	return fns, nil
+}
+
+// newFileTransforms returns the functions applying the transforms to a new file,
+// replacing any block transform metadata copied from another file.
+func newFileTransforms(ts []BlockTransform, meta map[string][]byte) ([]encodeFunc, error) {
+	if len(ts) > 0 {
+		maps.DeleteFunc(meta, func(k string, _ []byte) bool {
+			return strings.HasPrefix(k, transformKeyPrefix)
+		})
+	}
+	return encodeTransforms(ts, meta)
+}
+
+// decodeTransforms returns the functions reversing the transforms declared in the
+// header metadata, in the order they are to be applied.
+func decodeTransforms(ts []BlockTransform, meta map[string][]byte) ([]decodeFunc, error) {
+	var declared []string
+	if v := meta[transformsKey]; len(v) > 0 {
+		declared = strings.Split(string(v), ",")
+	}
+	if len(declared) == 0 && len(ts) == 0 {
+		return nil, nil
+	}
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("blocks are transformed with %s, which must be configured to read them", meta[transformsKey])
+	}
+
+	byName := make(map[string]BlockTransform, len(ts))
+	for _, t := range ts {
+		if !slices.Contains(declared, t.Name()) {
+			return nil, fmt.Errorf("block transform %s is not declared by the file", t.Name())
+		}
+		byName[t.Name()] = t
+	}
+
+	fns := make([]decodeFunc, 0, len(declared))
+	for _, name := range slices.Backward(declared) {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("blocks are transformed with %s, which is not configured", name)
+		}
+		fn, err := t.NewDecoder(meta)
+		if err != nil {
+			return nil, fmt.Errorf("block transform %s: %w", name, err)
+		}
+		fns = append(fns, fn)
+	}
+	return fns, nil
+}
+
+// declaredTransforms returns the transforms declared in the header metadata.
+func declaredTransforms(ts []BlockTransform, meta map[string][]byte) []BlockTransform {
+	declared := strings.Split(string(meta[transformsKey]), ",")
+	return slices.DeleteFunc(slices.Clone(ts), func(t BlockTransform) bool {
+		return !slices.Contains(declared, t.Name())
+	})
+}
+
+// fileTransforms returns the transforms of ts declared by the file with the given
+// header metadata, in the order they are applied to its blocks.
+func fileTransforms(ts []BlockTransform, meta map[string][]byte) []BlockTransform {
+	var applied []BlockTransform
+	for _, name := range strings.Split(string(meta[transformsKey]), ",") {
+		i := slices.IndexFunc(ts, func(t BlockTransform) bool { return t.Name() == name })
+		if i >= 0 {
+			applied = append(applied, ts[i])
+		}
+	}
+	return applied
+}
+
+// appendTransformer returns the transformer of blocks appended to the file with
+// the given header, or nil if there are no transforms.
+func appendTransformer(ts []BlockTransform, h *ocfHeader) (*transformer, error) {
+	if len(ts) == 0 {
+		return nil, nil
+	}
+
+	encodeFns, err := encodeTransforms(ts, maps.Clone(h.Meta))
+	if err != nil {
+		return nil, err
+	}
+	decodeFns, err := decodeTransforms(ts, h.Meta)
+	if err != nil {
+		return nil, err
+	}
+	return newTransformer(h.Sync, h.Meta, encodeFns, decodeFns), nil
+}
+
+// errTruncated is returned when a file with block transforms ends before its final block.
+var errTruncated = errors.New("file is truncated, its final block is missing")
+
+// transformer applies the block transforms of a file to the compressed data of
+// its blocks. Transformed blocks are prefixed with their index, and the file is
+// ended by a final block, so that blocks that are reordered, dropped or cut off
+// are detected.
+type transformer struct {
+	file   [32]byte
+	encode []encodeFunc
+	decode []decodeFunc
+
+	// next is the index of the next block read, or -1 if it is unknown.
+	next  int64
+	ended bool
+}
+
+// newTransformer returns the transformer of the file with the given sync marker
+// and header metadata, or nil if there are no transform functions.
+func newTransformer(sync [16]byte, meta map[string][]byte, encode []encodeFunc, decode []decodeFunc) *transformer {
+	if len(encode) == 0 && len(decode) == 0 {
+		return nil
+	}
+
+	t := &transformer{encode: encode, decode: decode}
+	t.reset(sync, meta)
+	return t
+}
+
+// reset moves the transformer to the start of the file with the given sync
+// marker and header metadata.
+func (t *transformer) reset(sync [16]byte, meta map[string][]byte) {
+	h := sha256.New()
+	_, _ = h.Write(sync[:])
+	for _, k := range slices.Sorted(maps.Keys(meta)) {
+		_, _ = h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(k))))
+		_, _ = h.Write([]byte(k))
+		_, _ = h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(meta[k]))))
+		_, _ = h.Write(meta[k])
+	}
+	h.Sum(t.file[:0])
+	t.next = 0
+	t.ended = false
+}
+
+// encodeBlock transforms the data of the block with the given index and count.
+func (t *transformer) encodeBlock(data []byte, index, count int64) []byte {
+	return t.encodeInfo(data, BlockInfo{File: t.file, Index: index, Count: count})
+}
+
+// final returns the data of the final block of a file with n blocks.
+func (t *transformer) final(n int64) []byte {
+	return t.encodeInfo(nil, BlockInfo{File: t.file, Index: n, Final: true})
+}
+
+func (t *transformer) encodeInfo(data []byte, info BlockInfo) []byte {
+	for _, fn := range t.encode {
+		data = fn(data, info)
+	}
+	b := binary.AppendVarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), info.Index)
+	return append(b, data...)
+}
+
+// decodeBlock reverses the transforms of the data of the block with the given
+// count, returning the index of the block. A block with no values is the final
+// block of the file.
+//
+// It is safe to call concurrently, as it does not check the index of the block.
+func (t *transformer) decodeBlock(data []byte, count int64) (int64, []byte, error) {
+	index, n := binary.Varint(data)
+	if n <= 0 || index < 0 {
+		return 0, nil, errors.New("invalid block index")
+	}
+
+	info := BlockInfo{File: t.file, Index: index, Count: count, Final: count == 0}
+	data = data[n:]
+	var err error
+	for _, fn := range t.decode {
+		if data, err = fn(data, info); err != nil {
+			return 0, nil, err
+		}
+	}
+	return index, data, nil
+}
+
+// check checks that the block with the given index and count follows the block
+// read before it. A block with an index below the expected one, repeating a block
+// already read, is reported as stale and does not move the expected index.
+func (t *transformer) check(index, count int64) (stale bool, err error) {
+	next := t.next
+	if next >= 0 && index < next {
+		return true, fmt.Errorf("block %d is out of order, expected block %d", index, next)
+	}
+
+	t.next = index + 1
+	if count == 0 {
+		t.ended = true
+	}
+	if next >= 0 && index != next {
+		return false, fmt.Errorf("block %d is out of order, expected block %d", index, next)
+	}
+	return false, nil
+}
+
+// end checks that the final block was read, once there are no more blocks.
+func (t *transformer) end() error {
+	if !t.ended {
+		return errTruncated
+	}
+	return nil
+}
+
+// seek forgets the index of the next block, once moved to another block of the file.
+func (t *transformer) seek() {
+	t.next = -1
+	t.ended = false
+}
+
+// KeyProvider provides the keys of block transforms.
+type KeyProvider interface {
+	// CurrentKey returns the key new files are transformed with, and its ID.
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given ID.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider of a fixed set of keys.
+type StaticKeys struct {
+	// Current is the ID of the key new files are transformed with.
+	Current string
+	// Keys is the keys, by ID.
+	Keys map[string][]byte
+}
+
+// CurrentKey returns the key new files are transformed with, and its ID.
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+// Key returns the key with the given ID.
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+	return key, nil
+}
+
+// fileKey returns the key of a file, from the key ID in its header metadata. A new
+// file is given the current key, and its ID is added to the metadata.
+func fileKey(keys KeyProvider, meta map[string][]byte, idKey string) ([]byte, error) {
+	if id, ok := meta[idKey]; ok {
+		return keys.Key(string(id))
+	}
+
+	id, key, err := keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	meta[idKey] = []byte(id)
+	return key, nil
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+type crc32cTransform struct{}
+
+// CRC32CTransform returns a block transform appending the CRC32C checksum of
+// each block and its BlockInfo, which is verified when the block is read.
+func CRC32CTransform() BlockTransform {
+	return crc32cTransform{}
+}
+
+func (crc32cTransform) Name() string {
+	return "crc32c"
+}
+
+func (crc32cTransform) NewEncoder(map[string][]byte) (func([]byte, BlockInfo) []byte, error) {
+	return func(b []byte, info BlockInfo) []byte {
+		return binary.BigEndian.AppendUint32(slices.Clip(b), crc32cChecksum(b, info))
+	}, nil
+}
+
+func (crc32cTransform) NewDecoder(map[string][]byte) (func([]byte, BlockInfo) ([]byte, error), error) {
+	return func(b []byte, info BlockInfo) ([]byte, error) {
+		if len(b) < 4 {
+			return nil, errors.New("crc32c: block too short")
+		}
+		data, sum := b[:len(b)-4], b[len(b)-4:]
+		if binary.BigEndian.Uint32(sum) != crc32cChecksum(data, info) {
+			return nil, errors.New("crc32c: checksum mismatch")
+		}
+		return data, nil
+	}, nil
+}
+
+func crc32cChecksum(data []byte, info BlockInfo) uint32 {
+	return crc32.Update(crc32.Checksum(info.AdditionalData(), crc32cTable), crc32cTable, data)
+}
+
+const hmacKeyIDKey = "ocf.hmac-sha256.key-id"
+
+type hmacTransform struct {
+	keys KeyProvider
+}
+
+// HMACTransform returns a block transform appending the HMAC-SHA256 of each
+// block and its BlockInfo, which is verified when the block is read. The ID of
+// the key is declared in the file header.
+func HMACTransform(keys KeyProvider) BlockTransform {
+	return hmacTransform{keys: keys}
+}
+
+func (hmacTransform) Name() string {
+	return "hmac-sha256"
+}
+
+func (t hmacTransform) NewEncoder(meta map[string][]byte) (func([]byte, BlockInfo) []byte, error) {
+	key, err := fileKey(t.keys, meta, hmacKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte, info BlockInfo) []byte {
+		return blockMAC(key, b, info).Sum(slices.Clip(b))
+	}, nil
+}
+
+func (t hmacTransform) NewDecoder(meta map[string][]byte) (func([]byte, BlockInfo) ([]byte, error), error) {
+	id, ok := meta[hmacKeyIDKey]
+	if !ok {
+		return nil, errors.New("no key id in file header")
+	}
+	key, err := t.keys.Key(string(id))
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte, info BlockInfo) ([]byte, error) {
+		if len(b) < sha256.Size {
+			return nil, errors.New("hmac-sha256: block too short")
+		}
+		data, sum := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+		if !hmac.Equal(sum, blockMAC(key, data, info).Sum(nil)) {
+			return nil, errors.New("hmac-sha256: block authentication failed")
+		}
+		return data, nil
+	}, nil
+}
+
+func blockMAC(key, data []byte, info BlockInfo) hash.Hash {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(info.AdditionalData())
+	_, _ = mac.Write(data)
+	return mac
+}
+
+const aesGCMKeyIDKey = "ocf.aes-gcm.key-id"
+
+type aesGCMTransform struct {
+	keys KeyProvider
+}
+
+// AESGCMTransform returns a block transform encrypting each block with AES-GCM,
+// using a random nonce per block and authenticating its BlockInfo. The key must
+// be 16, 24 or 32 bytes long, and its ID is declared in the file header.
+func AESGCMTransform(keys KeyProvider) BlockTransform {
+	return aesGCMTransform{keys: keys}
+}
+
+func (aesGCMTransform) Name() string {
+	return "aes-gcm"
+}
+
+func (t aesGCMTransform) NewEncoder(meta map[string][]byte) (func([]byte, BlockInfo) []byte, error) {
+	key, err := fileKey(t.keys, meta, aesGCMKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte, info BlockInfo) []byte {
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(b)+aead.Overhead())
+		_, _ = rand.Read(nonce)
+		return aead.Seal(nonce, nonce, b, info.AdditionalData())
+	}, nil
+}
+
+func (t aesGCMTransform) NewDecoder(meta map[string][]byte) (func([]byte, BlockInfo) ([]byte, error), error) {
+	id, ok := meta[aesGCMKeyIDKey]
+	if !ok {
+		return nil, errors.New("no key id in file header")
+	}
+	key, err := t.keys.Key(string(id))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte, info BlockInfo) ([]byte, error) {
+		if len(b) < aead.NonceSize() {
+			return nil, errors.New("aes-gcm: block too short")
+		}
+		data, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], info.AdditionalData())
+		if err != nil {
+			return nil, fmt.Errorf("aes-gcm: %w", err)
+		}
+		return data, nil
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
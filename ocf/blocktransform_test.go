@@ -0,0 +1,462 @@
+package ocf_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKeys = ocf.StaticKeys{
+	Current: "key-2",
+	Keys: map[string][]byte{
+		"key-1": bytes.Repeat([]byte{1}, 32),
+		"key-2": bytes.Repeat([]byte{2}, 32),
+	},
+}
+
+func TestBlockTransforms(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []ocf.BlockTransform
+		wantMeta   string
+	}{
+		{
+			name:       "crc32c",
+			transforms: []ocf.BlockTransform{ocf.CRC32CTransform()},
+			wantMeta:   "crc32c",
+		},
+		{
+			name:       "hmac",
+			transforms: []ocf.BlockTransform{ocf.HMACTransform(testKeys)},
+			wantMeta:   "hmac-sha256",
+		},
+		{
+			name:       "aes-gcm",
+			transforms: []ocf.BlockTransform{ocf.AESGCMTransform(testKeys)},
+			wantMeta:   "aes-gcm",
+		},
+		{
+			name:       "aes-gcm and crc32c",
+			transforms: []ocf.BlockTransform{ocf.AESGCMTransform(testKeys), ocf.CRC32CTransform()},
+			wantMeta:   "aes-gcm,crc32c",
+		},
+	}
+
+	for _, test := range tests {
+		for _, concurrency := range []int{0, 2} {
+			t.Run(fmt.Sprintf("%s concurrency %d", test.name, concurrency), func(t *testing.T) {
+				b := encodeLongs(t, 10,
+					ocf.WithBlockLength(3),
+					ocf.WithCodec(ocf.Deflate),
+					ocf.WithEncoderConcurrency(concurrency),
+					ocf.WithEncoderBlockTransforms(test.transforms...),
+				)
+
+				// Reverse the order the transforms are configured in, as only the names matter.
+				var reversed []ocf.BlockTransform
+				for i := len(test.transforms) - 1; i >= 0; i-- {
+					reversed = append(reversed, test.transforms[i])
+				}
+				dec, err := ocf.NewDecoder(bytes.NewReader(b),
+					ocf.WithDecoderConcurrency(concurrency),
+					ocf.WithDecoderBlockTransforms(reversed...),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = dec.Close() })
+
+				assert.Equal(t, []byte(test.wantMeta), dec.Metadata()["ocf.transforms"])
+
+				var got []int64
+				for v, err := range ocf.Records[int64](dec) {
+					require.NoError(t, err)
+					got = append(got, v)
+				}
+				assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, got)
+			})
+		}
+	}
+}
+
+func TestBlockTransforms_KeyRotation(t *testing.T) {
+	oldKeys := testKeys
+	oldKeys.Current = "key-1"
+	b := encodeLongs(t, 3, ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(oldKeys), ocf.HMACTransform(oldKeys)))
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b),
+		ocf.WithDecoderBlockTransforms(ocf.AESGCMTransform(testKeys), ocf.HMACTransform(testKeys)),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("key-1"), dec.Metadata()["ocf.aes-gcm.key-id"])
+	assert.Equal(t, []byte("key-1"), dec.Metadata()["ocf.hmac-sha256.key-id"])
+	var n int
+	for _, err := range dec.All() {
+		require.NoError(t, err)
+		n++
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestNewDecoder_BlockTransformErrors(t *testing.T) {
+	b := encodeLongs(t, 3, ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(testKeys)))
+
+	tests := []struct {
+		name    string
+		opts    []ocf.DecoderFunc
+		wantErr string
+	}{
+		{
+			name:    "not configured",
+			wantErr: "decoder: blocks are transformed with aes-gcm, which must be configured to read them",
+		},
+		{
+			name:    "not declared",
+			opts:    []ocf.DecoderFunc{ocf.WithDecoderBlockTransforms(ocf.AESGCMTransform(testKeys), ocf.CRC32CTransform())},
+			wantErr: "decoder: block transform crc32c is not declared by the file",
+		},
+		{
+			name:    "other transform",
+			opts:    []ocf.DecoderFunc{ocf.WithDecoderBlockTransforms(ocf.CRC32CTransform())},
+			wantErr: "decoder: block transform crc32c is not declared by the file",
+		},
+		{
+			name:    "unknown key",
+			opts:    []ocf.DecoderFunc{ocf.WithDecoderBlockTransforms(ocf.AESGCMTransform(ocf.StaticKeys{}))},
+			wantErr: `decoder: block transform aes-gcm: unknown key "key-2"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ocf.NewDecoder(bytes.NewReader(b), test.opts...)
+
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestDecoder_BlockTransformDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform ocf.BlockTransform
+		wantErr   string
+	}{
+		{
+			name:      "crc32c",
+			transform: ocf.CRC32CTransform(),
+			wantErr:   "crc32c: checksum mismatch",
+		},
+		{
+			name:      "hmac",
+			transform: ocf.HMACTransform(testKeys),
+			wantErr:   "hmac-sha256: block authentication failed",
+		},
+		{
+			name:      "aes-gcm",
+			transform: ocf.AESGCMTransform(testKeys),
+			wantErr:   "aes-gcm: cipher: message authentication failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := encodeLongs(t, 4, ocf.WithBlockLength(2), ocf.WithEncoderBlockTransforms(test.transform))
+			blocks := blockOffsets(t, b)
+			// The last byte of the data of the second block.
+			b[blocks[2]-17]++
+
+			dec, err := ocf.NewDecoder(bytes.NewReader(b), ocf.WithDecoderBlockTransforms(test.transform))
+			require.NoError(t, err)
+
+			var got []int64
+			for v, err := range ocf.Records[int64](dec) {
+				if err != nil {
+					assert.EqualError(t, err, test.wantErr)
+					break
+				}
+				got = append(got, v)
+			}
+			assert.Equal(t, []int64{0, 1}, got)
+		})
+	}
+}
+
+func TestDecoder_BlockTransformDetectsBlockTampering(t *testing.T) {
+	transforms := []struct {
+		name      string
+		transform ocf.BlockTransform
+		wantErr   string
+	}{
+		{
+			name:      "hmac",
+			transform: ocf.HMACTransform(testKeys),
+			wantErr:   "hmac-sha256: block authentication failed",
+		},
+		{
+			name:      "aes-gcm",
+			transform: ocf.AESGCMTransform(testKeys),
+			wantErr:   "aes-gcm: cipher: message authentication failed",
+		},
+	}
+	tests := []struct {
+		name string
+		// tamper changes the file with three blocks of two values at the given offsets,
+		// followed by the final block and the end of the file.
+		tamper   func(t *testing.T, b []byte, blocks []int64, opts ...ocf.EncoderFunc) []byte
+		want     []int64
+		wantAuth bool
+		wantErr  string
+	}{
+		{
+			name: "count",
+			tamper: func(_ *testing.T, b []byte, blocks []int64, _ ...ocf.EncoderFunc) []byte {
+				// The count of the second block, from 2 to 1.
+				b[blocks[1]] = 0x2
+				return b
+			},
+			want:     []int64{0, 1},
+			wantAuth: true,
+		},
+		{
+			name: "truncated",
+			tamper: func(_ *testing.T, b []byte, blocks []int64, _ ...ocf.EncoderFunc) []byte {
+				return b[:blocks[2]]
+			},
+			want:    []int64{0, 1, 2, 3},
+			wantErr: "file is truncated, its final block is missing",
+		},
+		{
+			name: "final block missing",
+			tamper: func(_ *testing.T, b []byte, blocks []int64, _ ...ocf.EncoderFunc) []byte {
+				return b[:blocks[3]]
+			},
+			want:    []int64{0, 1, 2, 3, 4, 5},
+			wantErr: "file is truncated, its final block is missing",
+		},
+		{
+			name: "reordered",
+			tamper: func(_ *testing.T, b []byte, blocks []int64, _ ...ocf.EncoderFunc) []byte {
+				second := bytes.Clone(b[blocks[1]:blocks[2]])
+				third := bytes.Clone(b[blocks[2]:blocks[3]])
+				return slices.Concat(b[:blocks[1]], third, second, b[blocks[3]:])
+			},
+			want:    []int64{0, 1},
+			wantErr: "block 2 is out of order, expected block 1",
+		},
+		{
+			name: "spliced",
+			tamper: func(t *testing.T, b []byte, blocks []int64, opts ...ocf.EncoderFunc) []byte {
+				other := encodeLongs(t, 6, opts...)
+				otherBlocks := blockOffsets(t, other)
+				block := bytes.Clone(other[otherBlocks[1]:otherBlocks[2]])
+				// Rewrite the sync marker of the block to the one of the file.
+				copy(block[len(block)-16:], b[blocks[1]-16:blocks[1]])
+				return slices.Concat(b[:blocks[1]], block, b[blocks[2]:])
+			},
+			want:     []int64{0, 1},
+			wantAuth: true,
+		},
+		{
+			name: "metadata",
+			tamper: func(_ *testing.T, b []byte, _ []int64, _ ...ocf.EncoderFunc) []byte {
+				return bytes.Replace(b, []byte("original"), []byte("modified"), 1)
+			},
+			wantAuth: true,
+		},
+	}
+
+	for _, tr := range transforms {
+		for _, test := range tests {
+			for _, concurrency := range []int{0, 2} {
+				t.Run(fmt.Sprintf("%s %s concurrency %d", tr.name, test.name, concurrency), func(t *testing.T) {
+					opts := []ocf.EncoderFunc{
+						ocf.WithBlockLength(2),
+						ocf.WithMetadataKeyVal("origin", []byte("original")),
+						ocf.WithEncoderBlockTransforms(tr.transform),
+					}
+					b := encodeLongs(t, 6, opts...)
+					b = test.tamper(t, b, blockOffsets(t, b), opts...)
+
+					dec, err := ocf.NewDecoder(bytes.NewReader(b),
+						ocf.WithDecoderConcurrency(concurrency),
+						ocf.WithDecoderBlockTransforms(tr.transform),
+					)
+					require.NoError(t, err)
+					t.Cleanup(func() { _ = dec.Close() })
+
+					var (
+						got    []int64
+						gotErr error
+					)
+					for v, err := range ocf.Records[int64](dec) {
+						if err != nil {
+							gotErr = err
+							break
+						}
+						got = append(got, v)
+					}
+					assert.Equal(t, test.want, got)
+					wantErr := test.wantErr
+					if test.wantAuth {
+						wantErr = tr.wantErr
+					}
+					require.Error(t, gotErr)
+					assert.Contains(t, gotErr.Error(), wantErr)
+				})
+			}
+		}
+	}
+}
+
+func TestDecoder_BlockTransformSkipsStaleBlocks(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(b []byte, blocks []int64) []byte
+		want       []int64
+		wantRanges func(blocks []int64) []string
+	}{
+		{
+			name: "replayed",
+			tamper: func(b []byte, blocks []int64) []byte {
+				return slices.Concat(b[:blocks[2]], b[blocks[0]:blocks[1]], b[blocks[2]:])
+			},
+			want: []int64{0, 1, 2, 3, 4, 5},
+			wantRanges: func(blocks []int64) []string {
+				return []string{
+					fmt.Sprintf("%d-%d: decoder: block 0 is out of order, expected block 2", blocks[2], blocks[2]+blocks[1]-blocks[0]),
+				}
+			},
+		},
+		{
+			name: "reordered",
+			tamper: func(b []byte, blocks []int64) []byte {
+				return slices.Concat(b[:blocks[1]], b[blocks[2]:blocks[3]], b[blocks[1]:blocks[2]], b[blocks[3]:])
+			},
+			want: []int64{0, 1, 4, 5},
+			wantRanges: func(blocks []int64) []string {
+				second := blocks[1] + blocks[3] - blocks[2]
+				return []string{
+					fmt.Sprintf("%d-%d: decoder: block 2 is out of order, expected block 1", blocks[1], blocks[1]),
+					fmt.Sprintf("%d-%d: decoder: block 1 is out of order, expected block 3", second, blocks[3]),
+				}
+			},
+		},
+	}
+
+	transform := ocf.HMACTransform(testKeys)
+	for _, test := range tests {
+		for _, concurrency := range []int{0, 2} {
+			t.Run(fmt.Sprintf("%s concurrency %d", test.name, concurrency), func(t *testing.T) {
+				b := encodeLongs(t, 6, ocf.WithBlockLength(2), ocf.WithEncoderBlockTransforms(transform))
+				blocks := blockOffsets(t, b)
+				b = test.tamper(b, blocks)
+
+				var ranges []string
+				dec, err := ocf.NewDecoder(bytes.NewReader(b),
+					ocf.WithDecoderConcurrency(concurrency),
+					ocf.WithDecoderBlockTransforms(transform),
+					ocf.WithSkipCorruptBlocks(func(r ocf.CorruptRange) {
+						ranges = append(ranges, fmt.Sprintf("%d-%d: %v", r.Start, r.End, r.Err))
+					}),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = dec.Close() })
+
+				var got []int64
+				for v, err := range ocf.Records[int64](dec) {
+					require.NoError(t, err)
+					got = append(got, v)
+				}
+				assert.Equal(t, test.want, got)
+				assert.Equal(t, test.wantRanges(blocks), ranges)
+			})
+		}
+	}
+}
+
+func TestEncoder_AppendWithBlockTransforms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.avro")
+	transforms := ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(testKeys))
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	enc, err := ocf.NewEncoder(`"long"`, f, transforms)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(int64(1)))
+	require.NoError(t, enc.Close())
+	require.NoError(t, f.Close())
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0o600)
+	require.NoError(t, err)
+	_, err = ocf.NewEncoder(`"long"`, f)
+	assert.Error(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0o600)
+	require.NoError(t, err)
+	enc, err = ocf.NewEncoder(`"long"`, f, transforms)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(int64(2)))
+	require.NoError(t, enc.Close())
+	require.NoError(t, f.Close())
+
+	f, err = os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+	dec, err := ocf.NewDecoder(f, ocf.WithDecoderBlockTransforms(ocf.AESGCMTransform(testKeys)))
+	require.NoError(t, err)
+
+	var got []int64
+	for v, err := range ocf.Records[int64](dec) {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	assert.Equal(t, []int64{1, 2}, got)
+}
+
+func TestSplit_CopiesTransformedBlocks(t *testing.T) {
+	transform := ocf.AESGCMTransform(testKeys)
+	b := encodeLongs(t, 4, ocf.WithBlockLength(2), ocf.WithEncoderBlockTransforms(transform))
+
+	var files []*bytes.Buffer
+	err := ocf.Split(bytes.NewReader(b), ocf.SplitLimit{Blocks: 1}, func(int) (io.Writer, error) {
+		files = append(files, &bytes.Buffer{})
+		return files[len(files)-1], nil
+	}, ocf.WithEncoderBlockTransforms(transform))
+	require.NoError(t, err)
+
+	require.Len(t, files, 2)
+	dec, err := ocf.NewDecoder(files[1], ocf.WithDecoderBlockTransforms(transform))
+	require.NoError(t, err)
+	var got []int64
+	for v, err := range ocf.Records[int64](dec) {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	assert.Equal(t, []int64{2, 3}, got)
+}
+
+func TestRecompress_TransformedBlocksNotConfigured(t *testing.T) {
+	b := encodeLongs(t, 4, ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(testKeys)))
+
+	err := ocf.Recompress(&bytes.Buffer{}, bytes.NewReader(b), ocf.Deflate)
+
+	assert.Error(t, err)
+}
+
+func TestMergeWithSchema_TransformedBlocksNotConfigured(t *testing.T) {
+	b := encodeLongs(t, 4, ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(testKeys)))
+
+	err := ocf.MergeWithSchema(avro.MustParse(`["null","long"]`), &bytes.Buffer{}, []io.Reader{bytes.NewReader(b)})
+
+	assert.EqualError(t, err, "merge: file 0: blocks are transformed with aes-gcm, which must be configured to read them")
+}
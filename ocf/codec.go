@@ -2,15 +2,19 @@ package ocf
 
 import (
 	"bytes"
+	"compress/bzip2"
 	"compress/flate"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
+	"sync"
 
+	dsbzip2 "github.com/dsnet/compress/bzip2"
 	"github.com/golang/snappy"
 	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 type codecMode int
@@ -29,8 +33,26 @@ const (
 	Deflate   CodecName = "deflate"
 	Snappy    CodecName = "snappy"
 	ZStandard CodecName = "zstandard"
+	Bzip2     CodecName = "bzip2"
+	XZ        CodecName = "xz"
 )
 
+// CodecFactory creates a codec. It is called once for every encoder and decoder
+// that uses the codec, so the returned codec does not need to be safe for concurrent use.
+type CodecFactory func() (Codec, error)
+
+var codecs sync.Map // map[CodecName]CodecFactory
+
+// RegisterCodec registers the codec factory under the given name, making the codec
+// available to encoders and decoders.
+//
+// A registered codec takes precedence over a built-in codec with the same name, in
+// which case the built-in codec options, such as the compression level, do not apply.
+// If the created codec implements io.Closer, it is closed with the encoder or decoder.
+func RegisterCodec(name CodecName, factory CodecFactory) {
+	codecs.Store(name, factory)
+}
+
 type codecOptions struct {
 	DeflateCompressionLevel int
 	ZStandardOptions        zstdOptions
@@ -46,6 +68,10 @@ type zstdOptions struct {
 }
 
 func resolveCodec(name CodecName, codecOpts codecOptions, mode codecMode) (Codec, error) {
+	if factory, ok := codecs.Load(name); ok {
+		return factory.(CodecFactory)()
+	}
+
 	switch name {
 	case Null, "":
 		return &NullCodec{}, nil
@@ -59,6 +85,12 @@ func resolveCodec(name CodecName, codecOpts codecOptions, mode codecMode) (Codec
 	case ZStandard:
 		return newZStandardCodec(codecOpts.ZStandardOptions, mode)
 
+	case Bzip2:
+		return &Bzip2Codec{}, nil
+
+	case XZ:
+		return &XZCodec{}, nil
+
 	default:
 		return nil, fmt.Errorf("unknown codec %s", name)
 	}
@@ -211,3 +243,45 @@ func (c *ZStandardCodec) Close() error {
 	}
 	return nil
 }
+
+// Bzip2Codec is a bzip2 compression codec.
+type Bzip2Codec struct{}
+
+// Decode decodes the given bytes.
+func (*Bzip2Codec) Decode(b []byte) ([]byte, error) {
+	return io.ReadAll(bzip2.NewReader(bytes.NewReader(b)))
+}
+
+// Encode encodes the given bytes.
+func (*Bzip2Codec) Encode(b []byte) []byte {
+	data := bytes.NewBuffer(make([]byte, 0, len(b)))
+
+	w, _ := dsbzip2.NewWriter(data, nil)
+	_, _ = w.Write(b)
+	_ = w.Close()
+
+	return data.Bytes()
+}
+
+// XZCodec is a xz compression codec.
+type XZCodec struct{}
+
+// Decode decodes the given bytes.
+func (*XZCodec) Decode(b []byte) ([]byte, error) {
+	r, err := xz.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// Encode encodes the given bytes.
+func (*XZCodec) Encode(b []byte) []byte {
+	data := bytes.NewBuffer(make([]byte, 0, len(b)))
+
+	w, _ := xz.NewWriter(data)
+	_, _ = w.Write(b)
+	_ = w.Close()
+
+	return data.Bytes()
+}
@@ -0,0 +1,39 @@
+package ocf
+
+import (
+	"iter"
+)
+
+// Records returns an iterator over the values of the decoder, decoded as T.
+//
+// An error is yielded with the zero value of T. A read error ends the iteration,
+// as does a decode error, unless corrupt blocks are skipped, in which case the
+// iteration continues with the next block.
+func Records[T any](dec *Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for dec.HasNext() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				if !yield(zero, err) || !dec.skipCorrupt {
+					return
+				}
+				continue
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err := dec.Error(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// All returns an iterator over the values of the decoder.
+//
+// See Records for how errors are yielded.
+func (d *Decoder) All() iter.Seq2[any, error] {
+	return Records[any](d)
+}
@@ -0,0 +1,95 @@
+package ocf_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecords(t *testing.T) {
+	b := encodeLongs(t, 5, ocf.WithBlockLength(2))
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var got []int64
+	for v, err := range ocf.Records[int64](dec) {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, got)
+}
+
+func TestRecords_Break(t *testing.T) {
+	b := encodeLongs(t, 5, ocf.WithBlockLength(2))
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var got []int64
+	for v, err := range ocf.Records[int64](dec) {
+		require.NoError(t, err)
+		got = append(got, v)
+		if len(got) == 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, []int64{0, 1, 2}, got)
+}
+
+func TestRecords_YieldsReadError(t *testing.T) {
+	b := encodeLongs(t, 5, ocf.WithBlockLength(2))
+	b[len(b)-1]++
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var got []int64
+	var errs []error
+	for v, err := range ocf.Records[int64](dec) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, v)
+	}
+
+	assert.Equal(t, []int64{0, 1, 2, 3}, got)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "decoder: invalid block")
+}
+
+func TestRecords_YieldsDecodeError(t *testing.T) {
+	b := encodeLongs(t, 5, ocf.WithBlockLength(2))
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var errs int
+	for _, err := range ocf.Records[string](dec) {
+		require.Error(t, err)
+		errs++
+	}
+
+	assert.Equal(t, 1, errs)
+}
+
+func TestDecoder_All(t *testing.T) {
+	b := encodeLongs(t, 3)
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var got []any
+	for v, err := range dec.All() {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+
+	assert.Equal(t, []any{int64(0), int64(1), int64(2)}, got)
+}
@@ -57,6 +57,11 @@ type decoderConfig struct {
 	DecoderConfig avro.API
 	SchemaCache   *avro.SchemaCache
 	CodecOptions  codecOptions
+	ReaderSchema  avro.Schema
+	Concurrency   int
+	SkipCorrupt   bool
+	OnCorrupt     func(CorruptRange)
+	Transforms    []BlockTransform
 }
 
 // DecoderFunc represents a configuration function for Decoder.
@@ -77,6 +82,17 @@ func WithDecoderSchemaCache(cache *avro.SchemaCache) DecoderFunc {
 	}
 }
 
+// WithReaderSchema sets the schema values are read with. The schema of the file is
+// resolved to the reader schema, applying aliases, defaults and promotions to
+// every value. A file with an incompatible schema fails to open.
+//
+// By default values are read with the schema of the file.
+func WithReaderSchema(schema avro.Schema) DecoderFunc {
+	return func(cfg *decoderConfig) {
+		cfg.ReaderSchema = schema
+	}
+}
+
 // WithZStandardDecoderOptions sets the options for the ZStandard decoder.
 func WithZStandardDecoderOptions(opts ...zstd.DOption) DecoderFunc {
 	return func(cfg *decoderConfig) {
@@ -94,60 +110,131 @@ func WithZStandardDecoder(dec *zstd.Decoder) DecoderFunc {
 	}
 }
 
+// WithDecoderConcurrency sets the number of goroutines decompressing the blocks
+// ahead of the block being decoded. Values are still decoded in order, on the
+// goroutine calling Decode.
+//
+// The goroutines run until the decoder is closed, so Close must be called once
+// the decoder is no longer used, including when decoding stops early or fails.
+//
+// By default blocks are decompressed on the calling goroutine as they are reached.
+func WithDecoderConcurrency(n int) DecoderFunc {
+	return func(cfg *decoderConfig) {
+		cfg.Concurrency = n
+	}
+}
+
 // Decoder reads and decodes Avro values from a container file.
 type Decoder struct {
 	reader      *avro.Reader
+	counter     *countingReader
 	resetReader *bytesx.ResetReader
+	api         avro.API
 	decoder     *avro.Decoder
 	meta        map[string][]byte
 	sync        [16]byte
 	schema      avro.Schema
+	// valueSchema is the schema values are decoded with.
+	valueSchema avro.Schema
 
 	codec Codec
+	t     *transformer
+
+	workers  *blockWorkers
+	pending  []*blockJob
+	readDone bool
+
+	skipCorrupt bool
+	onCorrupt   func(CorruptRange)
 
-	count int64
+	count  int64
+	offset int64
+	end    int64
+
+	closed bool
 }
 
 // NewDecoder returns a new decoder that reads from reader r.
 func NewDecoder(r io.Reader, opts ...DecoderFunc) (*Decoder, error) {
-	cfg := decoderConfig{
-		DecoderConfig: avro.DefaultConfig,
-		SchemaCache:   avro.DefaultSchemaCache,
-		CodecOptions: codecOptions{
-			DeflateCompressionLevel: flate.DefaultCompression,
-		},
-	}
-	for _, opt := range opts {
-		opt(&cfg)
-	}
+	return newDecoder(&countingReader{r: r}, computeDecoderConfig(opts))
+}
 
-	reader := avro.NewReader(r, 1024)
+func newDecoder(counter *countingReader, cfg decoderConfig) (*Decoder, error) {
+	reader := avro.NewReader(counter, 1024)
 
 	h, err := readHeader(reader, cfg.SchemaCache, cfg.CodecOptions, codecModeDecode)
 	if err != nil {
 		return nil, fmt.Errorf("decoder: %w", err)
 	}
 
+	decodeFns, err := decodeTransforms(cfg.Transforms, h.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("decoder: %w", err)
+	}
+
+	valueSchema := h.Schema
+	if cfg.ReaderSchema != nil {
+		valueSchema, err = avro.NewSchemaCompatibility().Resolve(cfg.ReaderSchema, h.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("decoder: %w", err)
+		}
+	}
+
+	t := newTransformer(h.Sync, h.Meta, nil, decodeFns)
+	var workers *blockWorkers
+	if cfg.Concurrency > 0 {
+		newCodec := func() (Codec, error) {
+			return resolveCodec(CodecName(h.Meta[codecKey]), cfg.CodecOptions, codecModeDecode)
+		}
+		workers, err = newBlockWorkers(cfg.Concurrency, newCodec, codecModeDecode, t)
+		if err != nil {
+			return nil, fmt.Errorf("decoder: %w", err)
+		}
+	}
+
 	decReader := bytesx.NewResetReader([]byte{})
 
 	return &Decoder{
 		reader:      reader,
+		counter:     counter,
 		resetReader: decReader,
-		decoder:     cfg.DecoderConfig.NewDecoder(h.Schema, decReader),
+		api:         cfg.DecoderConfig,
+		decoder:     cfg.DecoderConfig.NewDecoder(valueSchema, decReader),
 		meta:        h.Meta,
 		sync:        h.Sync,
 		codec:       h.Codec,
+		t:           t,
+		workers:     workers,
 		schema:      h.Schema,
+		valueSchema: valueSchema,
+		skipCorrupt: cfg.SkipCorrupt,
+		onCorrupt:   cfg.OnCorrupt,
+		offset:      counter.n - int64(reader.Buffered()),
 	}, nil
 }
 
+func computeDecoderConfig(opts []DecoderFunc) decoderConfig {
+	cfg := decoderConfig{
+		DecoderConfig: avro.DefaultConfig,
+		SchemaCache:   avro.DefaultSchemaCache,
+		CodecOptions: codecOptions{
+			DeflateCompressionLevel: flate.DefaultCompression,
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // Metadata returns the header metadata.
 func (d *Decoder) Metadata() map[string][]byte {
 	return d.meta
 }
 
 // Schema returns the schema that was parsed from the file's metadata
-// and that is used to interpret the file's contents.
+// and that is used to interpret the file's contents. Values are decoded
+// with it resolved to the reader schema, if one is set.
 func (d *Decoder) Schema() avro.Schema {
 	return d.schema
 }
@@ -174,7 +261,14 @@ func (d *Decoder) Decode(v any) error {
 
 	d.count--
 
-	return d.decoder.Decode(v)
+	err := d.decoder.Decode(v)
+	if err != nil && d.skipCorrupt {
+		// The rest of the block cannot be trusted, skip to the next block.
+		d.count = 0
+		d.decoder = d.api.NewDecoder(d.valueSchema, d.resetReader)
+		d.reportCorrupt(d.offset, d.end, err)
+	}
+	return err
 }
 
 // Error returns the last reader error.
@@ -186,18 +280,41 @@ func (d *Decoder) Error() error {
 	return d.reader.Error
 }
 
-// Close releases codec resources.
+// Close releases codec resources, stopping the goroutines started by
+// WithDecoderConcurrency. Closing the decoder again does nothing.
 func (d *Decoder) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	var err error
+	if d.workers != nil {
+		err = d.workers.Close()
+	}
 	if c, ok := d.codec.(io.Closer); ok {
-		return c.Close()
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
 	}
-	return nil
+	return err
 }
 
 func (d *Decoder) readBlock() int64 {
+	if d.workers != nil {
+		return d.readPipelinedBlock()
+	}
+	if d.skipCorrupt {
+		return d.readRecoveredBlock()
+	}
+
 	_ = d.reader.Peek()
+	d.offset = d.position()
 	if errors.Is(d.reader.Error, io.EOF) {
 		// There is no next block
+		if err := d.endBlocks(); err != nil {
+			d.reader.Error = err
+		}
 		return 0
 	}
 
@@ -206,16 +323,21 @@ func (d *Decoder) readBlock() int64 {
 
 	// Read the blocks data
 	switch {
-	case count > 0:
-		data := make([]byte, size)
-		d.reader.Read(data)
+	case count > 0 || (count == 0 && d.t != nil):
+		job := &blockJob{count: count, data: make([]byte, size)}
+		d.reader.Read(job.data)
+		if d.reader.Error != nil {
+			break
+		}
 
-		data, err := d.codec.Decode(data)
-		if err != nil {
-			d.reader.Error = err
+		if decodeBlock(d.codec, d.t, job); job.err == nil {
+			job.err = d.checkBlock(job)
+		}
+		if job.err != nil {
+			d.reader.Error = job.err
 		}
 
-		d.resetReader.Reset(data)
+		d.resetReader.Reset(job.data)
 
 	case size > 0:
 		// Skip the block data when count is 0
@@ -233,6 +355,213 @@ func (d *Decoder) readBlock() int64 {
 	return count
 }
 
+// checkBlock checks that a block of a file with block transforms follows the
+// block read before it.
+//
+// When skipping corrupt blocks, a block repeating a block already read is
+// returned as an error, to be skipped, and a block following missing blocks is
+// reported as corrupt at its offset and read.
+func (d *Decoder) checkBlock(job *blockJob) error {
+	if d.t == nil {
+		return nil
+	}
+	stale, err := d.t.check(job.index, job.count)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("decoder: %w", err)
+	if !d.skipCorrupt || stale {
+		return err
+	}
+	d.reportCorrupt(job.offset, job.offset, err)
+	return nil
+}
+
+// endBlocks checks that the final block of a file with block transforms was
+// read, once there are no more blocks. A missing final block is reported as
+// corrupt when skipping corrupt blocks.
+func (d *Decoder) endBlocks() error {
+	if d.t == nil {
+		return nil
+	}
+	if err := d.t.end(); err != nil {
+		err = fmt.Errorf("decoder: %w", err)
+		if d.skipCorrupt {
+			d.reportCorrupt(d.position(), d.position(), err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// readPipelinedBlock reads blocks ahead of the current block, submitting them for
+// decompression, and returns the next block once it is decompressed.
+//
+// Read errors are queued behind the blocks read before them, so they surface in
+// the same order as without read-ahead.
+func (d *Decoder) readPipelinedBlock() int64 {
+	for {
+		for !d.readDone && len(d.pending) <= d.workers.Size() {
+			job := d.readRawBlock()
+			if job.err != nil {
+				d.readDone = true
+			}
+			if job.done == nil {
+				d.workers.Submit(job)
+			}
+			d.pending = append(d.pending, job)
+		}
+
+		if len(d.pending) == 0 {
+			d.offset = d.position()
+			return 0
+		}
+		job := d.pending[0]
+		d.pending[0] = nil
+		d.pending = d.pending[1:]
+
+		d.offset, d.end = job.offset, job.end
+		job.wait()
+		if job.err == nil && job.count == 0 && d.t != nil {
+			// The final block is not processed by the workers.
+			decodeBlock(d.codec, d.t, job)
+		}
+		if job.err == nil {
+			job.err = d.checkBlock(job)
+		}
+		if errors.Is(job.err, io.EOF) {
+			if err := d.endBlocks(); err != nil {
+				job.err = err
+			}
+		}
+		if job.err != nil {
+			if d.skipCorrupt && job.count > 0 {
+				// The block could not be decompressed.
+				d.reportCorrupt(job.offset, job.end, job.err)
+				continue
+			}
+			d.reader.Error = job.err
+			return 0
+		}
+
+		d.resetReader.Reset(job.data)
+		return job.count
+	}
+}
+
+// readRecoveredBlock reads the next block, skipping corrupt blocks.
+func (d *Decoder) readRecoveredBlock() int64 {
+	for {
+		job := d.readRawBlock()
+		d.offset, d.end = job.offset, job.end
+		if errors.Is(job.err, io.EOF) {
+			if err := d.endBlocks(); err != nil {
+				job.err = err
+			}
+		}
+		if job.err != nil {
+			d.reader.Error = job.err
+			return 0
+		}
+		if job.count == 0 && d.t == nil {
+			return 0
+		}
+
+		if decodeBlock(d.codec, d.t, job); job.err == nil {
+			job.err = d.checkBlock(job)
+		}
+		if job.err != nil {
+			if job.count == 0 {
+				d.reader.Error = job.err
+				return 0
+			}
+			d.reportCorrupt(job.offset, job.end, job.err)
+			continue
+		}
+
+		d.resetReader.Reset(job.data)
+		return job.count
+	}
+}
+
+// readRawBlock reads the next compressed block. A read error is returned as
+// a processed job, leaving the reader error clear for the blocks ahead of it.
+//
+// When skipping corrupt blocks, a block that cannot be read is skipped by
+// searching for the next sync marker.
+func (d *Decoder) readRawBlock() *blockJob {
+	for {
+		_ = d.reader.Peek()
+		start := d.position()
+		if d.reader.Error != nil {
+			return d.takeReadError(start)
+		}
+
+		count := d.reader.ReadLong()
+		size := d.reader.ReadLong()
+		dataStart := d.position()
+		if d.skipCorrupt && d.reader.Error == nil && (count < 0 || size < 0) {
+			if err := d.resync(start, dataStart, nil, errors.New("decoder: invalid block header")); err != nil {
+				return doneJob(start, err)
+			}
+			continue
+		}
+
+		var data []byte
+		if size > 0 {
+			data = readData(d.reader, size)
+		}
+
+		var sync [16]byte
+		d.reader.Read(sync[:])
+		end := d.position()
+
+		var cause error
+		switch {
+		case errors.Is(d.reader.Error, io.ErrUnexpectedEOF):
+			cause = fmt.Errorf("decoder: truncated block: %w", d.reader.Error)
+		case d.reader.Error != nil:
+			return d.takeReadError(start)
+		case d.sync != sync:
+			cause = errors.New("decoder: invalid block")
+		}
+		if cause != nil && d.skipCorrupt {
+			read := append(data, sync[:]...)[:end-dataStart]
+			if err := d.resync(start, dataStart, read, cause); err != nil {
+				return doneJob(start, err)
+			}
+			continue
+		}
+		if cause != nil {
+			if d.reader.Error != nil {
+				return d.takeReadError(start)
+			}
+			return doneJob(start, cause)
+		}
+
+		if count == 0 {
+			// The data of the final block of a file with block transforms is kept
+			// to be checked.
+			job := doneJob(start, nil)
+			job.end, job.data = end, data
+			return job
+		}
+		return &blockJob{count: count, offset: start, end: end, data: data}
+	}
+}
+
+// position returns the offset of the next unread byte of the file.
+func (d *Decoder) position() int64 {
+	return d.counter.n - int64(d.reader.Buffered())
+}
+
+func (d *Decoder) takeReadError(offset int64) *blockJob {
+	err := d.reader.Error
+	d.reader.Error = nil
+	return doneJob(offset, err)
+}
+
 type encoderConfig struct {
 	BlockLength     int
 	BlockSize       int
@@ -243,6 +572,8 @@ type encoderConfig struct {
 	EncodingConfig  avro.API
 	SchemaCache     *avro.SchemaCache
 	SchemaMarshaler func(avro.Schema) ([]byte, error)
+	Concurrency     int
+	Transforms      []BlockTransform
 }
 
 // EncoderFunc represents a configuration function for Encoder.
@@ -341,6 +672,16 @@ func WithEncodingConfig(wCfg avro.API) EncoderFunc {
 	}
 }
 
+// WithEncoderConcurrency sets the number of goroutines compressing completed blocks.
+// Blocks are still written in order, on the goroutine calling Encode, Flush or Close.
+//
+// By default blocks are compressed on the calling goroutine as they are completed.
+func WithEncoderConcurrency(n int) EncoderFunc {
+	return func(cfg *encoderConfig) {
+		cfg.Concurrency = n
+	}
+}
+
 // Encoder writes Avro container file to an output stream.
 type Encoder struct {
 	writer  *avro.Writer
@@ -349,6 +690,12 @@ type Encoder struct {
 	sync    [16]byte
 
 	codec Codec
+	t     *transformer
+	// blocks is the number of blocks written to the file.
+	blocks int64
+
+	workers *blockWorkers
+	pending []*blockJob
 
 	blockLength int
 	count       int
@@ -356,6 +703,8 @@ type Encoder struct {
 
 	// Stored for Reset.
 	header Header
+
+	closed bool
 }
 
 // NewEncoder returns a new encoder that writes to w using schema s.
@@ -390,12 +739,33 @@ func newEncoder(schema avro.Schema, w io.Writer, cfg encoderConfig) (*Encoder, e
 		}
 
 		if info.Size() > 0 {
-			reader := avro.NewReader(file, 1024)
+			counter := &countingReader{r: file}
+			reader := avro.NewReader(counter, 1024)
 			h, err := readHeader(reader, cfg.SchemaCache, cfg.CodecOptions, codecModeEncode)
 			if err != nil {
 				return nil, err
 			}
-			if err = skipToEnd(reader, h.Sync); err != nil {
+
+			if names := transformNames(cfg.Transforms); names != string(h.Meta[transformsKey]) {
+				return nil, fmt.Errorf("block transforms %q do not match the transforms of the file %q", names, h.Meta[transformsKey])
+			}
+			t, err := appendTransformer(cfg.Transforms, h)
+			if err != nil {
+				return nil, err
+			}
+
+			var blocks int64
+			if t == nil {
+				err = skipToEnd(reader, h.Sync)
+			} else {
+				blocks, err = skipToFinal(file, reader, counter, h.Sync, t)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			workers, err := newEncoderWorkers(CodecName(h.Meta[codecKey]), cfg, t)
+			if err != nil {
 				return nil, err
 			}
 
@@ -407,6 +777,9 @@ func newEncoder(schema avro.Schema, w io.Writer, cfg encoderConfig) (*Encoder, e
 				encoder:     cfg.EncodingConfig.NewEncoder(h.Schema, buf),
 				sync:        h.Sync,
 				codec:       h.Codec,
+				t:           t,
+				blocks:      blocks,
+				workers:     workers,
 				blockLength: cfg.BlockLength,
 				blockSize:   cfg.BlockSize,
 			}
@@ -414,26 +787,24 @@ func newEncoder(schema avro.Schema, w io.Writer, cfg encoderConfig) (*Encoder, e
 		}
 	}
 
-	schemaJSON, err := cfg.SchemaMarshaler(schema)
+	encodeFns, err := newFileTransforms(cfg.Transforms, cfg.Metadata)
 	if err != nil {
 		return nil, err
 	}
-
-	cfg.Metadata[schemaKey] = schemaJSON
-	cfg.Metadata[codecKey] = []byte(cfg.CodecName)
-	header := Header{
-		Magic: magicBytes,
-		Meta:  cfg.Metadata,
-	}
-	header.Sync = cfg.Sync
-	if header.Sync == [16]byte{} {
-		_, _ = rand.Read(header.Sync[:])
+	header, err := newHeader(schema, cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	codec, err := resolveCodec(cfg.CodecName, cfg.CodecOptions, codecModeEncode)
 	if err != nil {
 		return nil, err
 	}
+	t := newTransformer(header.Sync, header.Meta, encodeFns, nil)
+	workers, err := newEncoderWorkers(cfg.CodecName, cfg, t)
+	if err != nil {
+		return nil, err
+	}
 
 	writer := avro.NewWriter(w, 512, avro.WithWriterConfig(cfg.EncodingConfig))
 	writer.WriteVal(HeaderSchema, header)
@@ -448,6 +819,8 @@ func newEncoder(schema avro.Schema, w io.Writer, cfg encoderConfig) (*Encoder, e
 		encoder:     cfg.EncodingConfig.NewEncoder(schema, buf),
 		sync:        header.Sync,
 		codec:       codec,
+		t:           t,
+		workers:     workers,
 		blockLength: cfg.BlockLength,
 		blockSize:   cfg.BlockSize,
 		header:      header,
@@ -455,6 +828,35 @@ func newEncoder(schema avro.Schema, w io.Writer, cfg encoderConfig) (*Encoder, e
 	return e, nil
 }
 
+func newHeader(schema avro.Schema, cfg encoderConfig) (Header, error) {
+	schemaJSON, err := cfg.SchemaMarshaler(schema)
+	if err != nil {
+		return Header{}, err
+	}
+
+	cfg.Metadata[schemaKey] = schemaJSON
+	cfg.Metadata[codecKey] = []byte(cfg.CodecName)
+	header := Header{
+		Magic: magicBytes,
+		Meta:  cfg.Metadata,
+	}
+	header.Sync = cfg.Sync
+	if header.Sync == [16]byte{} {
+		_, _ = rand.Read(header.Sync[:])
+	}
+	return header, nil
+}
+
+func newEncoderWorkers(name CodecName, cfg encoderConfig, t *transformer) (*blockWorkers, error) {
+	if cfg.Concurrency <= 0 {
+		return nil, nil
+	}
+	newCodec := func() (Codec, error) {
+		return resolveCodec(name, cfg.CodecOptions, codecModeEncode)
+	}
+	return newBlockWorkers(cfg.Concurrency, newCodec, codecModeEncode, t)
+}
+
 func computeEncoderConfig(opts []EncoderFunc) encoderConfig {
 	cfg := encoderConfig{
 		BlockLength: 100,
@@ -517,11 +919,16 @@ func (e *Encoder) Encode(v any) error {
 
 // Flush flushes the underlying writer.
 func (e *Encoder) Flush() error {
-	if e.count == 0 {
+	if e.count == 0 && len(e.pending) == 0 {
 		return nil
 	}
 
-	if err := e.writerBlock(); err != nil {
+	if e.count > 0 {
+		if err := e.writerBlock(); err != nil {
+			return err
+		}
+	}
+	if err := e.writePending(0); err != nil {
 		return err
 	}
 
@@ -529,8 +936,23 @@ func (e *Encoder) Flush() error {
 }
 
 // Close closes the encoder, flushing the writer and releasing codec resources.
+// The final block of a file with block transforms is written once it is closed.
+// Closing the encoder again does nothing.
 func (e *Encoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
 	err := e.Flush()
+	if err == nil {
+		err = e.writeFinal()
+	}
+	if e.workers != nil {
+		if werr := e.workers.Close(); werr != nil && err == nil {
+			err = werr
+		}
+	}
 	if c, ok := e.codec.(io.Closer); ok {
 		if cerr := c.Close(); cerr != nil && err == nil {
 			err = cerr
@@ -547,10 +969,17 @@ func (e *Encoder) Reset(w io.Writer) error {
 	if err := e.Flush(); err != nil {
 		return err
 	}
+	if err := e.writeFinal(); err != nil {
+		return err
+	}
 
 	// Generate new sync marker for the new file.
 	_, _ = rand.Read(e.header.Sync[:])
 	e.sync = e.header.Sync
+	e.blocks = 0
+	if e.t != nil {
+		e.t.reset(e.sync, e.header.Meta)
+	}
 
 	// Reset writer to new output and write header.
 	e.writer.Reset(w)
@@ -568,17 +997,55 @@ func (e *Encoder) Reset(w io.Writer) error {
 }
 
 func (e *Encoder) writerBlock() error {
-	e.writer.WriteLong(int64(e.count))
+	index := e.blocks
+	e.blocks++
 
-	b := e.codec.Encode(e.buf.Bytes())
+	if e.workers != nil {
+		job := &blockJob{count: int64(e.count), index: index, data: bytes.Clone(e.buf.Bytes())}
+		e.workers.Submit(job)
+		e.pending = append(e.pending, job)
 
-	e.writer.WriteLong(int64(len(b)))
-	_, _ = e.writer.Write(b)
+		e.count = 0
+		e.buf.Reset()
+		return e.writePending(e.workers.Size())
+	}
 
-	_, _ = e.writer.Write(e.sync[:])
+	err := e.writeBlock(int64(e.count), encodeBlock(e.codec, e.t, e.buf.Bytes(), index, int64(e.count)))
 
 	e.count = 0
 	e.buf.Reset()
+	return err
+}
+
+// writePending writes compressed blocks in order until at most n are pending.
+func (e *Encoder) writePending(n int) error {
+	for len(e.pending) > n {
+		job := e.pending[0]
+		e.pending[0] = nil
+		e.pending = e.pending[1:]
+
+		job.wait()
+		if err := e.writeBlock(job.count, job.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFinal writes the final block of a file with block transforms.
+func (e *Encoder) writeFinal() error {
+	if e.t == nil {
+		return nil
+	}
+	return e.writeBlock(0, e.t.final(e.blocks))
+}
+
+func (e *Encoder) writeBlock(count int64, b []byte) error {
+	e.writer.WriteLong(count)
+	e.writer.WriteLong(int64(len(b)))
+	_, _ = e.writer.Write(b)
+	_, _ = e.writer.Write(e.sync[:])
+
 	return e.writer.Flush()
 }
 
@@ -637,6 +1104,64 @@ func skipToEnd(reader *avro.Reader, sync [16]byte) error {
 	}
 }
 
+// skipToFinal moves the file to the final block of a file with block transforms,
+// so that blocks are appended in its place, and returns the number of blocks
+// before it. The final block is checked, so that blocks are not appended to a
+// truncated file.
+func skipToFinal(file *os.File, reader *avro.Reader, counter *countingReader, sync [16]byte, t *transformer) (int64, error) {
+	var blocks int64
+	for {
+		_ = reader.Peek()
+		offset := counter.n - int64(reader.Buffered())
+		if errors.Is(reader.Error, io.EOF) {
+			return 0, errTruncated
+		}
+
+		count := reader.ReadLong()
+		size := reader.ReadLong()
+		if reader.Error == nil && (count < 0 || size < 0) {
+			reader.Error = errors.New("invalid block header")
+		}
+		var data []byte
+		if count == 0 {
+			data = readData(reader, size)
+		} else {
+			reader.SkipNBytes(int(size))
+		}
+		var syncMark [16]byte
+		reader.Read(syncMark[:])
+		if reader.Error == nil && syncMark != sync {
+			reader.Error = errors.New("invalid block")
+		}
+		if reader.Error != nil {
+			return 0, reader.Error
+		}
+
+		if count > 0 {
+			blocks++
+			continue
+		}
+
+		index, _, err := t.decodeBlock(data, 0)
+		if err != nil {
+			return 0, err
+		}
+		if index != blocks {
+			return 0, fmt.Errorf("final block %d is out of order, expected block %d", index, blocks)
+		}
+		if _ = reader.Peek(); !errors.Is(reader.Error, io.EOF) {
+			return 0, errors.New("invalid block after the final block")
+		}
+		if err = file.Truncate(offset); err != nil {
+			return 0, err
+		}
+		if _, err = file.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		return blocks, nil
+	}
+}
+
 func defaultMarshalSchema(schema avro.Schema) ([]byte, error) {
 	return []byte(schema.String()), nil
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -457,6 +458,85 @@ func TestDecoder_WithZStandardOptions(t *testing.T) {
 	assert.ErrorContains(t, dec.Error(), "decoder: invalid block", "trailing byte in file should cause error before hitting zstd decoder")
 }
 
+func TestDecoder_WithBzip2AndXZ(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		codec ocf.CodecName
+	}{
+		{
+			name:  "bzip2",
+			file:  "testdata/full-bzip2.avro",
+			codec: ocf.Bzip2,
+		},
+		{
+			name:  "xz",
+			file:  "testdata/full-xz.avro",
+			codec: ocf.XZ,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := os.Open(test.file)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = f.Close() })
+
+			dec, err := ocf.NewDecoder(f)
+			require.NoError(t, err)
+			assert.Equal(t, []byte(test.codec), dec.Metadata()["avro.codec"])
+
+			var count int
+			for dec.HasNext() {
+				count++
+				var got FullRecord
+				err = dec.Decode(&got)
+
+				require.NoError(t, err)
+				assert.Equal(t, "I am a test record", got.Record.String)
+			}
+
+			require.NoError(t, dec.Error())
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	ocf.RegisterCodec("test-xor", func() (ocf.Codec, error) {
+		return xorCodec{}, nil
+	})
+
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(`"string"`, buf, ocf.WithCodec("test-xor"))
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode("foo"))
+	require.NoError(t, enc.Close())
+
+	assert.NotContains(t, buf.String(), "foo")
+
+	dec, err := ocf.NewDecoder(buf)
+	require.NoError(t, err)
+	require.True(t, dec.HasNext())
+	var got string
+	require.NoError(t, dec.Decode(&got))
+	assert.Equal(t, "foo", got)
+}
+
+type xorCodec struct{}
+
+func (xorCodec) Decode(b []byte) ([]byte, error) {
+	return xorCodec{}.Encode(b), nil
+}
+
+func (xorCodec) Encode(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0xff
+	}
+	return out
+}
+
 func TestDecoder_DecodeAvroError(t *testing.T) {
 	data := []byte{
 		'O', 'b', 'j', 0x01, 0x01, 0x26, 0x16, 'a', 'v', 'r', 'o', '.', 's', 'c', 'h', 'e', 'm', 'a',
@@ -568,6 +648,60 @@ func TestDecoder_WithConfig(t *testing.T) {
 	})
 }
 
+func TestDecoder_WithReaderSchema(t *testing.T) {
+	type recordV1 struct {
+		ID    int32  `avro:"id"`
+		Title string `avro:"title"`
+	}
+	type recordV2 struct {
+		ID    int64  `avro:"id"`
+		Name  string `avro:"name"`
+		Owner string `avro:"owner"`
+	}
+
+	writer := `{"type":"record","name":"test","fields":[{"name":"id","type":"int"},{"name":"title","type":"string"}]}`
+	reader := avro.MustParse(`{"type":"record","name":"test","fields":[
+		{"name":"id","type":"long"},
+		{"name":"name","aliases":["title"],"type":"string"},
+		{"name":"owner","type":"string","default":"nobody"}
+	]}`)
+
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(writer, buf, ocf.WithBlockLength(1))
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(recordV1{ID: 1, Title: "one"}))
+	require.NoError(t, enc.Encode(recordV1{ID: 2, Title: "two"}))
+	require.NoError(t, enc.Close())
+
+	for _, concurrency := range []int{0, 2} {
+		dec, err := ocf.NewDecoder(bytes.NewReader(buf.Bytes()),
+			ocf.WithReaderSchema(reader),
+			ocf.WithDecoderConcurrency(concurrency),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, avro.MustParse(writer).Fingerprint(), dec.Schema().Fingerprint())
+
+		var got []recordV2
+		for dec.HasNext() {
+			var v recordV2
+			require.NoError(t, dec.Decode(&v))
+			got = append(got, v)
+		}
+		require.NoError(t, dec.Error())
+		want := []recordV2{{ID: 1, Name: "one", Owner: "nobody"}, {ID: 2, Name: "two", Owner: "nobody"}}
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestNewDecoder_IncompatibleReaderSchema(t *testing.T) {
+	b := encodeLongs(t, 1)
+
+	_, err := ocf.NewDecoder(bytes.NewReader(b), ocf.WithReaderSchema(avro.MustParse(`"int"`)))
+
+	assert.Error(t, err)
+}
+
 func TestNewEncoder_InvalidSchema(t *testing.T) {
 	buf := &bytes.Buffer{}
 
@@ -965,6 +1099,27 @@ func TestEncoder_EncodeCompressesZStandardWithLevel(t *testing.T) {
 	assert.Equal(t, 942, buf.Len())
 }
 
+func TestEncoder_EncodeCompressesBzip2AndXZ(t *testing.T) {
+	for _, codec := range []ocf.CodecName{ocf.Bzip2, ocf.XZ} {
+		t.Run(string(codec), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc, err := ocf.NewEncoder(`"string"`, buf, ocf.WithCodec(codec))
+			require.NoError(t, err)
+			require.NoError(t, enc.Encode(strings.Repeat("a", 1000)))
+			require.NoError(t, enc.Close())
+
+			assert.Less(t, buf.Len(), 1000)
+
+			dec, err := ocf.NewDecoder(buf)
+			require.NoError(t, err)
+			require.True(t, dec.HasNext())
+			var got string
+			require.NoError(t, dec.Decode(&got))
+			assert.Equal(t, strings.Repeat("a", 1000), got)
+		})
+	}
+}
+
 func TestEncoder_EncodeError(t *testing.T) {
 	buf := &bytes.Buffer{}
 	enc, err := ocf.NewEncoder(`"long"`, buf)
@@ -1030,6 +1185,41 @@ func TestEncoder_CloseHandlesWriteBlockError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEncoderDecoder_CloseTwice(t *testing.T) {
+	for _, concurrency := range []int{0, 2} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			transform := ocf.HMACTransform(testKeys)
+			buf := &bytes.Buffer{}
+			enc, err := ocf.NewEncoder(`"long"`, buf,
+				ocf.WithEncoderConcurrency(concurrency),
+				ocf.WithEncoderBlockTransforms(transform),
+			)
+			require.NoError(t, err)
+			require.NoError(t, enc.Encode(int64(1)))
+
+			require.NoError(t, enc.Close())
+			size := buf.Len()
+			require.NoError(t, enc.Close())
+			assert.Equal(t, size, buf.Len())
+
+			dec, err := ocf.NewDecoder(buf,
+				ocf.WithDecoderConcurrency(concurrency),
+				ocf.WithDecoderBlockTransforms(transform),
+			)
+			require.NoError(t, err)
+			var got []int64
+			for v, err := range ocf.Records[int64](dec) {
+				require.NoError(t, err)
+				got = append(got, v)
+			}
+			assert.Equal(t, []int64{1}, got)
+
+			require.NoError(t, dec.Close())
+			assert.NoError(t, dec.Close())
+		})
+	}
+}
+
 func TestEncodeDecodeMetadata(t *testing.T) {
 	buf := &bytes.Buffer{}
 	enc, _ := ocf.NewEncoder(`"long"`, buf, ocf.WithMetadata(map[string][]byte{
@@ -1066,6 +1256,83 @@ func TestEncodeDecodeMetadataKeyVal(t *testing.T) {
 	assert.Equal(t, []byte("val2"), dec.Metadata()["key2"])
 }
 
+func TestEncoder_WithEncoderConcurrency(t *testing.T) {
+	syncBlock := [16]byte{9, 9, 9, 9, 9, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	encode := func(opts ...ocf.EncoderFunc) []byte {
+		buf := &bytes.Buffer{}
+		opts = append(opts, ocf.WithCodec(ocf.ZStandard), ocf.WithBlockLength(10), ocf.WithSyncBlock(syncBlock))
+		enc, err := ocf.NewEncoder(`"long"`, buf, opts...)
+		require.NoError(t, err)
+		for i := range int64(1000) {
+			require.NoError(t, enc.Encode(i))
+		}
+		require.NoError(t, enc.Flush())
+		require.NoError(t, enc.Encode(int64(1000)))
+		require.NoError(t, enc.Close())
+
+		// Skip the header, as the metadata order is random.
+		b := buf.Bytes()
+		return b[bytes.Index(b, syncBlock[:])+len(syncBlock):]
+	}
+
+	want := encode()
+	got := encode(ocf.WithEncoderConcurrency(4))
+
+	assert.Equal(t, want, got)
+}
+
+func TestDecoder_WithDecoderConcurrency(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(`"long"`, buf, ocf.WithCodec(ocf.Deflate), ocf.WithBlockLength(7))
+	require.NoError(t, err)
+	for i := range int64(1000) {
+		require.NoError(t, enc.Encode(i))
+	}
+	require.NoError(t, enc.Close())
+
+	dec, err := ocf.NewDecoder(buf, ocf.WithDecoderConcurrency(3))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dec.Close() })
+
+	var want int64
+	for dec.HasNext() {
+		var got int64
+		require.NoError(t, dec.Decode(&got))
+		require.Equal(t, want, got)
+		want++
+	}
+	require.NoError(t, dec.Error())
+	assert.Equal(t, int64(1000), want)
+}
+
+func TestDecoder_WithDecoderConcurrencyHandlesInvalidData(t *testing.T) {
+	f, err := os.Open("testdata/zstd-invalid-data.avro")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	dec, err := ocf.NewDecoder(f, ocf.WithDecoderConcurrency(2))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dec.Close() })
+
+	assert.False(t, dec.HasNext())
+	assert.Error(t, dec.Error())
+}
+
+func TestDecoder_WithDecoderConcurrencyInvalidBlock(t *testing.T) {
+	data := []byte{
+		'O', 'b', 'j', 0x01, 0x01, 0x26, 0x16, 'a', 'v', 'r', 'o', '.', 's', 'c', 'h', 'e', 'm', 'a',
+		0x0c, '"', 'l', 'o', 'n', 'g', '"', 0x00, 0xfa, 0x2b, 0x0f, 0x1a, 0xdd, 0xfd, 0x90, 0x7d, 0x87, 0x12,
+		0x15, 0x29, 0xd7, 0x1d, 0x1c, 0xdd, 0x02, 0x02, 0x02, 0xfb, 0x2b, 0x0f, 0x1a, 0xdd, 0xfd, 0x90, 0x7d,
+		0x87, 0x12, 0x15, 0x29, 0xd7, 0x1d, 0x1c, 0xdd,
+	}
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(data), ocf.WithDecoderConcurrency(2))
+	require.NoError(t, err)
+
+	assert.False(t, dec.HasNext())
+	assert.Error(t, dec.Error())
+}
+
 func TestEncode_WithSyncBlock(t *testing.T) {
 	buf := &bytes.Buffer{}
 	syncBlock := [16]byte{9, 9, 9, 9, 9, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
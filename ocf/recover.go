@@ -0,0 +1,148 @@
+package ocf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// CorruptRange is a range of a container file skipped because it is corrupt.
+type CorruptRange struct {
+	// Start is the offset of the first skipped byte.
+	Start int64
+	// End is the offset following the last skipped byte.
+	End int64
+	// Err is the reason the range was skipped.
+	Err error
+}
+
+// WithSkipCorruptBlocks makes the decoder skip corrupt blocks rather than stop.
+//
+// A block with an invalid sync marker, or that is truncated, is skipped by searching
+// for the next sync marker. A block that cannot be decompressed is skipped as a
+// whole, and when a value cannot be decoded, Decode returns the error and the rest
+// of its block is skipped. fn, if not nil, is called with each skipped range.
+//
+// In a file with block transforms, a block repeating a block already read is
+// skipped, and a block following missing blocks is reported with an empty range
+// at its offset, and read.
+func WithSkipCorruptBlocks(fn func(CorruptRange)) DecoderFunc {
+	return func(cfg *decoderConfig) {
+		cfg.SkipCorrupt = true
+		cfg.OnCorrupt = fn
+	}
+}
+
+// resync skips the corrupt block at offset start, moving to the block following
+// the next sync marker. The bytes read of the block, from offset at, are searched
+// before the rest of the file.
+func (d *Decoder) resync(start, at int64, read []byte, cause error) error {
+	// Return the bytes read ahead by the reader to the file.
+	buffered := make([]byte, d.reader.Buffered())
+	d.reader.Read(buffered)
+
+	src := io.MultiReader(bytes.NewReader(read), bytes.NewReader(buffered), d.counter.r)
+	pos, rest, err := findSync(src, d.sync, at)
+	if err != nil {
+		return fmt.Errorf("decoder: %w", err)
+	}
+
+	d.counter.r, d.counter.n = rest, pos
+	d.reader = avro.NewReader(d.counter, 1024)
+	d.reportCorrupt(start, pos, cause)
+
+	return nil
+}
+
+func (d *Decoder) reportCorrupt(start, end int64, err error) {
+	if d.onCorrupt != nil {
+		d.onCorrupt(CorruptRange{Start: start, End: end, Err: err})
+	}
+}
+
+// readData reads size bytes of block data. The data is read in chunks, so that
+// a corrupt size does not allocate more than the file holds.
+func readData(r *avro.Reader, size int64) []byte {
+	const chunkSize = 1 << 16
+
+	data := make([]byte, 0, min(size, chunkSize))
+	for int64(len(data)) < size {
+		n := int(min(size-int64(len(data)), chunkSize))
+		data = append(data, make([]byte, n)...)
+		r.Read(data[len(data)-n:])
+		if r.Error != nil {
+			break
+		}
+	}
+	return data
+}
+
+// SalvageResult describes the recovery of a container file.
+type SalvageResult struct {
+	// Records is the number of values recovered.
+	Records int64
+	// Corrupt is the ranges of the file that were skipped.
+	Corrupt []CorruptRange
+}
+
+// Salvage writes the values that can be recovered from the container file read
+// from r, with decOpts, to a new container file written to w, skipping corrupt
+// blocks. The new file has the schema, codec and metadata of the original file,
+// unless set by encOpts.
+//
+// The blocks of the new file are transformed with the block transforms set by
+// encOpts, or else with the block transforms of the original file, which must
+// be set by decOpts to read it. A file with block transforms is not salvaged
+// without them.
+//
+// An error is returned if the header of the file cannot be read.
+func Salvage(w io.Writer, r io.Reader, decOpts []DecoderFunc, encOpts ...EncoderFunc) (SalvageResult, error) {
+	var res SalvageResult
+	decOpts = append(slices.Clone(decOpts), WithSkipCorruptBlocks(func(c CorruptRange) {
+		res.Corrupt = append(res.Corrupt, c)
+	}))
+
+	dec, err := NewDecoder(r, decOpts...)
+	if err != nil {
+		return res, err
+	}
+	defer func() { _ = dec.Close() }()
+
+	meta := dec.Metadata()
+	opts := fileOptions(meta)
+	if declared := meta[transformsKey]; len(declared) > 0 {
+		ts := computeEncoderConfig(encOpts).Transforms
+		if len(ts) == 0 {
+			ts = fileTransforms(computeDecoderConfig(decOpts).Transforms, meta)
+		}
+		if len(ts) == 0 {
+			return res, fmt.Errorf("salvage: blocks are transformed with %s, which must be applied to the salvaged file", declared)
+		}
+		opts = append(opts, WithEncoderBlockTransforms(ts...))
+	}
+
+	enc, err := NewEncoderWithSchema(dec.Schema(), w, append(opts, encOpts...)...)
+	if err != nil {
+		return res, err
+	}
+
+	for dec.HasNext() {
+		var v any
+		if err = dec.Decode(&v); err != nil {
+			// The rest of the block has been skipped.
+			continue
+		}
+		if err = enc.Encode(v); err != nil {
+			return res, err
+		}
+		res.Records++
+	}
+	if err = dec.Error(); err != nil {
+		return res, err
+	}
+
+	return res, enc.Close()
+}
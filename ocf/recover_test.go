@@ -0,0 +1,259 @@
+package ocf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blockOffsets(t *testing.T, b []byte) []int64 {
+	t.Helper()
+
+	r, err := ocf.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var offsets []int64
+	for {
+		block, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		offsets = append(offsets, block.Offset)
+	}
+	return append(offsets, int64(len(b)))
+}
+
+func TestDecoder_WithSkipCorruptBlocks(t *testing.T) {
+	tests := []struct {
+		name        string
+		codec       ocf.CodecName
+		corrupt     func(b []byte, blocks []int64) []byte
+		want        []int64
+		wantRanges  func(blocks []int64) [][2]int64
+		wantDecodes int
+	}{
+		{
+			name:  "invalid sync",
+			codec: ocf.Null,
+			corrupt: func(b []byte, blocks []int64) []byte {
+				b[blocks[2]-1]++
+				return b
+			},
+			// The block following the invalid sync is found by the next sync.
+			want: []int64{0, 1, 6, 7, 8, 9},
+			wantRanges: func(blocks []int64) [][2]int64 {
+				return [][2]int64{{blocks[1], blocks[3]}}
+			},
+		},
+		{
+			name:  "invalid block header",
+			codec: ocf.Null,
+			corrupt: func(b []byte, blocks []int64) []byte {
+				b[blocks[2]] = 0x03
+				return b
+			},
+			want: []int64{0, 1, 2, 3, 6, 7, 8, 9},
+			wantRanges: func(blocks []int64) [][2]int64 {
+				return [][2]int64{{blocks[2], blocks[3]}}
+			},
+		},
+		{
+			name:  "truncated",
+			codec: ocf.Null,
+			corrupt: func(b []byte, blocks []int64) []byte {
+				return b[:len(b)-5]
+			},
+			want: []int64{0, 1, 2, 3, 4, 5, 6, 7},
+			wantRanges: func(blocks []int64) [][2]int64 {
+				return [][2]int64{{blocks[4], blocks[5] - 5}}
+			},
+		},
+		{
+			name:  "invalid compressed data",
+			codec: ocf.Snappy,
+			corrupt: func(b []byte, blocks []int64) []byte {
+				// The last byte of the block checksum.
+				b[blocks[2]-17]++
+				return b
+			},
+			want: []int64{0, 1, 4, 5, 6, 7, 8, 9},
+			wantRanges: func(blocks []int64) [][2]int64 {
+				return [][2]int64{{blocks[1], blocks[2]}}
+			},
+		},
+		{
+			name:  "invalid value",
+			codec: ocf.Null,
+			corrupt: func(b []byte, blocks []int64) []byte {
+				// The first value of the block continues into the second.
+				b[blocks[1]+2] = 0x80
+				return b
+			},
+			want: []int64{0, 1, 384, 4, 5, 6, 7, 8, 9},
+			wantRanges: func(blocks []int64) [][2]int64 {
+				return [][2]int64{{blocks[1], blocks[2]}}
+			},
+			wantDecodes: 1,
+		},
+	}
+
+	for _, test := range tests {
+		for _, concurrency := range []int{0, 2} {
+			t.Run(fmt.Sprintf("%s concurrency %d", test.name, concurrency), func(t *testing.T) {
+				b := encodeLongs(t, 10, ocf.WithBlockLength(2), ocf.WithCodec(test.codec))
+				blocks := blockOffsets(t, b)
+				b = test.corrupt(b, blocks)
+
+				var ranges [][2]int64
+				dec, err := ocf.NewDecoder(bytes.NewReader(b),
+					ocf.WithDecoderConcurrency(concurrency),
+					ocf.WithSkipCorruptBlocks(func(c ocf.CorruptRange) {
+						assert.Error(t, c.Err)
+						ranges = append(ranges, [2]int64{c.Start, c.End})
+					}),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = dec.Close() })
+
+				var got []int64
+				var decodeErrs int
+				for dec.HasNext() {
+					var v int64
+					if err = dec.Decode(&v); err != nil {
+						decodeErrs++
+						continue
+					}
+					got = append(got, v)
+				}
+
+				require.NoError(t, dec.Error())
+				assert.Equal(t, test.want, got)
+				assert.Equal(t, test.wantRanges(blocks), ranges)
+				assert.Equal(t, test.wantDecodes, decodeErrs)
+			})
+		}
+	}
+}
+
+func TestDecoder_WithSkipCorruptBlocksNilCallback(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(2))
+	b = b[:len(b)-5]
+
+	dec, err := ocf.NewDecoder(bytes.NewReader(b), ocf.WithSkipCorruptBlocks(nil))
+	require.NoError(t, err)
+
+	var n int
+	for dec.HasNext() {
+		var v int64
+		require.NoError(t, dec.Decode(&v))
+		n++
+	}
+
+	require.NoError(t, dec.Error())
+	assert.Equal(t, 8, n)
+}
+
+func TestSalvage(t *testing.T) {
+	b := encodeLongs(t, 10,
+		ocf.WithBlockLength(2),
+		ocf.WithCodec(ocf.Deflate),
+		ocf.WithMetadataKeyVal("owner", []byte("test")),
+	)
+	b = b[:len(b)-5]
+
+	buf := &bytes.Buffer{}
+	res, err := ocf.Salvage(buf, bytes.NewReader(b), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(8), res.Records)
+	require.Len(t, res.Corrupt, 1)
+	assert.ErrorIs(t, res.Corrupt[0].Err, io.ErrUnexpectedEOF)
+
+	dec, err := ocf.NewDecoder(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte(ocf.Deflate), dec.Metadata()["avro.codec"])
+	assert.Equal(t, []byte("test"), dec.Metadata()["owner"])
+
+	var got []int64
+	for dec.HasNext() {
+		var v int64
+		require.NoError(t, dec.Decode(&v))
+		got = append(got, v)
+	}
+	require.NoError(t, dec.Error())
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7}, got)
+}
+
+func TestSalvage_BlockTransforms(t *testing.T) {
+	aes := ocf.AESGCMTransform(testKeys)
+	hmac := ocf.HMACTransform(testKeys)
+	tests := []struct {
+		name     string
+		encOpts  []ocf.EncoderFunc
+		wantMeta string
+		want     ocf.BlockTransform
+	}{
+		{
+			name:     "reapplies transforms of file",
+			wantMeta: "aes-gcm",
+			want:     aes,
+		},
+		{
+			name:     "applies given transforms",
+			encOpts:  []ocf.EncoderFunc{ocf.WithEncoderBlockTransforms(hmac)},
+			wantMeta: "hmac-sha256",
+			want:     hmac,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := encodeLongs(t, 6, ocf.WithBlockLength(2), ocf.WithEncoderBlockTransforms(aes))
+			blocks := blockOffsets(t, b)
+			// The last byte of the data of the second block.
+			b[blocks[2]-17]++
+
+			buf := &bytes.Buffer{}
+			decOpts := []ocf.DecoderFunc{ocf.WithDecoderBlockTransforms(aes)}
+			res, err := ocf.Salvage(buf, bytes.NewReader(b), decOpts, test.encOpts...)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), res.Records)
+
+			_, err = ocf.NewDecoder(bytes.NewReader(buf.Bytes()))
+			require.Error(t, err)
+			dec, err := ocf.NewDecoder(buf, ocf.WithDecoderBlockTransforms(test.want))
+			require.NoError(t, err)
+			assert.Equal(t, []byte(test.wantMeta), dec.Metadata()["ocf.transforms"])
+			var got []int64
+			for v, err := range ocf.Records[int64](dec) {
+				require.NoError(t, err)
+				got = append(got, v)
+			}
+			assert.Equal(t, []int64{0, 1, 4, 5}, got)
+		})
+	}
+}
+
+func TestSalvage_BlockTransformsNotConfigured(t *testing.T) {
+	b := encodeLongs(t, 6, ocf.WithEncoderBlockTransforms(ocf.AESGCMTransform(testKeys)))
+
+	buf := &bytes.Buffer{}
+	_, err := ocf.Salvage(buf, bytes.NewReader(b), nil)
+
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
+
+func TestSalvage_InvalidHeader(t *testing.T) {
+	_, err := ocf.Salvage(&bytes.Buffer{}, bytes.NewReader([]byte{'O', 'b', 'j'}), nil)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package ocf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// SeekableDecoder is a Decoder that can move to the block at or after any
+// byte offset of a file, allowing a file to be split into byte ranges that
+// are read independently.
+//
+// To read the range [start, end) of a file, seek to start and read values
+// while the current block starts before end:
+//
+//	if _, err := dec.Seek(start, io.SeekStart); err != nil {
+//		return err
+//	}
+//	for dec.HasNext() && dec.Tell() < end {
+//		...
+//	}
+//
+// Each block of the file is read by exactly one of a set of adjoining ranges.
+type SeekableDecoder struct {
+	*Decoder
+
+	rs io.ReadSeeker
+}
+
+// NewSeekableDecoder returns a new decoder that reads from rs. Offsets are
+// relative to the start of rs, and the file header must begin at its current position.
+func NewSeekableDecoder(rs io.ReadSeeker, opts ...DecoderFunc) (*SeekableDecoder, error) {
+	pos, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("decoder: %w", err)
+	}
+
+	dec, err := newDecoder(&countingReader{r: rs, n: pos}, computeDecoderConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SeekableDecoder{
+		Decoder: dec,
+		rs:      rs,
+	}, nil
+}
+
+// Seek moves to the first block starting at or after offset, by searching for
+// the sync marker that precedes it, and returns the offset of that block. The
+// offset is interpreted according to whence, as by io.Seeker, with io.SeekCurrent
+// being relative to Tell.
+//
+// An offset within the header moves to the first block, and an offset past the
+// last block moves to the end of the file.
+func (d *SeekableDecoder) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		end, err := d.rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, fmt.Errorf("decoder: %w", err)
+		}
+		offset += end
+	default:
+		return 0, errors.New("decoder: invalid whence")
+	}
+
+	start := max(offset-int64(len(d.sync)), 0)
+	if _, err := d.rs.Seek(start, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("decoder: %w", err)
+	}
+
+	pos, rest, err := findSync(d.rs, d.sync, start)
+	if err != nil {
+		return 0, fmt.Errorf("decoder: %w", err)
+	}
+
+	d.counter.r, d.counter.n = rest, pos
+	d.reader = avro.NewReader(d.counter, 1024)
+	// Values of the previous block may remain buffered by the value decoder.
+	d.decoder = d.api.NewDecoder(d.valueSchema, d.resetReader)
+	d.pending = nil
+	d.readDone = false
+	d.count = 0
+	d.offset = pos
+	if d.t != nil {
+		d.t.seek()
+	}
+
+	return pos, nil
+}
+
+// SeekRecord moves to the nth value of the file, counting from zero, using an
+// index of the file built by Stat. The next call to Decode reads the value.
+func (d *SeekableDecoder) SeekRecord(idx *Index, n int64) error {
+	if idx.Sync != d.sync {
+		return errors.New("decoder: index is not of this file")
+	}
+	b, ok := idx.Find(n)
+	if !ok {
+		return fmt.Errorf("decoder: value %d not in file", n)
+	}
+
+	if _, err := d.Seek(b.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	if !d.HasNext() {
+		if err := d.Error(); err != nil {
+			return err
+		}
+		return fmt.Errorf("decoder: value %d not in file", n)
+	}
+
+	// Skip the values of the block before the nth value.
+	for range n - b.Record {
+		var v any
+		if err := d.Decode(&v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tell returns the offset of the block values are being read from. Before the
+// first call to HasNext, or after a Seek, it is the offset of the next block.
+func (d *SeekableDecoder) Tell() int64 {
+	return d.offset
+}
+
+// findSync returns the offset following the first sync marker in r, which is
+// at offset start, and a reader of the bytes following the sync marker. If there
+// is no sync marker, the offset of the end of r is returned.
+func findSync(r io.Reader, sync [16]byte, start int64) (int64, io.Reader, error) {
+	var window []byte
+	buf := make([]byte, 4096)
+	pos := start
+	for {
+		n, err := r.Read(buf)
+		window = append(window, buf[:n]...)
+		if i := bytes.Index(window, sync[:]); i >= 0 {
+			rest := io.MultiReader(bytes.NewReader(window[i+len(sync):]), r)
+			return pos + int64(i+len(sync)), rest, nil
+		}
+
+		// Keep enough bytes to match a sync marker split across reads.
+		if drop := len(window) - len(sync) + 1; drop > 0 {
+			pos += int64(drop)
+			window = append(window[:0], window[drop:]...)
+		}
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return pos + int64(len(window)), r, nil
+		case err != nil:
+			return 0, nil, err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ocf_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeekableDecoder_Splits(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ocf.DecoderFunc
+	}{
+		{
+			name: "sequential",
+		},
+		{
+			name: "concurrent",
+			opts: []ocf.DecoderFunc{ocf.WithDecoderConcurrency(2)},
+		},
+	}
+
+	b := encodeLongs(t, 1000, ocf.WithBlockLength(7), ocf.WithCodec(ocf.Deflate))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, splitSize := range []int64{1, 10, 100, 1000, int64(len(b))} {
+				var got []int64
+				for start := int64(0); start < int64(len(b)); start += splitSize {
+					end := start + splitSize
+
+					dec, err := ocf.NewSeekableDecoder(bytes.NewReader(b), test.opts...)
+					require.NoError(t, err)
+
+					pos, err := dec.Seek(start, io.SeekStart)
+					require.NoError(t, err)
+					assert.GreaterOrEqual(t, pos, start)
+					assert.Equal(t, pos, dec.Tell())
+
+					for dec.HasNext() && dec.Tell() < end {
+						var v int64
+						require.NoError(t, dec.Decode(&v))
+						got = append(got, v)
+					}
+					require.NoError(t, dec.Error())
+					require.NoError(t, dec.Close())
+				}
+
+				require.Len(t, got, 1000, "split size %d", splitSize)
+				for i, v := range got {
+					require.Equal(t, int64(i), v, "split size %d", splitSize)
+				}
+			}
+		})
+	}
+}
+
+func TestSeekableDecoder_SeekBackwards(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(3))
+
+	dec, err := ocf.NewSeekableDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+	first := dec.Tell()
+
+	var v int64
+	require.True(t, dec.HasNext())
+	require.NoError(t, dec.Decode(&v))
+	require.True(t, dec.HasNext())
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, first, dec.Tell())
+
+	for dec.HasNext() {
+		require.NoError(t, dec.Decode(&v))
+	}
+	assert.Equal(t, int64(len(b)), dec.Tell())
+
+	pos, err := dec.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	assert.Equal(t, first, pos)
+	assert.Equal(t, first, dec.Tell())
+
+	require.True(t, dec.HasNext())
+	require.NoError(t, dec.Decode(&v))
+	assert.Equal(t, int64(0), v)
+}
+
+func TestSeekableDecoder_SeekInvalidWhence(t *testing.T) {
+	dec, err := ocf.NewSeekableDecoder(bytes.NewReader(encodeLongs(t, 1)))
+	require.NoError(t, err)
+
+	_, err = dec.Seek(0, 3)
+
+	assert.Error(t, err)
+}
+
+func TestSeekableDecoder_SeekPastEnd(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(3))
+
+	dec, err := ocf.NewSeekableDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	pos, err := dec.Seek(10, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(b)), pos)
+
+	assert.False(t, dec.HasNext())
+	assert.NoError(t, dec.Error())
+	assert.Equal(t, int64(len(b)), dec.Tell())
+}
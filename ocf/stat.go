@@ -0,0 +1,277 @@
+package ocf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// FileStats describes a container file.
+type FileStats struct {
+	// Schema is the schema of the file.
+	Schema avro.Schema
+	// Fingerprint is the SHA256 fingerprint of the schema.
+	Fingerprint [32]byte
+	// Codec is the codec of the file.
+	Codec CodecName
+	// Records is the number of values in the file.
+	Records int64
+	// Blocks is the number of blocks in the file.
+	Blocks int64
+	// Size is the size of the file, in bytes.
+	Size int64
+	// HeaderSize is the size of the header, in bytes.
+	HeaderSize int64
+	// CompressedSize is the size of the block data, in bytes.
+	CompressedSize int64
+	// UncompressedSize is the size of the block data after decompression, in bytes.
+	// It is only set by WithUncompressedSize, and for a file with block transforms,
+	// only if they are set by WithStatBlockTransforms.
+	UncompressedSize int64
+	// Index is the index of the blocks of the file. It is only set by WithIndex.
+	Index *Index
+}
+
+type statConfig struct {
+	Index        bool
+	Uncompressed bool
+	Transforms   []BlockTransform
+}
+
+// StatFunc represents a configuration function for Stat.
+type StatFunc func(cfg *statConfig)
+
+// WithIndex makes Stat build an index of the blocks of the file.
+func WithIndex() StatFunc {
+	return func(cfg *statConfig) {
+		cfg.Index = true
+	}
+}
+
+// WithUncompressedSize makes Stat decompress the blocks of the file to
+// compute their uncompressed size.
+func WithUncompressedSize() StatFunc {
+	return func(cfg *statConfig) {
+		cfg.Uncompressed = true
+	}
+}
+
+// WithStatBlockTransforms sets the transforms reversed before blocks are
+// decompressed by WithUncompressedSize. They must be the transforms declared
+// by the file.
+func WithStatBlockTransforms(ts ...BlockTransform) StatFunc {
+	return func(cfg *statConfig) {
+		cfg.Transforms = ts
+	}
+}
+
+// Stat returns the statistics of the container file read from r. Only the
+// headers of the blocks are read, their data is skipped unless the uncompressed
+// size is requested. Values are never decoded.
+func Stat(r io.Reader, opts ...StatFunc) (FileStats, error) {
+	var cfg statConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	counter := &countingReader{r: r}
+	reader := avro.NewReader(counter, 1024)
+	position := func() int64 {
+		return counter.n - int64(reader.Buffered())
+	}
+
+	decCfg := computeDecoderConfig(nil)
+	h, err := readHeader(reader, decCfg.SchemaCache, decCfg.CodecOptions, codecModeDecode)
+	if err != nil {
+		return FileStats{}, fmt.Errorf("stat: %w", err)
+	}
+	defer func() {
+		if c, ok := h.Codec.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}()
+
+	stats := FileStats{
+		Schema:      h.Schema,
+		Fingerprint: h.Schema.Fingerprint(),
+		Codec:       normalizeCodecName(CodecName(h.Meta[codecKey])),
+		HeaderSize:  position(),
+	}
+	if cfg.Index {
+		stats.Index = &Index{Sync: h.Sync}
+	}
+	// The final block of a file with block transforms holds no values.
+	transformed := len(h.Meta[transformsKey]) > 0
+
+	// The blocks of a file with block transforms are only decompressed if the
+	// transforms are configured.
+	var t *transformer
+	if cfg.Uncompressed && transformed && len(cfg.Transforms) > 0 {
+		decodeFns, err := decodeTransforms(cfg.Transforms, h.Meta)
+		if err != nil {
+			return FileStats{}, fmt.Errorf("stat: %w", err)
+		}
+		t = newTransformer(h.Sync, h.Meta, nil, decodeFns)
+	}
+	uncompressed := cfg.Uncompressed && (!transformed || t != nil)
+
+	for {
+		_ = reader.Peek()
+		if errors.Is(reader.Error, io.EOF) {
+			break
+		}
+		offset := position()
+
+		count := reader.ReadLong()
+		size := reader.ReadLong()
+		if reader.Error == nil && (count < 0 || size < 0) {
+			reader.Error = errors.New("invalid block header")
+		}
+		if reader.Error != nil {
+			return FileStats{}, fmt.Errorf("stat: %w", reader.Error)
+		}
+
+		if uncompressed {
+			data := readData(reader, size)
+			if reader.Error == nil {
+				if data, err = decompressBlock(h.Codec, t, data, count); err != nil {
+					return FileStats{}, fmt.Errorf("stat: %w", err)
+				}
+				stats.UncompressedSize += int64(len(data))
+			}
+		} else {
+			reader.SkipNBytes(int(size))
+		}
+
+		var sync [16]byte
+		reader.Read(sync[:])
+		if reader.Error == nil && sync != h.Sync {
+			reader.Error = errors.New("invalid block")
+		}
+		if reader.Error != nil {
+			return FileStats{}, fmt.Errorf("stat: %w", reader.Error)
+		}
+
+		if transformed && count == 0 {
+			continue
+		}
+		if stats.Index != nil {
+			stats.Index.Blocks = append(stats.Index.Blocks, IndexEntry{
+				Record: stats.Records,
+				Offset: offset,
+				Count:  count,
+			})
+		}
+		stats.Records += count
+		stats.Blocks++
+		stats.CompressedSize += size
+	}
+	stats.Size = position()
+
+	return stats, nil
+}
+
+// decompressBlock reverses the block transforms of the block data, if there are
+// any, and decompresses it.
+func decompressBlock(codec Codec, t *transformer, data []byte, count int64) ([]byte, error) {
+	if t == nil {
+		return codec.Decode(data)
+	}
+	_, data, err := t.decodeBlock(data, count)
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	return codec.Decode(data)
+}
+
+// IndexSchema is the Avro schema of the entries of an index file.
+var IndexSchema = avro.MustParse(`{
+	"type": "record",
+	"name": "org.hamba.avro.ocf.IndexEntry",
+	"fields": [
+		{"name": "record", "type": "long"},
+		{"name": "offset", "type": "long"},
+		{"name": "count", "type": "long"}
+	]
+}`)
+
+const indexSyncKey = "ocf.index.sync"
+
+// Index maps the values of a container file to the blocks holding them.
+type Index struct {
+	// Sync is the sync marker of the indexed file.
+	Sync [16]byte
+	// Blocks is the blocks of the file, in order.
+	Blocks []IndexEntry
+}
+
+// IndexEntry is a block of an indexed container file.
+type IndexEntry struct {
+	// Record is the number of the first value of the block, counting from zero.
+	Record int64 `avro:"record"`
+	// Offset is the position of the block in the file, in bytes.
+	Offset int64 `avro:"offset"`
+	// Count is the number of values in the block.
+	Count int64 `avro:"count"`
+}
+
+// Find returns the block holding the nth value of the file.
+func (idx *Index) Find(n int64) (IndexEntry, bool) {
+	i := sort.Search(len(idx.Blocks), func(i int) bool {
+		b := idx.Blocks[i]
+		return b.Record+b.Count > n
+	})
+	if n < 0 || i == len(idx.Blocks) {
+		return IndexEntry{}, false
+	}
+	return idx.Blocks[i], true
+}
+
+// WriteIndex writes the index as a container file to w, to be kept alongside
+// the indexed file.
+func WriteIndex(w io.Writer, idx *Index) error {
+	enc, err := NewEncoderWithSchema(IndexSchema, w,
+		WithCodec(Deflate),
+		WithMetadataKeyVal(indexSyncKey, idx.Sync[:]),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range idx.Blocks {
+		if err = enc.Encode(b); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// ReadIndex reads an index written by WriteIndex from r.
+func ReadIndex(r io.Reader) (*Index, error) {
+	dec, err := NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dec.Close() }()
+
+	if dec.Schema().Fingerprint() != IndexSchema.Fingerprint() {
+		return nil, errors.New("ocf: not an index file")
+	}
+
+	idx := &Index{}
+	copy(idx.Sync[:], dec.Metadata()[indexSyncKey])
+	for dec.HasNext() {
+		var b IndexEntry
+		if err = dec.Decode(&b); err != nil {
+			return nil, err
+		}
+		idx.Blocks = append(idx.Blocks, b)
+	}
+	if err = dec.Error(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
@@ -0,0 +1,151 @@
+package ocf_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStat(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(4), ocf.WithCodec(ocf.Deflate))
+
+	stats, err := ocf.Stat(bytes.NewReader(b), ocf.WithUncompressedSize())
+	require.NoError(t, err)
+
+	blocks := blockOffsets(t, b)
+	assert.Equal(t, avro.Long, stats.Schema.Type())
+	assert.Equal(t, avro.MustParse(`"long"`).Fingerprint(), stats.Fingerprint)
+	assert.Equal(t, ocf.Deflate, stats.Codec)
+	assert.Equal(t, int64(10), stats.Records)
+	assert.Equal(t, int64(3), stats.Blocks)
+	assert.Equal(t, int64(len(b)), stats.Size)
+	assert.Equal(t, blocks[0], stats.HeaderSize)
+	assert.Positive(t, stats.CompressedSize)
+	// Each long between 0 and 9 is encoded as a single byte.
+	assert.Equal(t, int64(10), stats.UncompressedSize)
+	assert.Nil(t, stats.Index)
+}
+
+func TestStat_BlockTransforms(t *testing.T) {
+	transforms := []ocf.BlockTransform{ocf.CRC32CTransform(), ocf.HMACTransform(testKeys), ocf.AESGCMTransform(testKeys)}
+	for _, transform := range transforms {
+		t.Run(transform.Name(), func(t *testing.T) {
+			b := encodeLongs(t, 10,
+				ocf.WithBlockLength(4),
+				ocf.WithCodec(ocf.Deflate),
+				ocf.WithEncoderBlockTransforms(transform),
+			)
+
+			stats, err := ocf.Stat(bytes.NewReader(b), ocf.WithUncompressedSize(), ocf.WithStatBlockTransforms(transform))
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), stats.Records)
+			assert.Equal(t, int64(3), stats.Blocks)
+			assert.Equal(t, int64(10), stats.UncompressedSize)
+
+			stats, err = ocf.Stat(bytes.NewReader(b), ocf.WithUncompressedSize())
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), stats.Records)
+			assert.Zero(t, stats.UncompressedSize)
+		})
+	}
+}
+
+func TestStat_NullCodec(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(4))
+
+	stats, err := ocf.Stat(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	assert.Equal(t, ocf.Null, stats.Codec)
+	assert.Equal(t, int64(10), stats.CompressedSize)
+	assert.Zero(t, stats.UncompressedSize)
+}
+
+func TestStat_InvalidBlock(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(4))
+	b[len(b)-1]++
+
+	_, err := ocf.Stat(bytes.NewReader(b))
+
+	assert.EqualError(t, err, "stat: invalid block")
+}
+
+func TestStat_WithIndex(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(4))
+
+	stats, err := ocf.Stat(bytes.NewReader(b), ocf.WithIndex())
+	require.NoError(t, err)
+
+	blocks := blockOffsets(t, b)
+	require.NotNil(t, stats.Index)
+	want := []ocf.IndexEntry{
+		{Record: 0, Offset: blocks[0], Count: 4},
+		{Record: 4, Offset: blocks[1], Count: 4},
+		{Record: 8, Offset: blocks[2], Count: 2},
+	}
+	assert.Equal(t, want, stats.Index.Blocks)
+
+	got, ok := stats.Index.Find(5)
+	assert.True(t, ok)
+	assert.Equal(t, want[1], got)
+
+	_, ok = stats.Index.Find(10)
+	assert.False(t, ok)
+}
+
+func TestIndex_WriteRead(t *testing.T) {
+	b := encodeLongs(t, 10, ocf.WithBlockLength(4))
+	stats, err := ocf.Stat(bytes.NewReader(b), ocf.WithIndex())
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, ocf.WriteIndex(buf, stats.Index))
+
+	got, err := ocf.ReadIndex(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, stats.Index, got)
+}
+
+func TestReadIndex_NotAnIndex(t *testing.T) {
+	_, err := ocf.ReadIndex(bytes.NewReader(encodeLongs(t, 1)))
+
+	assert.Error(t, err)
+}
+
+func TestSeekableDecoder_SeekRecord(t *testing.T) {
+	b := encodeLongs(t, 100, ocf.WithBlockLength(7), ocf.WithCodec(ocf.Snappy))
+	stats, err := ocf.Stat(bytes.NewReader(b), ocf.WithIndex())
+	require.NoError(t, err)
+
+	dec, err := ocf.NewSeekableDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	for _, n := range []int64{99, 0, 42, 7, 6} {
+		require.NoError(t, dec.SeekRecord(stats.Index, n))
+
+		var v int64
+		require.True(t, dec.HasNext())
+		require.NoError(t, dec.Decode(&v))
+		assert.Equal(t, n, v)
+	}
+
+	err = dec.SeekRecord(stats.Index, 100)
+	assert.Error(t, err)
+
+	other, err := ocf.Stat(bytes.NewReader(encodeLongs(t, 1)), ocf.WithIndex())
+	require.NoError(t, err)
+	err = dec.SeekRecord(other.Index, 0)
+	assert.Error(t, err)
+}
+
+func TestStat_InvalidHeader(t *testing.T) {
+	_, err := ocf.Stat(io.LimitReader(bytes.NewReader(encodeLongs(t, 1)), 3))
+
+	assert.Error(t, err)
+}
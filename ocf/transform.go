@@ -0,0 +1,313 @@
+package ocf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// Merge writes the values of the container files read from srcs to a single
+// container file written to dst, with the schema, codec and metadata of the
+// first file, unless set by opts.
+//
+// See MergeWithSchema for how the files are copied.
+func Merge(dst io.Writer, srcs []io.Reader, opts ...EncoderFunc) error {
+	if len(srcs) == 0 {
+		return errors.New("merge: no files to merge")
+	}
+
+	first, err := newSourceReader(srcs[0], opts)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	defer func() { _ = first.Close() }()
+
+	opts = append(fileOptions(first.Metadata()), opts...)
+	w, err := NewBlockWriter(first.Schema(), dst, opts...)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	if err = w.AppendAllFrom(first); err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	if err = appendFiles(w, srcs[1:]); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	return nil
+}
+
+// MergeWithSchema writes the values of the container files read from srcs to a
+// single container file with the given schema written to dst.
+//
+// The blocks of files with the schema are copied without decoding their values,
+// and recompressed only if their codec differs. The values of files with another
+// schema are decoded, resolved to the schema and encoded again.
+//
+// The block transforms set by opts are reversed when reading the files that
+// declare them, and applied to the file written. Files with other block
+// transforms cannot be merged.
+func MergeWithSchema(schema avro.Schema, dst io.Writer, srcs []io.Reader, opts ...EncoderFunc) error {
+	w, err := NewBlockWriter(schema, dst, opts...)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	if err = appendFiles(w, srcs); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	return nil
+}
+
+func appendFiles(w *BlockWriter, srcs []io.Reader) error {
+	for i, src := range srcs {
+		if err := appendFile(w, src); err != nil {
+			return fmt.Errorf("merge: file %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// newSourceReader returns a block reader of a file copied from, reversing the
+// block transforms set by opts that the file declares.
+func newSourceReader(src io.Reader, opts []EncoderFunc) (*BlockReader, error) {
+	return newTransformedReader(src, computeEncoderConfig(opts).Transforms)
+}
+
+func newTransformedReader(src io.Reader, ts []BlockTransform) (*BlockReader, error) {
+	r, err := NewBlockReader(src)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.setTransforms(declaredTransforms(ts, r.Metadata())); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func appendFile(w *BlockWriter, src io.Reader) error {
+	r, err := newTransformedReader(src, w.transforms)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	if r.Schema().Fingerprint() == w.schema.Fingerprint() {
+		return w.AppendAllFrom(r)
+	}
+	return w.appendResolvedFrom(r)
+}
+
+// appendResolvedFrom writes all remaining blocks of r, decoding their values with
+// the schema of r resolved to the schema of the writer.
+func (w *BlockWriter) appendResolvedFrom(r *BlockReader) error {
+	if r.transformed {
+		return r.transformedError()
+	}
+
+	schema, err := avro.NewSchemaCompatibility().Resolve(w.schema, r.Schema())
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	enc := avro.NewEncoderForSchema(w.schema, buf)
+	for {
+		b, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err := r.Codec().Decode(b.Data)
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		dec := avro.NewDecoderForSchema(schema, bytes.NewReader(data))
+		for range b.Count {
+			var v any
+			if err = dec.Decode(&v); err != nil {
+				return err
+			}
+			if err = enc.Encode(v); err != nil {
+				return err
+			}
+		}
+
+		b.Data = w.Codec().Encode(buf.Bytes())
+		if err = w.WriteBlock(b); err != nil {
+			return err
+		}
+	}
+}
+
+// SplitLimit limits the files written by Split. A zero limit is no limit.
+type SplitLimit struct {
+	// Blocks is the maximum number of blocks of a file.
+	Blocks int
+	// Bytes is the maximum size of the block data of a file, after compression.
+	// A block larger than Bytes is written to a file of its own.
+	Bytes int64
+}
+
+// Split copies the blocks of the container file read from src to a sequence of
+// container files within limit. The nth file is written to the writer returned by
+// next(n), which is closed once the file is complete if it is an io.Closer.
+//
+// The files have the schema, codec and metadata of src, unless set by opts. Blocks
+// are copied without decoding their values, and recompressed only if the codec differs.
+// The block transforms set by opts are reversed when reading src, if it declares
+// them, and applied to the files written.
+func Split(src io.Reader, limit SplitLimit, next func(n int) (io.Writer, error), opts ...EncoderFunc) error {
+	r, err := newSourceReader(src, opts)
+	if err != nil {
+		return fmt.Errorf("split: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	opts = append(fileOptions(r.Metadata()), opts...)
+
+	var (
+		files  int
+		out    io.Writer
+		w      *BlockWriter
+		blocks int
+		size   int64
+	)
+	finish := func() error {
+		err := w.Close()
+		if c, ok := out.(io.Closer); ok {
+			err = errors.Join(err, c.Close())
+		}
+		w = nil
+		return err
+	}
+	defer func() {
+		if w != nil {
+			_ = finish()
+		}
+	}()
+
+	for {
+		b, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("split: %w", err)
+		}
+
+		if w != nil && limit.exceeded(blocks, size, int64(len(b.Data))) {
+			if err = finish(); err != nil {
+				return fmt.Errorf("split: %w", err)
+			}
+		}
+		if w == nil {
+			if out, err = next(files); err != nil {
+				return fmt.Errorf("split: %w", err)
+			}
+			if w, err = NewBlockWriter(r.Schema(), out, opts...); err != nil {
+				return fmt.Errorf("split: %w", err)
+			}
+			files++
+			blocks, size = 0, 0
+		}
+
+		if b, err = w.convertBlock(r, b); err != nil {
+			return fmt.Errorf("split: %w", err)
+		}
+		if err = w.WriteBlock(b); err != nil {
+			return fmt.Errorf("split: %w", err)
+		}
+		blocks++
+		size += int64(len(b.Data))
+	}
+
+	if w == nil {
+		return nil
+	}
+	if err = finish(); err != nil {
+		return fmt.Errorf("split: %w", err)
+	}
+	return nil
+}
+
+// exceeded determines if adding a block of the given size to a file with the
+// given number of blocks and size exceeds the limit.
+func (l SplitLimit) exceeded(blocks int, size, blockSize int64) bool {
+	if l.Blocks > 0 && blocks >= l.Blocks {
+		return true
+	}
+	return l.Bytes > 0 && blocks > 0 && size+blockSize > l.Bytes
+}
+
+// Recompress copies the container file read from src to dst, compressing its
+// blocks with the given codec. The values of the file are not decoded.
+//
+// The file has the schema and metadata of src, unless set by opts. The block
+// transforms set by opts are reversed when reading src, if it declares them, and
+// applied to the file written.
+func Recompress(dst io.Writer, src io.Reader, codec CodecName, opts ...EncoderFunc) error {
+	r, err := newSourceReader(src, opts)
+	if err != nil {
+		return fmt.Errorf("recompress: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	opts = append(fileOptions(r.Metadata()), append([]EncoderFunc{WithCodec(codec)}, opts...)...)
+	w, err := NewBlockWriter(r.Schema(), dst, opts...)
+	if err != nil {
+		return fmt.Errorf("recompress: %w", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	if err = w.AppendAllFrom(r); err != nil {
+		return fmt.Errorf("recompress: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("recompress: %w", err)
+	}
+	return nil
+}
+
+// fileOptions returns the options to write a file with the schema, codec and user
+// metadata of a file with the given header metadata. The metadata of block
+// transforms is not kept, as the blocks of the file are transformed again.
+func fileOptions(meta map[string][]byte) []EncoderFunc {
+	opts := []EncoderFunc{
+		WithCodec(normalizeCodecName(CodecName(meta[codecKey]))),
+		WithSchemaMarshaler(rawSchema(meta)),
+	}
+	for k, v := range meta {
+		if strings.HasPrefix(k, "avro.") || strings.HasPrefix(k, transformKeyPrefix) {
+			continue
+		}
+		opts = append(opts, WithMetadataKeyVal(k, v))
+	}
+	return opts
+}
+
+// rawSchema returns a schema marshaler that writes the schema of a file with the
+// given header metadata as it is, preserving its properties and formatting.
+func rawSchema(meta map[string][]byte) func(avro.Schema) ([]byte, error) {
+	return func(avro.Schema) ([]byte, error) {
+		return meta[schemaKey], nil
+	}
+}
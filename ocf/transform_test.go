@@ -0,0 +1,217 @@
+package ocf_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLongs(t *testing.T, r io.Reader) []int64 {
+	t.Helper()
+
+	dec, err := ocf.NewDecoder(r)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dec.Close() })
+
+	var got []int64
+	for dec.HasNext() {
+		var v int64
+		require.NoError(t, dec.Decode(&v))
+		got = append(got, v)
+	}
+	require.NoError(t, dec.Error())
+	return got
+}
+
+func TestMerge(t *testing.T) {
+	srcs := []io.Reader{
+		bytes.NewReader(encodeLongs(t, 3, ocf.WithCodec(ocf.Deflate), ocf.WithMetadataKeyVal("owner", []byte("test")))),
+		bytes.NewReader(encodeLongs(t, 2, ocf.WithCodec(ocf.Snappy))),
+		bytes.NewReader(encodeLongs(t, 1)),
+	}
+
+	buf := &bytes.Buffer{}
+	err := ocf.Merge(buf, srcs)
+	require.NoError(t, err)
+
+	b := buf.Bytes()
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(ocf.Deflate), dec.Metadata()["avro.codec"])
+	assert.Equal(t, []byte("test"), dec.Metadata()["owner"])
+	assert.Equal(t, []byte(`"long"`), dec.Metadata()["avro.schema"])
+
+	assert.Equal(t, []int64{0, 1, 2, 0, 1, 0}, decodeLongs(t, bytes.NewReader(b)))
+}
+
+func TestMerge_WithCodec(t *testing.T) {
+	srcs := []io.Reader{
+		bytes.NewReader(encodeLongs(t, 2, ocf.WithCodec(ocf.Deflate))),
+		bytes.NewReader(encodeLongs(t, 2, ocf.WithCodec(ocf.Deflate))),
+	}
+
+	buf := &bytes.Buffer{}
+	err := ocf.Merge(buf, srcs, ocf.WithCodec(ocf.ZStandard))
+	require.NoError(t, err)
+
+	b := buf.Bytes()
+	dec, err := ocf.NewDecoder(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(ocf.ZStandard), dec.Metadata()["avro.codec"])
+	assert.Equal(t, []int64{0, 1, 0, 1}, decodeLongs(t, bytes.NewReader(b)))
+}
+
+func TestMerge_NoFiles(t *testing.T) {
+	err := ocf.Merge(&bytes.Buffer{}, nil)
+
+	assert.Error(t, err)
+}
+
+func TestMergeWithSchema_ResolvesSchemas(t *testing.T) {
+	type recordV1 struct {
+		A int64 `avro:"a"`
+	}
+	type recordV2 struct {
+		A int64  `avro:"a"`
+		B string `avro:"b"`
+	}
+
+	v1 := `{"type":"record","name":"test","fields":[{"name":"a","type":"long"}]}`
+	v2 := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"long"},{"name":"b","type":"string","default":"none"}]}`)
+
+	buf1 := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(v1, buf1, ocf.WithCodec(ocf.Snappy))
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(recordV1{A: 1}))
+	require.NoError(t, enc.Close())
+
+	buf2 := &bytes.Buffer{}
+	enc, err = ocf.NewEncoderWithSchema(v2, buf2)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(recordV2{A: 2, B: "two"}))
+	require.NoError(t, enc.Close())
+
+	buf := &bytes.Buffer{}
+	err = ocf.MergeWithSchema(v2, buf, []io.Reader{buf1, buf2}, ocf.WithCodec(ocf.Deflate))
+	require.NoError(t, err)
+
+	dec, err := ocf.NewDecoder(buf)
+	require.NoError(t, err)
+	var got []recordV2
+	for dec.HasNext() {
+		var v recordV2
+		require.NoError(t, dec.Decode(&v))
+		got = append(got, v)
+	}
+	require.NoError(t, dec.Error())
+	assert.Equal(t, []recordV2{{A: 1, B: "none"}, {A: 2, B: "two"}}, got)
+}
+
+func TestMergeWithSchema_IncompatibleSchema(t *testing.T) {
+	srcs := []io.Reader{bytes.NewReader(encodeLongs(t, 1))}
+
+	err := ocf.MergeWithSchema(avro.MustParse(`"boolean"`), &bytes.Buffer{}, srcs)
+
+	assert.Error(t, err)
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit ocf.SplitLimit
+		want  [][]int64
+	}{
+		{
+			name:  "blocks",
+			limit: ocf.SplitLimit{Blocks: 2},
+			want:  [][]int64{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9}},
+		},
+		{
+			name:  "bytes",
+			limit: ocf.SplitLimit{Bytes: 5},
+			want:  [][]int64{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9}},
+		},
+		{
+			name:  "smaller than a block",
+			limit: ocf.SplitLimit{Bytes: 1},
+			want:  [][]int64{{0, 1}, {2, 3}, {4, 5}, {6, 7}, {8, 9}},
+		},
+		{
+			name: "no limit",
+			want: [][]int64{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := encodeLongs(t, 10, ocf.WithBlockLength(2))
+
+			var files []*closingBuffer
+			err := ocf.Split(bytes.NewReader(b), test.limit, func(n int) (io.Writer, error) {
+				assert.Equal(t, len(files), n)
+				files = append(files, &closingBuffer{})
+				return files[n], nil
+			})
+			require.NoError(t, err)
+
+			got := make([][]int64, 0, len(files))
+			for _, f := range files {
+				assert.True(t, f.closed)
+				got = append(got, decodeLongs(t, &f.Buffer))
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSplit_WithCodec(t *testing.T) {
+	b := encodeLongs(t, 4, ocf.WithBlockLength(2), ocf.WithCodec(ocf.Snappy))
+
+	var files []*bytes.Buffer
+	err := ocf.Split(bytes.NewReader(b), ocf.SplitLimit{Blocks: 1}, func(int) (io.Writer, error) {
+		files = append(files, &bytes.Buffer{})
+		return files[len(files)-1], nil
+	}, ocf.WithCodec(ocf.Deflate))
+	require.NoError(t, err)
+
+	require.Len(t, files, 2)
+	dec, err := ocf.NewDecoder(bytes.NewReader(files[1].Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(ocf.Deflate), dec.Metadata()["avro.codec"])
+	assert.Equal(t, []int64{2, 3}, decodeLongs(t, files[1]))
+}
+
+func TestRecompress(t *testing.T) {
+	b := encodeLongs(t, 10,
+		ocf.WithBlockLength(3),
+		ocf.WithCodec(ocf.Deflate),
+		ocf.WithMetadataKeyVal("owner", []byte("test")),
+	)
+
+	buf := &bytes.Buffer{}
+	err := ocf.Recompress(buf, bytes.NewReader(b), ocf.ZStandard)
+	require.NoError(t, err)
+
+	got := buf.Bytes()
+	dec, err := ocf.NewDecoder(bytes.NewReader(got))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(ocf.ZStandard), dec.Metadata()["avro.codec"])
+	assert.Equal(t, []byte("test"), dec.Metadata()["owner"])
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, decodeLongs(t, bytes.NewReader(got)))
+}
@@ -0,0 +1,108 @@
+package ocf
+
+import (
+	"io"
+	"iter"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// TypedDecoder reads and decodes values of type T from a container file.
+type TypedDecoder[T any] struct {
+	dec *Decoder
+}
+
+// NewTypedDecoder returns a new typed decoder that reads from reader r.
+func NewTypedDecoder[T any](r io.Reader, opts ...DecoderFunc) (*TypedDecoder[T], error) {
+	dec, err := NewDecoder(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedDecoder[T]{dec: dec}, nil
+}
+
+// Metadata returns the header metadata.
+func (d *TypedDecoder[T]) Metadata() map[string][]byte {
+	return d.dec.Metadata()
+}
+
+// Schema returns the schema of the file.
+func (d *TypedDecoder[T]) Schema() avro.Schema {
+	return d.dec.Schema()
+}
+
+// Next decodes the next value. It returns io.EOF when there are no more values,
+// and the read error if the file could not be read to its end.
+func (d *TypedDecoder[T]) Next() (T, error) {
+	var v T
+	if !d.dec.HasNext() {
+		if err := d.dec.Error(); err != nil {
+			return v, err
+		}
+		return v, io.EOF
+	}
+
+	err := d.dec.Decode(&v)
+	return v, err
+}
+
+// All returns an iterator over the remaining values.
+//
+// See Records for how errors are yielded.
+func (d *TypedDecoder[T]) All() iter.Seq2[T, error] {
+	return Records[T](d.dec)
+}
+
+// Close releases codec resources.
+func (d *TypedDecoder[T]) Close() error {
+	return d.dec.Close()
+}
+
+// TypedEncoder writes values of type T to a container file.
+type TypedEncoder[T any] struct {
+	enc *Encoder
+}
+
+// NewTypedEncoder returns a new typed encoder that writes to w using schema s.
+func NewTypedEncoder[T any](s string, w io.Writer, opts ...EncoderFunc) (*TypedEncoder[T], error) {
+	enc, err := NewEncoder(s, w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedEncoder[T]{enc: enc}, nil
+}
+
+// NewTypedEncoderWithSchema returns a new typed encoder that writes to w using schema.
+func NewTypedEncoderWithSchema[T any](schema avro.Schema, w io.Writer, opts ...EncoderFunc) (*TypedEncoder[T], error) {
+	enc, err := NewEncoderWithSchema(schema, w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedEncoder[T]{enc: enc}, nil
+}
+
+// Encode writes the Avro encoding of v to the stream.
+func (e *TypedEncoder[T]) Encode(v T) error {
+	return e.enc.Encode(v)
+}
+
+// EncodeAll writes the Avro encoding of the values of seq to the stream,
+// stopping at the first error.
+func (e *TypedEncoder[T]) EncodeAll(seq iter.Seq[T]) error {
+	for v := range seq {
+		if err := e.enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes the underlying writer.
+func (e *TypedEncoder[T]) Flush() error {
+	return e.enc.Flush()
+}
+
+// Close closes the encoder, flushing the writer and releasing codec resources.
+func (e *TypedEncoder[T]) Close() error {
+	return e.enc.Close()
+}
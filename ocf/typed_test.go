@@ -0,0 +1,66 @@
+package ocf_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/ocf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedEncoderDecoder(t *testing.T) {
+	type record struct {
+		A int64  `avro:"a"`
+		B string `avro:"b"`
+	}
+	want := []record{{A: 1, B: "one"}, {A: 2, B: "two"}, {A: 3, B: "three"}}
+
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewTypedEncoder[record](`{"type":"record","name":"test","fields":[{"name":"a","type":"long"},{"name":"b","type":"string"}]}`, buf)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(want[0]))
+	require.NoError(t, enc.EncodeAll(slices.Values(want[1:])))
+	require.NoError(t, enc.Close())
+
+	dec, err := ocf.NewTypedDecoder[record](buf)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dec.Close() })
+
+	first, err := dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, want[0], first)
+
+	var got []record
+	for v, err := range dec.All() {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	assert.Equal(t, want[1:], got)
+
+	_, err = dec.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTypedDecoder_NextReturnsReadError(t *testing.T) {
+	b := encodeLongs(t, 2, ocf.WithBlockLength(1))
+	b[len(b)-1]++
+
+	dec, err := ocf.NewTypedDecoder[int64](bytes.NewReader(b))
+	require.NoError(t, err)
+
+	v, err := dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), v)
+
+	_, err = dec.Next()
+	assert.EqualError(t, err, "decoder: invalid block")
+}
+
+func TestNewTypedEncoder_InvalidSchema(t *testing.T) {
+	_, err := ocf.NewTypedEncoder[int64](`"nope"`, &bytes.Buffer{})
+
+	assert.Error(t, err)
+}
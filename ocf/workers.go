@@ -0,0 +1,125 @@
+package ocf
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// blockJob is a block waiting to be compressed or decompressed.
+type blockJob struct {
+	count  int64
+	index  int64
+	offset int64
+	end    int64
+	data   []byte
+	err    error
+
+	done chan struct{}
+}
+
+// wait blocks until the job is processed.
+func (j *blockJob) wait() {
+	<-j.done
+}
+
+// blockWorkers compresses or decompresses blocks on a pool of goroutines,
+// each with its own codec, applying or reversing the block transforms of the
+// file if there are any.
+type blockWorkers struct {
+	jobs   chan *blockJob
+	codecs []Codec
+	t      *transformer
+	wg     sync.WaitGroup
+	closed bool
+}
+
+func newBlockWorkers(n int, newCodec func() (Codec, error), mode codecMode, t *transformer) (*blockWorkers, error) {
+	w := &blockWorkers{jobs: make(chan *blockJob, n), t: t}
+	for range n {
+		codec, err := newCodec()
+		if err != nil {
+			_ = w.Close()
+			return nil, err
+		}
+		w.codecs = append(w.codecs, codec)
+
+		w.wg.Add(1)
+		go w.run(codec, mode)
+	}
+	return w, nil
+}
+
+// Size returns the number of workers.
+func (w *blockWorkers) Size() int {
+	return len(w.codecs)
+}
+
+func (w *blockWorkers) run(codec Codec, mode codecMode) {
+	defer w.wg.Done()
+
+	for job := range w.jobs {
+		switch mode {
+		case codecModeEncode:
+			job.data = encodeBlock(codec, w.t, job.data, job.index, job.count)
+		case codecModeDecode:
+			decodeBlock(codec, w.t, job)
+		}
+		close(job.done)
+	}
+}
+
+// encodeBlock compresses the block data and applies the block transforms.
+func encodeBlock(codec Codec, t *transformer, data []byte, index, count int64) []byte {
+	data = codec.Encode(data)
+	if t != nil {
+		data = t.encodeBlock(data, index, count)
+	}
+	return data
+}
+
+// decodeBlock reverses the block transforms of the job data, setting the index
+// of the block, and decompresses it.
+func decodeBlock(codec Codec, t *transformer, job *blockJob) {
+	if t != nil {
+		if job.index, job.data, job.err = t.decodeBlock(job.data, job.count); job.err != nil {
+			return
+		}
+	}
+	if job.count > 0 {
+		job.data, job.err = codec.Decode(job.data)
+	}
+}
+
+// Submit queues the job for processing.
+func (w *blockWorkers) Submit(job *blockJob) {
+	job.done = make(chan struct{})
+	w.jobs <- job
+}
+
+// Close stops the workers and releases their codecs. Closing the workers again
+// does nothing.
+func (w *blockWorkers) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	close(w.jobs)
+	w.wg.Wait()
+
+	var errs []error
+	for _, codec := range w.codecs {
+		if c, ok := codec.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// doneJob returns a job at the given offset that needs no processing, carrying the given error.
+func doneJob(offset int64, err error) *blockJob {
+	job := &blockJob{offset: offset, err: err, done: make(chan struct{})}
+	close(job.done)
+	return job
+}
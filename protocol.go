@@ -224,14 +224,16 @@ func MustParseProtocol(protocol string) *Protocol {
 
 // ParseProtocol parses an Avro protocol.
 func ParseProtocol(protocol string) (*Protocol, error) {
-	cache := &SchemaCache{}
+	return parseProtocolBytes([]byte(protocol), &SchemaCache{}, UnionDefaultFirstType)
+}
 
+func parseProtocolBytes(protocol []byte, cache *SchemaCache, unionDefaults UnionDefaultMode) (*Protocol, error) {
 	var m map[string]any
-	if err := jsoniter.Unmarshal([]byte(protocol), &m); err != nil {
+	if err := jsoniter.Unmarshal(protocol, &m); err != nil {
 		return nil, err
 	}
 
-	seen := seenCache{}
+	seen := newSeenCache(unionDefaults)
 	return parseProtocol(m, seen, cache)
 }
 
@@ -354,7 +356,11 @@ func parseMessage(namespace string, m map[string]any, seen seenCache, cache *Sch
 				return nil, err
 			}
 
-			if rec, ok := schema.(*RecordSchema); ok && !rec.IsError() {
+			named := schema
+			if ref, ok := schema.(*RefSchema); ok {
+				named = ref.Schema()
+			}
+			if rec, ok := named.(*RecordSchema); ok && !rec.IsError() {
 				return nil, errors.New("avro: errors record schema must be of type error")
 			}
 
@@ -110,6 +110,12 @@ func (r *Reader) readByte() byte {
 	return b
 }
 
+// Buffered returns the number of bytes read from the underlying reader
+// that have not yet been consumed.
+func (r *Reader) Buffered() int {
+	return r.tail - r.head
+}
+
 // Peek returns the next byte in the buffer.
 // The Reader Error will be io.EOF if no next byte exists.
 func (r *Reader) Peek() byte {
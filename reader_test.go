@@ -57,6 +57,15 @@ func TestReader_Peek(t *testing.T) {
 	assert.Equal(t, int32(27), i)
 }
 
+func TestReader_Buffered(t *testing.T) {
+	r := avro.NewReader(bytes.NewReader([]byte{0x36, 0x02, 0x04}), 2)
+
+	_ = r.ReadInt()
+
+	require.NoError(t, r.Error)
+	assert.Equal(t, 1, r.Buffered())
+}
+
 func TestReader_PeekNoData(t *testing.T) {
 	r := (&avro.Reader{}).Reset([]byte{0x36})
 
@@ -0,0 +1,320 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SubjectVersion represents a version of a subject.
+type SubjectVersion struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// GetSubjectVersionsByID gets the subjects and versions of the schema with the given id.
+func (c *Client) GetSubjectVersionsByID(ctx context.Context, id int) ([]SubjectVersion, error) {
+	var versions []SubjectVersion
+	p := path.Join("schemas", "ids", strconv.Itoa(id), "versions")
+	if err := c.request(ctx, http.MethodGet, p, nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetSubjectsByID gets the subjects of the schema with the given id.
+func (c *Client) GetSubjectsByID(ctx context.Context, id int) ([]string, error) {
+	var subjects []string
+	p := path.Join("schemas", "ids", strconv.Itoa(id), "subjects")
+	if err := c.request(ctx, http.MethodGet, p, nil, &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// GetSubjectsIncludingDeleted gets the registry subjects, including soft deleted subjects.
+func (c *Client) GetSubjectsIncludingDeleted(ctx context.Context) ([]string, error) {
+	var subjects []string
+	if err := c.request(ctx, http.MethodGet, "subjects?deleted=true", nil, &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// GetVersionsIncludingDeleted gets the schema versions for a subject, including
+// soft deleted versions.
+func (c *Client) GetVersionsIncludingDeleted(ctx context.Context, subject string) ([]int, error) {
+	var versions []int
+	p := path.Join("subjects", subject, "versions") + "?deleted=true"
+	if err := c.request(ctx, http.MethodGet, p, nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// DeleteSubjectPermanently permanently deletes a subject. The subject must be
+// soft deleted first.
+func (c *Client) DeleteSubjectPermanently(ctx context.Context, subject string) ([]int, error) {
+	var versions []int
+	p := path.Join("subjects", subject) + "?permanent=true"
+	if err := c.request(ctx, http.MethodDelete, p, nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// DeleteSubjectVersion soft deletes a version of a subject.
+func (c *Client) DeleteSubjectVersion(ctx context.Context, subject string, version int) (int, error) {
+	return c.deleteSubjectVersion(ctx, subject, version, false)
+}
+
+// DeleteSubjectVersionPermanently permanently deletes a version of a subject.
+// The version must be soft deleted first.
+func (c *Client) DeleteSubjectVersionPermanently(ctx context.Context, subject string, version int) (int, error) {
+	return c.deleteSubjectVersion(ctx, subject, version, true)
+}
+
+func (c *Client) deleteSubjectVersion(ctx context.Context, subject string, version int, permanent bool) (int, error) {
+	var deleted int
+	p := path.Join("subjects", subject, "versions", strconv.Itoa(version))
+	if permanent {
+		p += "?permanent=true"
+	}
+	if err := c.request(ctx, http.MethodDelete, p, nil, &deleted); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// DefaultContext is the context of subjects not qualified with a context.
+const DefaultContext = "."
+
+// ContextSubject returns the subject qualified with the schema context, such as
+// ":.orders:payments-value". Subjects in the default context are returned unqualified.
+func ContextSubject(schemaContext, subject string) string {
+	if schemaContext == "" || schemaContext == DefaultContext {
+		return subject
+	}
+	if !strings.HasPrefix(schemaContext, ".") {
+		schemaContext = "." + schemaContext
+	}
+	return ":" + schemaContext + ":" + subject
+}
+
+// SplitContextSubject splits a subject qualified with a schema context into the
+// context and the unqualified subject. Unqualified subjects are in the default context.
+func SplitContextSubject(subject string) (schemaContext, name string) {
+	if !strings.HasPrefix(subject, ":.") {
+		return DefaultContext, subject
+	}
+	schemaContext, name, ok := strings.Cut(subject[1:], ":")
+	if !ok {
+		return DefaultContext, subject
+	}
+	return schemaContext, name
+}
+
+// GetContexts gets the schema contexts of the registry.
+func (c *Client) GetContexts(ctx context.Context) ([]string, error) {
+	var contexts []string
+	if err := c.request(ctx, http.MethodGet, "contexts", nil, &contexts); err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
+
+// Registry modes.
+const (
+	ReadWriteMode        string = "READWRITE"
+	ReadOnlyMode         string = "READONLY"
+	ReadOnlyOverrideMode string = "READONLY_OVERRIDE"
+	ImportMode           string = "IMPORT"
+)
+
+func validateMode(mode string) error {
+	switch mode {
+	case ReadWriteMode, ReadOnlyMode, ReadOnlyOverrideMode, ImportMode:
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %s", mode)
+	}
+}
+
+type modePayload struct {
+	Mode string `json:"mode"`
+}
+
+// SetGlobalMode sets the global mode of the registry. Force allows setting the
+// import mode when the registry has subjects.
+func (c *Client) SetGlobalMode(ctx context.Context, mode string, force bool) error {
+	return c.setMode(ctx, "mode", mode, force)
+}
+
+// SetMode sets the mode of a subject. Force allows setting the import mode when
+// the subject has versions.
+func (c *Client) SetMode(ctx context.Context, subject, mode string, force bool) error {
+	return c.setMode(ctx, path.Join("mode", subject), mode, force)
+}
+
+func (c *Client) setMode(ctx context.Context, p, mode string, force bool) error {
+	if err := validateMode(mode); err != nil {
+		return err
+	}
+
+	if force {
+		p += "?force=true"
+	}
+	return c.request(ctx, http.MethodPut, p, modePayload{Mode: mode}, nil)
+}
+
+// GetGlobalMode gets the global mode of the registry.
+func (c *Client) GetGlobalMode(ctx context.Context) (string, error) {
+	var resp modePayload
+	if err := c.request(ctx, http.MethodGet, "mode", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Mode, nil
+}
+
+// GetMode gets the mode of a subject.
+func (c *Client) GetMode(ctx context.Context, subject string) (string, error) {
+	var resp modePayload
+	if err := c.request(ctx, http.MethodGet, path.Join("mode", subject), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Mode, nil
+}
+
+// Config represents the configuration of the registry or of a subject.
+//
+// When setting a config, empty fields are left unchanged.
+type Config struct {
+	// Compatibility is the compatibility level.
+	Compatibility string
+	// Normalize determines if schemas are normalized when registered or looked up.
+	Normalize *bool
+	// Alias is the subject the subject is an alias of.
+	Alias string
+	// CompatibilityGroup is the metadata property grouping the schemas checked
+	// for compatibility.
+	CompatibilityGroup string
+	// DefaultMetadata is the metadata of schemas registered without metadata.
+	DefaultMetadata *SchemaMetadata
+	// OverrideMetadata is the metadata overriding the metadata of registered schemas.
+	OverrideMetadata *SchemaMetadata
+}
+
+type configPayload struct {
+	Compatibility      string          `json:"compatibility,omitempty"`
+	CompatibilityLevel string          `json:"compatibilityLevel,omitempty"`
+	Normalize          *bool           `json:"normalize,omitempty"`
+	Alias              string          `json:"alias,omitempty"`
+	CompatibilityGroup string          `json:"compatibilityGroup,omitempty"`
+	DefaultMetadata    *schemaMetadata `json:"defaultMetadata,omitempty"`
+	OverrideMetadata   *schemaMetadata `json:"overrideMetadata,omitempty"`
+}
+
+func newMetadataPayload(m *SchemaMetadata) *schemaMetadata {
+	if m == nil {
+		return nil
+	}
+	return &schemaMetadata{Properties: m.Properties}
+}
+
+func newMetadata(m *schemaMetadata) *SchemaMetadata {
+	if m == nil {
+		return nil
+	}
+	return &SchemaMetadata{Properties: m.Properties}
+}
+
+// SetGlobalConfig sets the global config of the registry.
+func (c *Client) SetGlobalConfig(ctx context.Context, cfg Config) error {
+	return c.setConfig(ctx, "config", cfg)
+}
+
+// SetConfig sets the config of a subject.
+func (c *Client) SetConfig(ctx context.Context, subject string, cfg Config) error {
+	return c.setConfig(ctx, path.Join("config", subject), cfg)
+}
+
+func (c *Client) setConfig(ctx context.Context, p string, cfg Config) error {
+	if cfg.Compatibility != "" {
+		if err := validateCompatibilityLevel(cfg.Compatibility); err != nil {
+			return err
+		}
+	}
+
+	req := configPayload{
+		Compatibility:      cfg.Compatibility,
+		Normalize:          cfg.Normalize,
+		Alias:              cfg.Alias,
+		CompatibilityGroup: cfg.CompatibilityGroup,
+		DefaultMetadata:    newMetadataPayload(cfg.DefaultMetadata),
+		OverrideMetadata:   newMetadataPayload(cfg.OverrideMetadata),
+	}
+	return c.request(ctx, http.MethodPut, p, req, nil)
+}
+
+// GetGlobalConfig gets the global config of the registry.
+func (c *Client) GetGlobalConfig(ctx context.Context) (Config, error) {
+	return c.getConfig(ctx, "config")
+}
+
+// GetConfig gets the config of a subject.
+func (c *Client) GetConfig(ctx context.Context, subject string) (Config, error) {
+	return c.getConfig(ctx, path.Join("config", subject))
+}
+
+func (c *Client) getConfig(ctx context.Context, p string) (Config, error) {
+	var resp configPayload
+	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		Compatibility:      resp.CompatibilityLevel,
+		Normalize:          resp.Normalize,
+		Alias:              resp.Alias,
+		CompatibilityGroup: resp.CompatibilityGroup,
+		DefaultMetadata:    newMetadata(resp.DefaultMetadata),
+		OverrideMetadata:   newMetadata(resp.OverrideMetadata),
+	}
+	if cfg.Compatibility == "" {
+		cfg.Compatibility = resp.Compatibility
+	}
+	return cfg, nil
+}
+
+// CompatibilityResult is the result of a compatibility check.
+type CompatibilityResult struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages"`
+}
+
+// CheckCompatibility checks if the schema is compatible with a version of the
+// subject, returning the messages explaining any incompatibility. A version of
+// zero checks against all versions, following the compatibility level of the
+// subject, and a version of -1 checks against the latest version.
+func (c *Client) CheckCompatibility(
+	ctx context.Context,
+	subject string,
+	version int,
+	schema string,
+	references ...SchemaReference,
+) (CompatibilityResult, error) {
+	req := schemaPayload{Schema: schema, References: references}
+	p := path.Join("compatibility", "subjects", subject, "versions")
+	if version != 0 {
+		p = path.Join(p, strconv.Itoa(version))
+	}
+
+	var resp CompatibilityResult
+	if err := c.request(ctx, http.MethodPost, p+"?verbose=true", req, &resp); err != nil {
+		return CompatibilityResult{}, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,311 @@
+package registry_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AdminRequests(t *testing.T) {
+	normalize := true
+
+	tests := []struct {
+		name      string
+		call      func(ctx context.Context, client *registry.Client) (any, error)
+		wantReq   string
+		wantBody  string
+		resp      string
+		want      any
+		wantQuery string
+	}{
+		{
+			name: "subject versions by id",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetSubjectVersionsByID(ctx, 5)
+			},
+			wantReq: "GET /schemas/ids/5/versions",
+			resp:    `[{"subject":"foo","version":1}]`,
+			want:    []registry.SubjectVersion{{Subject: "foo", Version: 1}},
+		},
+		{
+			name: "subjects by id",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetSubjectsByID(ctx, 5)
+			},
+			wantReq: "GET /schemas/ids/5/subjects",
+			resp:    `["foo","bar"]`,
+			want:    []string{"foo", "bar"},
+		},
+		{
+			name: "subjects including deleted",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetSubjectsIncludingDeleted(ctx)
+			},
+			wantReq:   "GET /subjects",
+			wantQuery: "deleted=true",
+			resp:      `["foo"]`,
+			want:      []string{"foo"},
+		},
+		{
+			name: "versions including deleted",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetVersionsIncludingDeleted(ctx, "foo")
+			},
+			wantReq:   "GET /subjects/foo/versions",
+			wantQuery: "deleted=true",
+			resp:      `[1,2]`,
+			want:      []int{1, 2},
+		},
+		{
+			name: "delete subject permanently",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.DeleteSubjectPermanently(ctx, "foo")
+			},
+			wantReq:   "DELETE /subjects/foo",
+			wantQuery: "permanent=true",
+			resp:      `[1]`,
+			want:      []int{1},
+		},
+		{
+			name: "delete subject version",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.DeleteSubjectVersion(ctx, "foo", 2)
+			},
+			wantReq: "DELETE /subjects/foo/versions/2",
+			resp:    `2`,
+			want:    2,
+		},
+		{
+			name: "delete subject version permanently",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.DeleteSubjectVersionPermanently(ctx, "foo", 2)
+			},
+			wantReq:   "DELETE /subjects/foo/versions/2",
+			wantQuery: "permanent=true",
+			resp:      `2`,
+			want:      2,
+		},
+		{
+			name: "contexts",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetContexts(ctx)
+			},
+			wantReq: "GET /contexts",
+			resp:    `[".",".orders"]`,
+			want:    []string{".", ".orders"},
+		},
+		{
+			name: "context subject",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetVersions(ctx, registry.ContextSubject(".orders", "foo"))
+			},
+			wantReq: "GET /subjects/:.orders:foo/versions",
+			resp:    `[1]`,
+			want:    []int{1},
+		},
+		{
+			name: "global mode",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetGlobalMode(ctx)
+			},
+			wantReq: "GET /mode",
+			resp:    `{"mode":"READWRITE"}`,
+			want:    registry.ReadWriteMode,
+		},
+		{
+			name: "subject mode",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetMode(ctx, "foo")
+			},
+			wantReq: "GET /mode/foo",
+			resp:    `{"mode":"READONLY"}`,
+			want:    registry.ReadOnlyMode,
+		},
+		{
+			name: "set global mode",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return nil, client.SetGlobalMode(ctx, registry.ImportMode, true)
+			},
+			wantReq:   "PUT /mode",
+			wantQuery: "force=true",
+			wantBody:  `{"mode":"IMPORT"}`,
+			resp:      `{"mode":"IMPORT"}`,
+		},
+		{
+			name: "set subject mode",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return nil, client.SetMode(ctx, "foo", registry.ReadOnlyMode, false)
+			},
+			wantReq:  "PUT /mode/foo",
+			wantBody: `{"mode":"READONLY"}`,
+			resp:     `{"mode":"READONLY"}`,
+		},
+		{
+			name: "global config",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetGlobalConfig(ctx)
+			},
+			wantReq: "GET /config",
+			resp:    `{"compatibilityLevel":"FULL","normalize":true,"defaultMetadata":{"properties":{"owner":"team"}}}`,
+			want: registry.Config{
+				Compatibility:   registry.FullCL,
+				Normalize:       &normalize,
+				DefaultMetadata: &registry.SchemaMetadata{Properties: map[string]string{"owner": "team"}},
+			},
+		},
+		{
+			name: "subject config",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.GetConfig(ctx, "foo")
+			},
+			wantReq: "GET /config/foo",
+			resp:    `{"compatibility":"NONE","alias":"bar"}`,
+			want:    registry.Config{Compatibility: registry.NoneCL, Alias: "bar"},
+		},
+		{
+			name: "set global config",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return nil, client.SetGlobalConfig(ctx, registry.Config{Compatibility: registry.FullCL, Normalize: &normalize})
+			},
+			wantReq:  "PUT /config",
+			wantBody: `{"compatibility":"FULL","normalize":true}`,
+			resp:     `{"compatibility":"FULL","normalize":true}`,
+		},
+		{
+			name: "set subject config",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return nil, client.SetConfig(ctx, "foo", registry.Config{
+					OverrideMetadata: &registry.SchemaMetadata{Properties: map[string]string{"owner": "team"}},
+				})
+			},
+			wantReq:  "PUT /config/foo",
+			wantBody: `{"overrideMetadata":{"properties":{"owner":"team"}}}`,
+			resp:     `{}`,
+		},
+		{
+			name: "check compatibility",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.CheckCompatibility(ctx, "foo", 0, `"int"`)
+			},
+			wantReq:   "POST /compatibility/subjects/foo/versions",
+			wantQuery: "verbose=true",
+			wantBody:  `{"schema":"\"int\""}`,
+			resp:      `{"is_compatible":false,"messages":["incompatible"]}`,
+			want:      registry.CompatibilityResult{Messages: []string{"incompatible"}},
+		},
+		{
+			name: "check compatibility with latest",
+			call: func(ctx context.Context, client *registry.Client) (any, error) {
+				return client.CheckCompatibility(ctx, "foo", -1, `"int"`)
+			},
+			wantReq:   "POST /compatibility/subjects/foo/versions/-1",
+			wantQuery: "verbose=true",
+			wantBody:  `{"schema":"\"int\""}`,
+			resp:      `{"is_compatible":true}`,
+			want:      registry.CompatibilityResult{IsCompatible: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.wantReq, r.Method+" "+r.URL.Path)
+				assert.Equal(t, test.wantQuery, r.URL.RawQuery)
+				if test.wantBody != "" {
+					body, _ := io.ReadAll(r.Body)
+					assert.JSONEq(t, test.wantBody, string(body))
+				}
+
+				_, _ = w.Write([]byte(test.resp))
+			}))
+			t.Cleanup(s.Close)
+			client, _ := registry.NewClient(s.URL)
+
+			got, err := test.call(context.Background(), client)
+
+			require.NoError(t, err)
+			if test.want != nil {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_AdminRequestsHandleInvalidValues(t *testing.T) {
+	client, _ := registry.NewClient("http://example.com")
+	ctx := context.Background()
+
+	assert.Error(t, client.SetGlobalMode(ctx, "INVALID", false))
+	assert.Error(t, client.SetMode(ctx, "foo", "INVALID", false))
+	assert.Error(t, client.SetGlobalConfig(ctx, registry.Config{Compatibility: "INVALID"}))
+	assert.Error(t, client.SetConfig(ctx, "foo", registry.Config{Compatibility: "INVALID"}))
+}
+
+func TestClient_AdminRequestsError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		_, _ = w.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+	ctx := context.Background()
+
+	_, err := client.GetSubjectVersionsByID(ctx, 5)
+	assert.Error(t, err)
+	_, err = client.DeleteSubjectVersion(ctx, "foo", 1)
+	assert.Error(t, err)
+	_, err = client.GetMode(ctx, "foo")
+	assert.Error(t, err)
+	_, err = client.GetConfig(ctx, "foo")
+	assert.Error(t, err)
+	_, err = client.CheckCompatibility(ctx, "foo", 1, `"int"`)
+	assert.Error(t, err)
+}
+
+func TestContextSubject(t *testing.T) {
+	tests := []struct {
+		context string
+		subject string
+		want    string
+	}{
+		{context: "", subject: "foo", want: "foo"},
+		{context: ".", subject: "foo", want: "foo"},
+		{context: ".orders", subject: "foo", want: ":.orders:foo"},
+		{context: "orders", subject: "foo", want: ":.orders:foo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			got := registry.ContextSubject(test.context, test.subject)
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSplitContextSubject(t *testing.T) {
+	tests := []struct {
+		subject     string
+		wantContext string
+		wantName    string
+	}{
+		{subject: "foo", wantContext: ".", wantName: "foo"},
+		{subject: ":.orders:foo", wantContext: ".orders", wantName: "foo"},
+		{subject: ":.orders", wantContext: ".", wantName: ":.orders"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.subject, func(t *testing.T) {
+			ctx, name := registry.SplitContextSubject(test.subject)
+
+			assert.Equal(t, test.wantContext, ctx)
+			assert.Equal(t, test.wantName, name)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// TokenSource returns bearer tokens to authenticate requests with.
+type TokenSource interface {
+	// Token returns a valid bearer token.
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc is a function returning bearer tokens.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token returns a valid bearer token.
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// TokenInvalidator is implemented by token sources caching tokens. The client
+// invalidates the cached token when the registry rejects a request as unauthorized,
+// before retrying it once with a new token.
+type TokenInvalidator interface {
+	// InvalidateToken discards the cached token.
+	InvalidateToken()
+}
+
+// StaticToken returns a token source always returning the given token.
+func StaticToken(token string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// tokenExpiryDelta is how long before their expiry tokens are refreshed.
+const tokenExpiryDelta = 10 * time.Second
+
+type clientCredentialsSource struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// ClientCredentialsTokenSource returns a token source fetching tokens from the
+// token url with the OAuth 2.0 client credentials grant. Tokens are cached and
+// refreshed shortly before they expire.
+func ClientCredentialsTokenSource(tokenURL, clientID, clientSecret string, scopes ...string) TokenSource {
+	return &clientCredentialsSource{
+		client:       defaultClient,
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+type tokenPayload struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (s *clientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expires.IsZero() || time.Now().Add(tokenExpiryDelta).Before(s.expires)) {
+		return s.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not perform token request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	var payload tokenPayload
+	if err = jsoniter.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return "", errors.New("token response has no access token")
+	}
+	if payload.TokenType != "" && !strings.EqualFold(payload.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported token type %q", payload.TokenType)
+	}
+
+	s.token = payload.AccessToken
+	s.expires = time.Time{}
+	if payload.ExpiresIn > 0 {
+		s.expires = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return s.token, nil
+}
+
+// InvalidateToken discards the cached token, so that a new token is fetched.
+func (s *clientCredentialsSource) InvalidateToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+	s.expires = time.Time{}
+}
+
+// WithBearerToken sets the token source to perform bearer auth with. A token
+// is requested from the source for each attempt of a request, and a request
+// rejected as unauthorized is retried once with a new token.
+func WithBearerToken(src TokenSource) ClientFunc {
+	return func(c *Client) {
+		c.tokens = src
+	}
+}
+
+// WithHeaders sets headers to add to every request, such as the logical
+// cluster and identity pool headers of Confluent Cloud.
+func WithHeaders(headers map[string]string) ClientFunc {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		for k, v := range headers {
+			c.headers.Set(k, v)
+		}
+	}
+}
+
+// WithTLS configures TLS from PEM files. The certificate and key files set the
+// client certificate for mutual TLS, and the CA file sets the certificate
+// authorities trusted to verify the registry. Empty files are ignored, but the
+// certificate and key files must be set together.
+//
+// The http client transport must be an *http.Transport.
+func WithTLS(certFile, keyFile, caFile string) ClientFunc {
+	return func(c *Client) {
+		c.tlsFiles = tlsFiles{cert: certFile, key: keyFile, ca: caFile}
+	}
+}
+
+type tlsFiles struct {
+	cert string
+	key  string
+	ca   string
+}
+
+func (f tlsFiles) isZero() bool {
+	return f == tlsFiles{}
+}
+
+// configureTLS replaces the http client with one using the TLS files, keeping
+// the rest of the TLS configuration of its transport.
+func (c *Client) configureTLS() error {
+	transport := http.DefaultTransport
+	if c.client.Transport != nil {
+		transport = c.client.Transport
+	}
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("cannot configure tls on transport %T", transport)
+	}
+	t = t.Clone()
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+
+	if f := c.tlsFiles; f.cert != "" || f.key != "" {
+		if f.cert == "" || f.key == "" {
+			return errors.New("tls certificate and key files must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(f.cert, f.key)
+		if err != nil {
+			return fmt.Errorf("loading tls certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.tlsFiles.ca != "" {
+		b, err := os.ReadFile(c.tlsFiles.ca)
+		if err != nil {
+			return fmt.Errorf("reading tls ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return errors.New("tls ca file contains no certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	t.TLSClientConfig = cfg
+
+	client := *c.client
+	client.Transport = t
+	c.client = &client
+	return nil
+}
+
+// requestHeader returns the header to perform requests with.
+func (c *Client) requestHeader(ctx context.Context) (http.Header, error) {
+	h := c.headers.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Content-Type", contentType)
+
+	switch {
+	case c.tokens != nil:
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting token: %w", err)
+		}
+		h.Set("Authorization", "Bearer "+token)
+	case len(c.creds.username) > 0 || len(c.creds.password) > 0:
+		req := http.Request{Header: h}
+		req.SetBasicAuth(c.creds.username, c.creds.password)
+	}
+	return h, nil
+}
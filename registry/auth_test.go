@@ -0,0 +1,385 @@
+package registry_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_BearerToken(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithBearerToken(registry.StaticToken("secret")))
+
+	_, err := client.GetSubjects(context.Background())
+
+	assert.NoError(t, err)
+}
+
+type rotatingTokens struct {
+	n           atomic.Int32
+	invalidated atomic.Bool
+}
+
+func (s *rotatingTokens) Token(context.Context) (string, error) {
+	if s.n.Load() == 0 || s.invalidated.Load() {
+		s.n.Add(1)
+		s.invalidated.Store(false)
+	}
+	return fmt.Sprintf("token-%d", s.n.Load()), nil
+}
+
+func (s *rotatingTokens) InvalidateToken() {
+	s.invalidated.Store(true)
+}
+
+func TestNewClient_BearerTokenRefreshedWhenUnauthorized(t *testing.T) {
+	var got []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error_code":40101,"message":"Unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithBearerToken(&rotatingTokens{}))
+
+	_, err := client.GetSubjects(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, got)
+}
+
+func TestNewClient_BearerTokenRefreshedOnce(t *testing.T) {
+	var count atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error_code":40101,"message":"Unauthorized"}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithBearerToken(&rotatingTokens{}))
+
+	_, err := client.GetSubjects(context.Background())
+
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, http.StatusUnauthorized, regErr.StatusCode)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestNewClient_BearerTokenError(t *testing.T) {
+	var count atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+	}))
+	t.Cleanup(s.Close)
+	src := registry.TokenSourceFunc(func(context.Context) (string, error) {
+		return "", errors.New("test")
+	})
+	client, _ := registry.NewClient(s.URL, registry.WithBearerToken(src))
+
+	_, err := client.GetSubjects(context.Background())
+
+	assert.Error(t, err)
+	assert.Zero(t, count.Load())
+}
+
+func TestNewClient_Headers(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "lsrc-123", r.Header.Get("target-sr-cluster"))
+		assert.Equal(t, "pool-123", r.Header.Get("Confluent-Identity-Pool-Id"))
+		assert.Equal(t, "application/vnd.schemaregistry.v1+json", r.Header.Get("Content-Type"))
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithHeaders(map[string]string{
+		"target-sr-cluster":          "lsrc-123",
+		"Confluent-Identity-Pool-Id": "pool-123",
+	}))
+
+	_, err := client.GetSubjects(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestClientCredentialsTokenSource(t *testing.T) {
+	var count atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		assert.Equal(t, http.MethodPost, r.Method)
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "secret", secret)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(s.Close)
+	src := registry.ClientCredentialsTokenSource(s.URL, "client", "secret", "read", "write")
+
+	for range 2 {
+		token, err := src.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestClientCredentialsTokenSource_RefreshesExpiredTokens(t *testing.T) {
+	var count atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":5}`))
+	}))
+	t.Cleanup(s.Close)
+	src := registry.ClientCredentialsTokenSource(s.URL, "client", "secret")
+
+	for range 2 {
+		_, err := src.Token(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestClientCredentialsTokenSource_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{
+			name:   "status",
+			status: http.StatusUnauthorized,
+			body:   `{"error":"invalid_client"}`,
+		},
+		{
+			name:   "invalid json",
+			status: http.StatusOK,
+			body:   `{`,
+		},
+		{
+			name:   "no access token",
+			status: http.StatusOK,
+			body:   `{"token_type":"bearer"}`,
+		},
+		{
+			name:   "token type",
+			status: http.StatusOK,
+			body:   `{"access_token":"token","token_type":"mac"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			t.Cleanup(s.Close)
+			src := registry.ClientCredentialsTokenSource(s.URL, "client", "secret")
+
+			_, err := src.Token(context.Background())
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewClient_TLS(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey := writeCertificate(t, dir, "ca", nil, nil)
+	writeCertificate(t, dir, "server", caCert, caKey)
+	writeCertificate(t, dir, "client", caCert, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	client, err := registry.NewClient(s.URL, registry.WithTLS(
+		filepath.Join(dir, "client.pem"),
+		filepath.Join(dir, "client-key.pem"),
+		filepath.Join(dir, "ca.pem"),
+	))
+	require.NoError(t, err)
+	_, err = client.GetSubjects(context.Background())
+	assert.NoError(t, err)
+
+	client, err = registry.NewClient(s.URL, registry.WithTLS("", "", filepath.Join(dir, "ca.pem")))
+	require.NoError(t, err)
+	_, err = client.GetSubjects(context.Background())
+	assert.Error(t, err)
+}
+
+func TestNewClient_TLSKeepsTransportConfig(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey := writeCertificate(t, dir, "ca", nil, nil)
+	writeCertificate(t, dir, "server", caCert, caKey)
+	writeCertificate(t, dir, "client", caCert, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	client, err := registry.NewClient(s.URL,
+		registry.WithHTTPClient(&http.Client{Transport: transport}),
+		registry.WithTLS(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem"), ""),
+	)
+	require.NoError(t, err)
+
+	_, err = client.GetSubjects(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, transport.TLSClientConfig.Certificates)
+}
+
+func TestNewClient_TLSErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeCertificate(t, dir, "client", nil, nil)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.pem"), []byte("test"), 0o600))
+
+	tests := []struct {
+		name string
+		opts []registry.ClientFunc
+	}{
+		{
+			name: "missing key",
+			opts: []registry.ClientFunc{registry.WithTLS(filepath.Join(dir, "client.pem"), "", "")},
+		},
+		{
+			name: "invalid key pair",
+			opts: []registry.ClientFunc{registry.WithTLS(filepath.Join(dir, "client.pem"), filepath.Join(dir, "empty.pem"), "")},
+		},
+		{
+			name: "missing ca file",
+			opts: []registry.ClientFunc{registry.WithTLS("", "", filepath.Join(dir, "missing.pem"))},
+		},
+		{
+			name: "empty ca file",
+			opts: []registry.ClientFunc{registry.WithTLS("", "", filepath.Join(dir, "empty.pem"))},
+		},
+		{
+			name: "unsupported transport",
+			opts: []registry.ClientFunc{
+				registry.WithHTTPClient(&http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}),
+				registry.WithTLS("", "", filepath.Join(dir, "client.pem")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := registry.NewClient("https://example.com", test.opts...)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// writeCertificate writes a certificate and key signed by the parent, or a
+// self-signed CA when parent is nil, to PEM files in dir.
+func writeCertificate(
+	t *testing.T,
+	dir, name string,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
@@ -0,0 +1,378 @@
+package registry
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// Names of the caches of a CachingRegistry, as reported to CacheMetrics.
+const (
+	CacheSchemas  = "schemas"
+	CacheVersions = "versions"
+	CacheLatest   = "latest"
+	CacheIDs      = "ids"
+)
+
+// CacheMetrics holds hooks called on cache events. The name of the cache the
+// event happened in is passed to each hook. Nil hooks are ignored. Hooks are
+// called without the cache locked, so they may use the registry.
+type CacheMetrics struct {
+	OnHit   func(cache string)
+	OnMiss  func(cache string)
+	OnEvict func(cache string)
+}
+
+// CacheFunc is a function used to customize the CachingRegistry.
+type CacheFunc func(*CachingRegistry)
+
+// WithLatestTTL sets how long the latest schema of a subject is cached. By
+// default, latest schemas are not cached.
+func WithLatestTTL(ttl time.Duration) CacheFunc {
+	return func(r *CachingRegistry) {
+		r.latestTTL = ttl
+	}
+}
+
+// WithCacheSize sets the maximum number of entries of each cache, evicting the
+// least recently used entries. By default, the caches are unbounded.
+func WithCacheSize(size int) CacheFunc {
+	return func(r *CachingRegistry) {
+		r.size = size
+	}
+}
+
+// WithCacheMetrics sets the hooks called on cache events.
+func WithCacheMetrics(metrics CacheMetrics) CacheFunc {
+	return func(r *CachingRegistry) {
+		r.metrics = metrics
+	}
+}
+
+type versionKey struct {
+	subject string
+	version int
+}
+
+type idKey struct {
+	subject string
+	schema  string
+	refs    string
+}
+
+type registeredSchema struct {
+	id     int
+	schema avro.Schema
+}
+
+// CachingRegistry is a Registry caching the responses of another Registry.
+//
+// Schemas by id, subject versions and the ids of registered schemas are
+// immutable and cached until evicted. The latest schemas of subjects are cached
+// for the latest TTL. Errors are never cached, and deleting a subject or any of
+// its versions removes its entries.
+type CachingRegistry struct {
+	Registry
+
+	latestTTL time.Duration
+	size      int
+	metrics   CacheMetrics
+
+	schemas  *lruCache[int, avro.Schema]
+	versions *lruCache[versionKey, SchemaInfo]
+	latest   *lruCache[string, SchemaInfo]
+	ids      *lruCache[idKey, registeredSchema]
+}
+
+// NewCachingRegistry returns a registry caching the responses of reg.
+func NewCachingRegistry(reg Registry, opts ...CacheFunc) *CachingRegistry {
+	r := &CachingRegistry{Registry: reg}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.schemas = newLRUCache[int, avro.Schema](CacheSchemas, r.size, 0, r.metrics)
+	r.versions = newLRUCache[versionKey, SchemaInfo](CacheVersions, r.size, 0, r.metrics)
+	r.latest = newLRUCache[string, SchemaInfo](CacheLatest, r.size, r.latestTTL, r.metrics)
+	r.ids = newLRUCache[idKey, registeredSchema](CacheIDs, r.size, 0, r.metrics)
+	return r
+}
+
+// GetSchema returns the schema with the given id.
+func (r *CachingRegistry) GetSchema(ctx context.Context, id int) (avro.Schema, error) {
+	if schema, ok := r.schemas.get(id); ok {
+		return schema, nil
+	}
+
+	schema, err := r.Registry.GetSchema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.schemas.add(id, schema)
+	return schema, nil
+}
+
+// DeleteSubject delete subject.
+func (r *CachingRegistry) DeleteSubject(ctx context.Context, subject string) ([]int, error) {
+	versions, err := r.Registry.DeleteSubject(ctx, subject)
+	r.invalidate(subject)
+	return versions, err
+}
+
+// DeleteSubjectPermanently permanently deletes a soft deleted subject.
+func (r *CachingRegistry) DeleteSubjectPermanently(ctx context.Context, subject string) ([]int, error) {
+	versions, err := r.Registry.DeleteSubjectPermanently(ctx, subject)
+	r.invalidate(subject)
+	return versions, err
+}
+
+// DeleteSubjectVersion soft deletes a version of a subject.
+func (r *CachingRegistry) DeleteSubjectVersion(ctx context.Context, subject string, version int) (int, error) {
+	deleted, err := r.Registry.DeleteSubjectVersion(ctx, subject, version)
+	r.invalidate(subject)
+	return deleted, err
+}
+
+// DeleteSubjectVersionPermanently permanently deletes a soft deleted version of a subject.
+func (r *CachingRegistry) DeleteSubjectVersionPermanently(
+	ctx context.Context,
+	subject string,
+	version int,
+) (int, error) {
+	deleted, err := r.Registry.DeleteSubjectVersionPermanently(ctx, subject, version)
+	r.invalidate(subject)
+	return deleted, err
+}
+
+// invalidate removes the entries of the subject.
+func (r *CachingRegistry) invalidate(subject string) {
+	r.versions.removeFunc(func(k versionKey) bool { return k.subject == subject })
+	r.latest.removeFunc(func(k string) bool { return k == subject })
+	r.ids.removeFunc(func(k idKey) bool { return k.subject == subject })
+}
+
+// GetSchemaByVersion gets the schema by version.
+func (r *CachingRegistry) GetSchemaByVersion(ctx context.Context, subject string, version int) (avro.Schema, error) {
+	info, err := r.GetSchemaInfo(ctx, subject, version)
+	if err != nil {
+		return nil, err
+	}
+	return info.Schema, nil
+}
+
+// GetLatestSchema gets the latest schema for a subject.
+func (r *CachingRegistry) GetLatestSchema(ctx context.Context, subject string) (avro.Schema, error) {
+	info, err := r.GetLatestSchemaInfo(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	return info.Schema, nil
+}
+
+// GetSchemaInfo gets the schema and schema metadata for a subject and version.
+// Version -1 is the latest version, cached as the latest schema of the subject.
+func (r *CachingRegistry) GetSchemaInfo(ctx context.Context, subject string, version int) (SchemaInfo, error) {
+	if version == -1 {
+		return r.GetLatestSchemaInfo(ctx, subject)
+	}
+
+	key := versionKey{subject: subject, version: version}
+	if info, ok := r.versions.get(key); ok {
+		return info, nil
+	}
+
+	info, err := r.Registry.GetSchemaInfo(ctx, subject, version)
+	if err != nil {
+		return SchemaInfo{}, err
+	}
+	r.versions.add(key, info)
+	return info, nil
+}
+
+// GetLatestSchemaInfo gets the latest schema and schema metadata for a subject.
+func (r *CachingRegistry) GetLatestSchemaInfo(ctx context.Context, subject string) (SchemaInfo, error) {
+	if r.latestTTL <= 0 {
+		return r.Registry.GetLatestSchemaInfo(ctx, subject)
+	}
+
+	if info, ok := r.latest.get(subject); ok {
+		return info, nil
+	}
+
+	info, err := r.Registry.GetLatestSchemaInfo(ctx, subject)
+	if err != nil {
+		return SchemaInfo{}, err
+	}
+	r.latest.add(subject, info)
+	return info, nil
+}
+
+// CreateSchema creates a schema in the registry, returning the schema id.
+func (r *CachingRegistry) CreateSchema(
+	ctx context.Context,
+	subject, schema string,
+	references ...SchemaReference,
+) (int, avro.Schema, error) {
+	key := newIDKey(subject, schema, references)
+	if reg, ok := r.ids.get(key); ok {
+		return reg.id, reg.schema, nil
+	}
+
+	id, sch, err := r.Registry.CreateSchema(ctx, subject, schema, references...)
+	if err != nil {
+		return id, sch, err
+	}
+	r.ids.add(key, registeredSchema{id: id, schema: sch})
+	return id, sch, nil
+}
+
+// IsRegistered determines if the schema is registered.
+func (r *CachingRegistry) IsRegistered(ctx context.Context, subject, schema string) (int, avro.Schema, error) {
+	return r.IsRegisteredWithRefs(ctx, subject, schema)
+}
+
+// IsRegisteredWithRefs determines if the schema is registered, with optional referenced schemas.
+func (r *CachingRegistry) IsRegisteredWithRefs(
+	ctx context.Context,
+	subject, schema string,
+	references ...SchemaReference,
+) (int, avro.Schema, error) {
+	key := newIDKey(subject, schema, references)
+	if reg, ok := r.ids.get(key); ok {
+		return reg.id, reg.schema, nil
+	}
+
+	id, sch, err := r.Registry.IsRegisteredWithRefs(ctx, subject, schema, references...)
+	if err != nil {
+		return id, sch, err
+	}
+	r.ids.add(key, registeredSchema{id: id, schema: sch})
+	return id, sch, nil
+}
+
+func newIDKey(subject, schema string, refs []SchemaReference) idKey {
+	var sb strings.Builder
+	for _, ref := range refs {
+		sb.WriteString(ref.Name)
+		sb.WriteByte(0)
+		sb.WriteString(ref.Subject)
+		sb.WriteByte(0)
+		sb.WriteString(strconv.Itoa(ref.Version))
+		sb.WriteByte(0)
+	}
+	return idKey{subject: subject, schema: schema, refs: sb.String()}
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	val     V
+	expires time.Time
+}
+
+// lruCache is a cache of a bounded size, evicting the least recently used
+// entries. Entries expire after the TTL, if it is set.
+type lruCache[K comparable, V any] struct {
+	name    string
+	size    int
+	ttl     time.Duration
+	metrics CacheMetrics
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+func newLRUCache[K comparable, V any](name string, size int, ttl time.Duration, metrics CacheMetrics) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		name:    name,
+		size:    size,
+		ttl:     ttl,
+		metrics: metrics,
+		ll:      list.New(),
+		items:   map[K]*list.Element{},
+	}
+}
+
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	val, ok := c.lookup(key)
+	if ok {
+		c.event(c.metrics.OnHit)
+	} else {
+		c.event(c.metrics.OnMiss)
+	}
+	return val, ok
+}
+
+func (c *lruCache[K, V]) lookup(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		if c.ttl <= 0 || time.Now().Before(entry.expires) {
+			c.ll.MoveToFront(el)
+			return entry.val, true
+		}
+		c.remove(el)
+	}
+
+	var zero V
+	return zero, false
+}
+
+func (c *lruCache[K, V]) add(key K, val V) {
+	if c.insert(key, val) {
+		c.event(c.metrics.OnEvict)
+	}
+}
+
+// insert adds the entry to the cache, reporting whether an entry was evicted.
+func (c *lruCache[K, V]) insert(key K, val V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry[K, V]{key: key, val: val}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return false
+	}
+	c.items[key] = c.ll.PushFront(entry)
+
+	if c.size > 0 && c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+		return true
+	}
+	return false
+}
+
+func (c *lruCache[K, V]) removeFunc(fn func(K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if fn(key) {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *lruCache[K, V]) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry[K, V]).key)
+}
+
+func (c *lruCache[K, V]) event(fn func(string)) {
+	if fn != nil {
+		fn(c.name)
+	}
+}
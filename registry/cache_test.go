@@ -0,0 +1,248 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cacheSchemaV1 = `{"type":"record","name":"test","fields":[{"name":"a","type":"int"}]}`
+	cacheSchemaV2 = `{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"b","type":"int","default":0}]}`
+)
+
+func newCachingRegistry(t *testing.T, opts ...registry.CacheFunc) (*registrytest.Server, *registry.CachingRegistry) {
+	t.Helper()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, registry.NewCachingRegistry(srv.Client(), opts...)
+}
+
+func TestNewCachingRegistry(t *testing.T) {
+	_, reg := newCachingRegistry(t)
+
+	assert.Implements(t, (*registry.Registry)(nil), reg)
+}
+
+func TestCachingRegistry_CachesImmutableLookups(t *testing.T) {
+	srv, reg := newCachingRegistry(t)
+	ctx := context.Background()
+
+	for range 2 {
+		id, schema, err := reg.CreateSchema(ctx, "foo", cacheSchemaV1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+		assert.NotNil(t, schema)
+
+		id, _, err = reg.IsRegistered(ctx, "foo", cacheSchemaV1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+
+		info, err := reg.GetSchemaInfo(ctx, "foo", 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, info.ID)
+
+		_, err = reg.GetSchemaByVersion(ctx, "foo", 1)
+		require.NoError(t, err)
+
+		_, err = reg.GetSchema(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	want := []string{
+		"POST /subjects/foo/versions",
+		"GET /subjects/foo/versions/1",
+		"GET /schemas/ids/1",
+	}
+	assert.Equal(t, want, srv.Requests())
+}
+
+func TestCachingRegistry_LatestTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []registry.CacheFunc
+		wantCount int
+	}{
+		{
+			name:      "not cached by default",
+			wantCount: 3,
+		},
+		{
+			name:      "cached for ttl",
+			opts:      []registry.CacheFunc{registry.WithLatestTTL(50 * time.Millisecond)},
+			wantCount: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, reg := newCachingRegistry(t, test.opts...)
+			ctx := context.Background()
+			_, err := srv.Register("foo", cacheSchemaV1)
+			require.NoError(t, err)
+
+			info, err := reg.GetLatestSchemaInfo(ctx, "foo")
+			require.NoError(t, err)
+			assert.Equal(t, 1, info.Version)
+
+			_, err = srv.Register("foo", cacheSchemaV2)
+			require.NoError(t, err)
+			_, err = reg.GetLatestSchema(ctx, "foo")
+			require.NoError(t, err)
+
+			time.Sleep(60 * time.Millisecond)
+			info, err = reg.GetLatestSchemaInfo(ctx, "foo")
+			require.NoError(t, err)
+			assert.Equal(t, 2, info.Version)
+
+			assert.Len(t, srv.Requests(), test.wantCount)
+		})
+	}
+}
+
+func TestCachingRegistry_LatestVersionNumberIsNotPinned(t *testing.T) {
+	srv, reg := newCachingRegistry(t)
+	ctx := context.Background()
+	_, err := srv.Register("foo", cacheSchemaV1)
+	require.NoError(t, err)
+
+	info, err := reg.GetSchemaInfo(ctx, "foo", -1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.Version)
+
+	_, err = srv.Register("foo", cacheSchemaV2)
+	require.NoError(t, err)
+
+	info, err = reg.GetSchemaInfo(ctx, "foo", -1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Version)
+	_, err = reg.GetSchemaByVersion(ctx, "foo", -1)
+	require.NoError(t, err)
+
+	want := []string{
+		"GET /subjects/foo/versions/latest",
+		"GET /subjects/foo/versions/latest",
+		"GET /subjects/foo/versions/latest",
+	}
+	assert.Equal(t, want, srv.Requests())
+}
+
+func TestCachingRegistry_EvictsLeastRecentlyUsed(t *testing.T) {
+	events := map[string]int{}
+	metrics := registry.CacheMetrics{
+		OnHit:   func(cache string) { events["hit "+cache]++ },
+		OnMiss:  func(cache string) { events["miss "+cache]++ },
+		OnEvict: func(cache string) { events["evict "+cache]++ },
+	}
+	srv, reg := newCachingRegistry(t, registry.WithCacheSize(2), registry.WithCacheMetrics(metrics))
+	ctx := context.Background()
+	srv.SetCompatibility("foo", registry.NoneCL)
+	for _, schema := range []string{cacheSchemaV1, cacheSchemaV2, `"int"`} {
+		_, err := srv.Register("foo", schema)
+		require.NoError(t, err)
+	}
+
+	for _, version := range []int{1, 2, 1, 3, 1, 2} {
+		_, err := reg.GetSchemaInfo(ctx, "foo", version)
+		require.NoError(t, err)
+	}
+
+	want := map[string]int{
+		"miss versions":  4,
+		"hit versions":   2,
+		"evict versions": 2,
+	}
+	assert.Equal(t, want, events)
+}
+
+func TestCachingRegistry_MetricsHooksMayUseRegistry(t *testing.T) {
+	var (
+		reg    *registry.CachingRegistry
+		nested bool
+		events int
+	)
+	ctx := context.Background()
+	// Each hook looks up a schema from the cache the event happened in.
+	hook := func(string) {
+		events++
+		if nested {
+			return
+		}
+		nested = true
+		_, err := reg.GetSchemaInfo(ctx, "foo", 1)
+		assert.NoError(t, err)
+		nested = false
+	}
+	metrics := registry.CacheMetrics{OnHit: hook, OnMiss: hook, OnEvict: hook}
+	srv, r := newCachingRegistry(t, registry.WithCacheSize(1), registry.WithCacheMetrics(metrics))
+	reg = r
+	srv.SetCompatibility("foo", registry.NoneCL)
+	for _, schema := range []string{cacheSchemaV1, cacheSchemaV2} {
+		_, err := srv.Register("foo", schema)
+		require.NoError(t, err)
+	}
+
+	for _, version := range []int{1, 2, 1} {
+		_, err := reg.GetSchemaInfo(ctx, "foo", version)
+		require.NoError(t, err)
+	}
+
+	assert.Positive(t, events)
+}
+
+func TestCachingRegistry_DeleteSubjectInvalidates(t *testing.T) {
+	_, reg := newCachingRegistry(t, registry.WithLatestTTL(time.Hour))
+	ctx := context.Background()
+
+	_, _, err := reg.CreateSchema(ctx, "foo", cacheSchemaV1)
+	require.NoError(t, err)
+	_, err = reg.GetLatestSchemaInfo(ctx, "foo")
+	require.NoError(t, err)
+
+	versions, err := reg.DeleteSubject(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions)
+
+	_, err = reg.GetLatestSchemaInfo(ctx, "foo")
+	assert.Error(t, err)
+	_, _, err = reg.IsRegistered(ctx, "foo", cacheSchemaV1)
+	assert.Error(t, err)
+}
+
+func TestCachingRegistry_DoesNotCacheErrors(t *testing.T) {
+	srv, reg := newCachingRegistry(t)
+	ctx := context.Background()
+
+	_, err := reg.GetSchemaInfo(ctx, "foo", 1)
+	assert.Error(t, err)
+
+	_, err = srv.Register("foo", cacheSchemaV1)
+	require.NoError(t, err)
+
+	info, err := reg.GetSchemaInfo(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.ID)
+}
+
+func TestCachingRegistry_DeleteSubjectVersionInvalidates(t *testing.T) {
+	srv, reg := newCachingRegistry(t)
+	ctx := context.Background()
+	_, err := srv.Register("foo", cacheSchemaV1)
+	require.NoError(t, err)
+
+	_, err = reg.GetSchemaInfo(ctx, "foo", 1)
+	require.NoError(t, err)
+
+	deleted, err := reg.DeleteSubjectVersion(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = reg.GetSchemaInfo(ctx, "foo", 1)
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -17,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aryehlev/avro/v2"
@@ -66,6 +68,64 @@ type Registry interface {
 	// IsCompatibleWithRefs determines if the schema is compatible with all schemas in the subject,
 	// with optional referenced schemas.
 	IsCompatibleWithRefs(ctx context.Context, subject, schema string, refs ...SchemaReference) (bool, error)
+
+	// GetSubjectVersionsByID gets the subjects and versions of the schema with the given id.
+	GetSubjectVersionsByID(ctx context.Context, id int) ([]SubjectVersion, error)
+
+	// GetSubjectsByID gets the subjects of the schema with the given id.
+	GetSubjectsByID(ctx context.Context, id int) ([]string, error)
+
+	// GetSubjectsIncludingDeleted gets the registry subjects, including soft deleted subjects.
+	GetSubjectsIncludingDeleted(ctx context.Context) ([]string, error)
+
+	// GetVersionsIncludingDeleted gets the schema versions for a subject, including soft deleted versions.
+	GetVersionsIncludingDeleted(ctx context.Context, subject string) ([]int, error)
+
+	// DeleteSubjectPermanently permanently deletes a soft deleted subject.
+	DeleteSubjectPermanently(ctx context.Context, subject string) ([]int, error)
+
+	// DeleteSubjectVersion soft deletes a version of a subject.
+	DeleteSubjectVersion(ctx context.Context, subject string, version int) (int, error)
+
+	// DeleteSubjectVersionPermanently permanently deletes a soft deleted version of a subject.
+	DeleteSubjectVersionPermanently(ctx context.Context, subject string, version int) (int, error)
+
+	// GetContexts gets the schema contexts of the registry.
+	GetContexts(ctx context.Context) ([]string, error)
+
+	// SetGlobalMode sets the global mode of the registry.
+	SetGlobalMode(ctx context.Context, mode string, force bool) error
+
+	// SetMode sets the mode of a subject.
+	SetMode(ctx context.Context, subject, mode string, force bool) error
+
+	// GetGlobalMode gets the global mode of the registry.
+	GetGlobalMode(ctx context.Context) (string, error)
+
+	// GetMode gets the mode of a subject.
+	GetMode(ctx context.Context, subject string) (string, error)
+
+	// SetGlobalConfig sets the global config of the registry.
+	SetGlobalConfig(ctx context.Context, cfg Config) error
+
+	// SetConfig sets the config of a subject.
+	SetConfig(ctx context.Context, subject string, cfg Config) error
+
+	// GetGlobalConfig gets the global config of the registry.
+	GetGlobalConfig(ctx context.Context) (Config, error)
+
+	// GetConfig gets the config of a subject.
+	GetConfig(ctx context.Context, subject string) (Config, error)
+
+	// CheckCompatibility checks if the schema is compatible with a version of the subject,
+	// returning the messages explaining any incompatibility.
+	CheckCompatibility(
+		ctx context.Context,
+		subject string,
+		version int,
+		schema string,
+		refs ...SchemaReference,
+	) (CompatibilityResult, error)
 }
 
 type schemaPayload struct {
@@ -90,10 +150,11 @@ type credentials struct {
 }
 
 type schemaInfoPayload struct {
-	Schema   string         `json:"schema"`
-	ID       int            `json:"id"`
-	Version  int            `json:"version"`
-	Metadata schemaMetadata `json:"metadata"`
+	Schema     string            `json:"schema"`
+	ID         int               `json:"id"`
+	Version    int               `json:"version"`
+	References []SchemaReference `json:"references"`
+	Metadata   schemaMetadata    `json:"metadata"`
 }
 
 type schemaMetadata struct {
@@ -101,8 +162,9 @@ type schemaMetadata struct {
 }
 
 // Parse converts the string schema registry response into a
-// SchemaInfo object with an avro.Schema schema.
-func (s *schemaInfoPayload) Parse() (info SchemaInfo, err error) {
+// SchemaInfo object with an avro.Schema schema, resolving the names
+// of referenced schemas from cache.
+func (s *schemaInfoPayload) Parse(cache *avro.SchemaCache) (info SchemaInfo, err error) {
 	info = SchemaInfo{
 		ID:      s.ID,
 		Version: s.Version,
@@ -110,7 +172,7 @@ func (s *schemaInfoPayload) Parse() (info SchemaInfo, err error) {
 			Properties: s.Metadata.Properties,
 		},
 	}
-	info.Schema, err = avro.Parse(s.Schema)
+	info.Schema, err = avro.ParseWithCache(s.Schema, "", cache)
 	return info, err
 }
 
@@ -164,61 +226,183 @@ func WithBasicAuth(username, password string) ClientFunc {
 	}
 }
 
+// WithRetries sets the number of times failed requests are retried. Requests
+// are retried on network errors, server errors and rate limiting, waiting a
+// backoff between attempts that doubles from initial up to maxBackoff.
+func WithRetries(retries int, initial, maxBackoff time.Duration) ClientFunc {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = initial
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithFailoverURLs sets the registry urls requests fail over to, in order, when
+// a request to the current url fails and may be retried. Every url is tried
+// before a request is retried.
+func WithFailoverURLs(urls ...string) ClientFunc {
+	return func(c *Client) {
+		c.failover = urls
+	}
+}
+
+// WithNegativeCacheTTL sets how long schema ids that are not found by the
+// registry are remembered, returning the same error without a request.
+func WithNegativeCacheTTL(ttl time.Duration) ClientFunc {
+	return func(c *Client) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithCoalescedRequestTimeout sets how long a request shared by concurrent
+// callers may take, as it is not canceled with the contexts of its callers. By
+// default, it is as long as a request may take with the timeout of the http
+// client and the retries, or a minute if the http client has no timeout.
+func WithCoalescedRequestTimeout(timeout time.Duration) ClientFunc {
+	return func(c *Client) {
+		c.flights.timeout = timeout
+	}
+}
+
 // Client is an HTTP registry client.
 type Client struct {
 	client *http.Client
-	base   *url.URL
+	bases  []*url.URL
 
-	creds credentials
+	creds    credentials
+	tokens   TokenSource
+	headers  http.Header
+	tlsFiles tlsFiles
+
+	failover    []string
+	current     atomic.Int32
+	retries     int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	negativeTTL time.Duration
+
+	flights  flightGroup
+	cache    sync.Map // map[int]avro.Schema
+	guids    sync.Map // map[[16]byte]avro.Schema
+	refs     sync.Map // map[SchemaReference]schemaPayload
+	notFound sync.Map // map[any]notFoundEntry
+}
 
-	cache sync.Map // map[int]avro.Schema
+type notFoundEntry struct {
+	err     error
+	expires time.Time
 }
 
 // NewClient creates a schema registry Client with the given base url.
 func NewClient(baseURL string, opts ...ClientFunc) (*Client, error) {
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, err
-	}
-	if !strings.HasSuffix(u.Path, "/") {
-		u.Path += "/"
-	}
-
 	c := &Client{
 		client: defaultClient,
-		base:   u,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	for _, rawURL := range append([]string{baseURL}, c.failover...) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		c.bases = append(c.bases, u)
+	}
+
+	if !c.tlsFiles.isZero() {
+		if err := c.configureTLS(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.flights.timeout <= 0 {
+		c.flights.timeout = c.requestTimeout()
+	}
+
 	return c, nil
 }
 
+// requestTimeout returns how long a request may take, trying every url on each
+// attempt and waiting the backoff between attempts.
+func (c *Client) requestTimeout() time.Duration {
+	if c.client.Timeout <= 0 {
+		return time.Minute
+	}
+
+	attempts := (c.retries + 1) * len(c.bases)
+	if c.tokens != nil {
+		// Unauthorized requests are made again with a new token.
+		attempts *= 2
+	}
+	timeout := time.Duration(attempts) * c.client.Timeout
+	backoff := c.backoff
+	for range c.retries {
+		timeout += backoff
+		backoff = min(2*backoff, c.maxBackoff)
+	}
+	return timeout
+}
+
 // GetSchema returns the schema with the given id.
 //
 // GetSchema will cache the schema in memory after it is successfully returned,
 // allowing it to be used efficiently in a high load situation.
 func (c *Client) GetSchema(ctx context.Context, id int) (avro.Schema, error) {
-	if schema, ok := c.cache.Load(id); ok {
-		return schema.(avro.Schema), nil
+	return c.getSchema(ctx, &c.cache, id, path.Join("schemas", "ids", strconv.Itoa(id)))
+}
+
+// ResolveSchema returns the schema with the given id or GUID.
+//
+// ResolveSchema will cache the schema in memory after it is successfully returned.
+func (c *Client) ResolveSchema(ctx context.Context, id SchemaID) (avro.Schema, error) {
+	if !id.IsGUID() {
+		return c.GetSchema(ctx, int(id.ID))
 	}
+	return c.getSchema(ctx, &c.guids, id.GUID, path.Join("schemas", "guids", id.String()))
+}
 
-	var resp schemaPayload
-	p := path.Join("schemas", "ids", strconv.Itoa(id))
-	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
-		return nil, err
+// getSchema returns the schema at the path, caching it by key. Concurrent lookups
+// of the same key are coalesced into a single request, which is not canceled with
+// the context of any caller, but each caller returns once its context is done.
+func (c *Client) getSchema(ctx context.Context, cache *sync.Map, key any, p string) (avro.Schema, error) {
+	if schema, ok := cache.Load(key); ok {
+		return schema.(avro.Schema), nil
+	}
+	if entry, ok := c.notFound.Load(key); ok {
+		if time.Now().Before(entry.(notFoundEntry).expires) {
+			return nil, entry.(notFoundEntry).err
+		}
+		c.notFound.Delete(key)
 	}
 
-	schema, err := avro.Parse(resp.Schema)
+	schema, err := c.flights.do(ctx, key, func(ctx context.Context) (any, error) {
+		var resp schemaPayload
+		if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
+			var regErr Error
+			if c.negativeTTL > 0 && errors.As(err, &regErr) && regErr.StatusCode == http.StatusNotFound {
+				c.notFound.Store(key, notFoundEntry{err: err, expires: time.Now().Add(c.negativeTTL)})
+			}
+			return nil, err
+		}
+
+		schema, err := c.parseSchema(ctx, resp.Schema, resp.References)
+		if err != nil {
+			return nil, err
+		}
+
+		cache.Store(key, schema)
+
+		return schema, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	c.cache.Store(id, schema)
-
-	return schema, nil
+	return schema.(avro.Schema), nil
 }
 
 // GetSubjects gets the registry subjects.
@@ -258,7 +442,7 @@ func (c *Client) GetSchemaByVersion(ctx context.Context, subject string, version
 	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
 		return nil, err
 	}
-	return avro.Parse(resp.Schema)
+	return c.parseSchema(ctx, resp.Schema, resp.References)
 }
 
 // GetLatestSchema gets the latest schema for a subject.
@@ -268,7 +452,7 @@ func (c *Client) GetLatestSchema(ctx context.Context, subject string) (avro.Sche
 	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
 		return nil, err
 	}
-	return avro.Parse(resp.Schema)
+	return c.parseSchema(ctx, resp.Schema, resp.References)
 }
 
 // GetSchemaInfo gets the schema and schema metadata for a subject and version.
@@ -278,7 +462,7 @@ func (c *Client) GetSchemaInfo(ctx context.Context, subject string, version int)
 	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
 		return SchemaInfo{}, err
 	}
-	return resp.Parse()
+	return c.parseSchemaInfo(ctx, &resp)
 }
 
 // GetLatestSchemaInfo gets the latest schema and schema metadata for a subject.
@@ -288,7 +472,7 @@ func (c *Client) GetLatestSchemaInfo(ctx context.Context, subject string) (Schem
 	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
 		return SchemaInfo{}, err
 	}
-	return resp.Parse()
+	return c.parseSchemaInfo(ctx, &resp)
 }
 
 // CreateSchema creates a schema in the registry, returning the schema id.
@@ -417,25 +601,147 @@ func (c *Client) GetCompatibilityLevel(ctx context.Context, subject string) (str
 	return resp.Compatibility, nil
 }
 
+// parseSchema parses the schema in a cache isolated from other schemas, after
+// the schemas it references.
+func (c *Client) parseSchema(ctx context.Context, schema string, refs []SchemaReference) (avro.Schema, error) {
+	cache, err := c.resolveReferences(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	return avro.ParseWithCache(schema, "", cache)
+}
+
+// parseSchemaInfo parses the schema info in a cache isolated from other schemas,
+// after the schemas it references.
+func (c *Client) parseSchemaInfo(ctx context.Context, payload *schemaInfoPayload) (SchemaInfo, error) {
+	cache, err := c.resolveReferences(ctx, payload.References)
+	if err != nil {
+		return SchemaInfo{}, err
+	}
+	return payload.Parse(cache)
+}
+
+// resolveReferences returns a new cache holding the referenced schemas, fetched
+// and parsed recursively in dependency order.
+func (c *Client) resolveReferences(ctx context.Context, refs []SchemaReference) (*avro.SchemaCache, error) {
+	cache := &avro.SchemaCache{}
+	seen := map[SchemaReference]bool{}
+
+	var resolve func(refs []SchemaReference) error
+	resolve = func(refs []SchemaReference) error {
+		for _, ref := range refs {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			payload, err := c.getReference(ctx, ref)
+			if err != nil {
+				return fmt.Errorf("getting reference %q: %w", ref.Name, err)
+			}
+			if err = resolve(payload.References); err != nil {
+				return err
+			}
+			if _, err = avro.ParseWithCache(payload.Schema, "", cache); err != nil {
+				return fmt.Errorf("parsing reference %q: %w", ref.Name, err)
+			}
+		}
+		return nil
+	}
+	if err := resolve(refs); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// getReference returns the referenced schema, caching it once it is fetched.
+func (c *Client) getReference(ctx context.Context, ref SchemaReference) (schemaPayload, error) {
+	if payload, ok := c.refs.Load(ref); ok {
+		return payload.(schemaPayload), nil
+	}
+
+	var resp schemaPayload
+	p := path.Join("subjects", ref.Subject, "versions", strconv.Itoa(ref.Version))
+	if err := c.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
+		return schemaPayload{}, err
+	}
+
+	c.refs.Store(ref, resp)
+	return resp, nil
+}
+
 func (c *Client) request(ctx context.Context, method, path string, in, out any) error {
-	var body io.Reader
+	var body []byte
 	if in != nil {
-		b, _ := jsoniter.Marshal(in)
-		body = bytes.NewReader(b)
+		body, _ = jsoniter.Marshal(in)
 	}
 
-	// These errors are not possible as we have already parse the base URL.
-	u, _ := c.base.Parse(path)
-	req, _ := http.NewRequestWithContext(ctx, method, u.String(), body)
-	req.Header.Set("Content-Type", contentType)
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		var (
+			retry bool
+			err   error
+		)
+		for range c.bases {
+			i := c.current.Load()
+			if retry, err = c.doAuthorized(ctx, c.bases[i], method, path, body, out); !retry {
+				return err
+			}
+			c.current.CompareAndSwap(i, (i+1)%int32(len(c.bases)))
+		}
+		if attempt >= c.retries {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, c.maxBackoff)
+	}
+}
 
-	if len(c.creds.username) > 0 || len(c.creds.password) > 0 {
-		req.SetBasicAuth(c.creds.username, c.creds.password)
+// doAuthorized performs a request against the base url with the current request
+// header. A request rejected as unauthorized with a bearer token is performed
+// again once, after invalidating the token.
+func (c *Client) doAuthorized(ctx context.Context, base *url.URL, method, path string, body []byte, out any) (bool, error) {
+	for refreshed := false; ; refreshed = true {
+		header, err := c.requestHeader(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		retry, err := c.do(ctx, base, method, path, header, body, out)
+		var regErr Error
+		if refreshed || c.tokens == nil || !errors.As(err, &regErr) || regErr.StatusCode != http.StatusUnauthorized {
+			return retry, err
+		}
+		if inv, ok := c.tokens.(TokenInvalidator); ok {
+			inv.InvalidateToken()
+		}
 	}
+}
+
+// do performs a request against the base url, returning if it may be retried.
+func (c *Client) do(
+	ctx context.Context,
+	base *url.URL,
+	method, path string,
+	header http.Header,
+	body []byte,
+	out any,
+) (bool, error) {
+	// These errors are not possible as we have already parse the base URL.
+	u, _ := base.Parse(path)
+	req, _ := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	req.Header = header.Clone()
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("could not perform request: %w", err)
+		return ctx.Err() == nil, fmt.Errorf("could not perform request: %w", err)
 	}
 	defer func() {
 		_, _ = io.Copy(io.Discard, resp.Body)
@@ -445,13 +751,14 @@ func (c *Client) request(ctx context.Context, method, path string, in, out any)
 	if resp.StatusCode >= http.StatusBadRequest {
 		err := Error{StatusCode: resp.StatusCode}
 		_ = jsoniter.NewDecoder(resp.Body).Decode(&err)
-		return err
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retry, err
 	}
 
 	if out != nil {
-		return jsoniter.NewDecoder(resp.Body).Decode(out)
+		return false, jsoniter.NewDecoder(resp.Body).Decode(out)
 	}
-	return nil
+	return false, nil
 }
 
 // Error is returned by the registry when there is an error.
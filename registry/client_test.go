@@ -6,8 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aryehlev/avro/v2"
 	"github.com/aryehlev/avro/v2/registry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,6 +145,98 @@ func TestClient_GetSchemaSchemaError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// referenceServer serves a schema with id 5 referencing the "Order" schema,
+// which itself references the "Item" schema. Requests are counted by path.
+func referenceServer(t *testing.T) (*httptest.Server, map[string]int) {
+	t.Helper()
+
+	count := map[string]int{}
+	schemas := map[string]string{
+		"/schemas/ids/5": `{"schema":"{\"type\":\"record\",\"name\":\"Envelope\",\"fields\":[{\"name\":\"order\",\"type\":\"Order\"},{\"name\":\"item\",\"type\":\"Item\"}]}",` +
+			`"references":[{"name":"Order","subject":"order","version":1},{"name":"Item","subject":"item","version":2}]}`,
+		"/subjects/envelope/versions/latest": `{"id":5,"version":3,"schema":"{\"type\":\"record\",\"name\":\"Envelope\",\"fields\":[{\"name\":\"order\",\"type\":\"Order\"}]}",` +
+			`"references":[{"name":"Order","subject":"order","version":1}]}`,
+		"/subjects/order/versions/1": `{"schema":"{\"type\":\"record\",\"name\":\"Order\",\"fields\":[{\"name\":\"item\",\"type\":\"Item\"}]}",` +
+			`"references":[{"name":"Item","subject":"item","version":2}]}`,
+		"/subjects/item/versions/2": `{"schema":"{\"type\":\"record\",\"name\":\"Item\",\"fields\":[{\"name\":\"id\",\"type\":\"int\"}]}"}`,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count[r.URL.Path]++
+
+		schema, ok := schemas[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(schema))
+	}))
+	t.Cleanup(s.Close)
+	return s, count
+}
+
+func TestClient_GetSchemaResolvesReferences(t *testing.T) {
+	s, count := referenceServer(t)
+	client, _ := registry.NewClient(s.URL)
+
+	schema, err := client.GetSchema(context.Background(), 5)
+	require.NoError(t, err)
+
+	type item struct {
+		ID int `avro:"id"`
+	}
+	type envelope struct {
+		Order struct {
+			Item item `avro:"item"`
+		} `avro:"order"`
+		Item item `avro:"item"`
+	}
+	var want envelope
+	want.Order.Item.ID = 1
+	want.Item.ID = 2
+	b, err := avro.Marshal(schema, want)
+	require.NoError(t, err)
+	var got envelope
+	require.NoError(t, avro.Unmarshal(schema, b, &got))
+	assert.Equal(t, want, got)
+	assert.Nil(t, avro.DefaultSchemaCache.Get("Order"))
+	assert.Equal(t, map[string]int{
+		"/schemas/ids/5":             1,
+		"/subjects/order/versions/1": 1,
+		"/subjects/item/versions/2":  1,
+	}, count)
+}
+
+func TestClient_GetLatestSchemaInfoResolvesCachedReferences(t *testing.T) {
+	s, count := referenceServer(t)
+	client, _ := registry.NewClient(s.URL)
+
+	_, err := client.GetSchema(context.Background(), 5)
+	require.NoError(t, err)
+
+	info, err := client.GetLatestSchemaInfo(context.Background(), "envelope")
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, info.ID)
+	assert.Equal(t, "Envelope", info.Schema.(avro.NamedSchema).FullName())
+	assert.Equal(t, 1, count["/subjects/order/versions/1"])
+	assert.Equal(t, 1, count["/subjects/item/versions/2"])
+}
+
+func TestClient_GetSchemaReferenceError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/5" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"schema":"\"Order\"","references":[{"name":"Order","subject":"order","version":1}]}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+
+	_, err := client.GetSchema(context.Background(), 5)
+
+	assert.EqualError(t, err, `getting reference "Order": registry error: 404`)
+}
 func TestClient_GetSubjects(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "GET", r.Method)
@@ -882,3 +978,219 @@ func TestError_ErrorEmptyMessage(t *testing.T) {
 
 	assert.Equal(t, "registry error: 404", str)
 }
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		opts      []registry.ClientFunc
+		wantCount int
+		wantErr   require.ErrorAssertionFunc
+	}{
+		{
+			name:      "retries server errors",
+			status:    http.StatusServiceUnavailable,
+			opts:      []registry.ClientFunc{registry.WithRetries(2, time.Millisecond, time.Millisecond)},
+			wantCount: 3,
+			wantErr:   require.NoError,
+		},
+		{
+			name:      "retries rate limiting",
+			status:    http.StatusTooManyRequests,
+			opts:      []registry.ClientFunc{registry.WithRetries(2, time.Millisecond, time.Millisecond)},
+			wantCount: 3,
+			wantErr:   require.NoError,
+		},
+		{
+			name:      "gives up after retries",
+			status:    http.StatusServiceUnavailable,
+			opts:      []registry.ClientFunc{registry.WithRetries(1, time.Millisecond, time.Millisecond)},
+			wantCount: 2,
+			wantErr:   require.Error,
+		},
+		{
+			name:      "does not retry by default",
+			status:    http.StatusServiceUnavailable,
+			wantCount: 1,
+			wantErr:   require.Error,
+		},
+		{
+			name:      "does not retry client errors",
+			status:    http.StatusNotFound,
+			opts:      []registry.ClientFunc{registry.WithRetries(2, time.Millisecond, time.Millisecond)},
+			wantCount: 1,
+			wantErr:   require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				count++
+				if count < 3 {
+					w.WriteHeader(test.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"schema":"\"int\""}`))
+			}))
+			t.Cleanup(s.Close)
+			client, _ := registry.NewClient(s.URL, test.opts...)
+
+			_, err := client.GetSchema(context.Background(), 5)
+
+			test.wantErr(t, err)
+			assert.Equal(t, test.wantCount, count)
+		})
+	}
+}
+
+func TestClient_RetryBackoffHonoursContext(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithRetries(5, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	t.Cleanup(cancel)
+	_, err := client.GetSchema(ctx, 5)
+
+	assert.Error(t, err)
+}
+
+func TestClient_FailoverURLs(t *testing.T) {
+	var primary, secondary int
+	s1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(s1.Close)
+	s2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary++
+		_, _ = w.Write([]byte(`["foo"]`))
+	}))
+	t.Cleanup(s2.Close)
+	client, err := registry.NewClient(s1.URL, registry.WithFailoverURLs(s2.URL))
+	require.NoError(t, err)
+
+	for range 2 {
+		subjects, err := client.GetSubjects(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo"}, subjects)
+	}
+
+	assert.Equal(t, 1, primary)
+	assert.Equal(t, 2, secondary)
+}
+
+func TestClient_FailoverURLsError(t *testing.T) {
+	_, err := registry.NewClient("http://example.com", registry.WithFailoverURLs("://"))
+
+	assert.Error(t, err)
+}
+
+func TestClient_GetSchemaCoalescesRequests(t *testing.T) {
+	var count atomic.Int32
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"schema":"\"int\""}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			schema, err := client.GetSchema(context.Background(), 5)
+			assert.NoError(t, err)
+			assert.Equal(t, avro.Int, schema.Type())
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestClient_GetSchemaCoalescedRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"schema":"\"int\""}`))
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(release) })
+	client, _ := registry.NewClient(s.URL, registry.WithCoalescedRequestTimeout(20*time.Millisecond))
+
+	_, err := client.GetSchema(context.Background(), 5)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_GetSchemaCoalescedRequestOutlivesCanceledCaller(t *testing.T) {
+	var count atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		close(started)
+		<-release
+		_, _ = w.Write([]byte(`{"schema":"\"int\""}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := client.GetSchema(ctx, 5)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan avro.Schema)
+	go func() {
+		schema, err := client.GetSchema(context.Background(), 5)
+		assert.NoError(t, err)
+		second <- schema
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+	schema := <-second
+	require.NotNil(t, schema)
+	assert.Equal(t, avro.Int, schema.Type())
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestClient_GetSchemaNegativeCache(t *testing.T) {
+	count := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL, registry.WithNegativeCacheTTL(50*time.Millisecond))
+
+	_, err := client.GetSchema(context.Background(), 5)
+	assert.EqualError(t, err, "Schema not found")
+	_, err = client.GetSchema(context.Background(), 5)
+	assert.EqualError(t, err, "Schema not found")
+	assert.Equal(t, 1, count)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetSchema(context.Background(), 5)
+	assert.Error(t, err)
+	assert.Equal(t, 2, count)
+}
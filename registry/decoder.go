@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/aryehlev/avro/v2"
 )
@@ -19,17 +20,70 @@ func WithAPI(api avro.API) DecoderFunc {
 	}
 }
 
+// WithSchemaResolver sets the resolver schemas are looked up with, instead of the client.
+func WithSchemaResolver(resolver SchemaResolver) DecoderFunc {
+	return func(d *Decoder) {
+		d.resolver = resolver
+	}
+}
+
+// WithWireFormats sets the wire formats payloads may be framed in. The format of
+// each payload is detected, trying the formats in order. By default, only the
+// Confluent wire format is decoded.
+func WithWireFormats(formats ...WireFormat) DecoderFunc {
+	return func(d *Decoder) {
+		d.formats = formats
+	}
+}
+
+// WithReaderSchema sets the schema data is decoded into. The schema each payload
+// was written with is resolved against it once per schema id.
+func WithReaderSchema(schema avro.Schema) DecoderFunc {
+	return func(d *Decoder) {
+		d.reader = schema
+	}
+}
+
+// WithWriterSchemaFallback makes the decoder decode data with the schema it was
+// written with when it cannot be resolved against the reader schema, rather than
+// returning an error.
+func WithWriterSchemaFallback() DecoderFunc {
+	return func(d *Decoder) {
+		d.fallback = true
+	}
+}
+
+// resolvedKey identifies a resolved schema by the writer schema id and the full
+// form of the reader schema, as the defaults and aliases the canonical form drops
+// change the resolution.
+type resolvedKey struct {
+	id     SchemaID
+	reader string
+}
+
+type resolvedSchema struct {
+	schema avro.Schema
+	err    error
+}
+
 // Decoder decodes confluent wire formatted avro payloads.
 type Decoder struct {
-	client *Client
-	api    avro.API
+	resolver SchemaResolver
+	formats  []WireFormat
+	api      avro.API
+	reader   avro.Schema
+	fallback bool
+
+	resolved sync.Map // map[resolvedKey]resolvedSchema
+	forms    sync.Map // map[avro.Schema]string
 }
 
 // NewDecoder returns a decoder that will get schemas from client.
 func NewDecoder(client *Client, opts ...DecoderFunc) *Decoder {
 	d := &Decoder{
-		client: client,
-		api:    avro.DefaultConfig,
+		resolver: client,
+		formats:  []WireFormat{ConfluentFormat()},
+		api:      avro.DefaultConfig,
 	}
 	for _, opt := range opts {
 		opt(d)
@@ -43,21 +97,75 @@ func NewDecoder(client *Client, opts ...DecoderFunc) *Decoder {
 // See:
 // https://docs.confluent.io/3.2.0/schema-registry/docs/serializer-formatter.html#wire-format.
 func (d *Decoder) Decode(ctx context.Context, data []byte, v any) error {
-	if len(data) < 6 {
-		return errors.New("data too short")
+	return d.decode(ctx, data, nil, d.reader, v)
+}
+
+// DecodeWithHeaders decodes the message with the given data and headers into v.
+// The headers are used by formats carrying the schema id in a header.
+func (d *Decoder) DecodeWithHeaders(ctx context.Context, data []byte, headers map[string][]byte, v any) error {
+	return d.decode(ctx, data, headers, d.reader, v)
+}
+
+// DecodeWithReaderSchema decodes data into v, resolving the schema the data was
+// written with against the given reader schema.
+func (d *Decoder) DecodeWithReaderSchema(ctx context.Context, data []byte, reader avro.Schema, v any) error {
+	return d.decode(ctx, data, nil, reader, v)
+}
+
+func (d *Decoder) decode(ctx context.Context, data []byte, headers map[string][]byte, reader avro.Schema, v any) error {
+	format, err := detectFormat(d.formats, data, headers)
+	if err != nil {
+		return err
 	}
 
-	id, err := extractSchemaID(data)
+	id, payload, err := format.Extract(data, headers)
 	if err != nil {
-		return fmt.Errorf("extracting schema id: %w", err)
+		return err
 	}
 
-	schema, err := d.client.GetSchema(ctx, id)
+	schema, err := d.resolver.ResolveSchema(ctx, id)
 	if err != nil {
 		return fmt.Errorf("getting schema: %w", err)
 	}
 
-	return d.api.Unmarshal(schema, data[5:], v)
+	if reader != nil {
+		if schema, err = d.resolve(id, reader, schema); err != nil {
+			return err
+		}
+	}
+
+	return d.api.Unmarshal(schema, payload, v)
+}
+
+// resolve returns the writer schema with the given id resolved against the reader
+// schema, caching the resolution.
+func (d *Decoder) resolve(id SchemaID, reader, writer avro.Schema) (avro.Schema, error) {
+	key := resolvedKey{id: id, reader: d.schemaForm(reader)}
+	if res, ok := d.resolved.Load(key); ok {
+		return res.(resolvedSchema).schema, res.(resolvedSchema).err
+	}
+
+	schema, err := avro.NewSchemaCompatibility().Resolve(reader, writer)
+	if err != nil {
+		if d.fallback {
+			schema, err = writer, nil
+		} else {
+			schema, err = nil, fmt.Errorf("resolving schema %s: %w", id, err)
+		}
+	}
+
+	d.resolved.Store(key, resolvedSchema{schema: schema, err: err})
+	return schema, err
+}
+
+// schemaForm returns the full form of the reader schema, caching it by schema.
+func (d *Decoder) schemaForm(reader avro.Schema) string {
+	if form, ok := d.forms.Load(reader); ok {
+		return form.(string)
+	}
+	form := schemaForm(reader)
+	d.forms.Store(reader, form)
+	return form
 }
 
 func extractSchemaID(data []byte) (int, error) {
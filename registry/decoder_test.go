@@ -2,11 +2,14 @@ package registry_test
 
 import (
 	"context"
+	"encoding/binary"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/aryehlev/avro/v2"
 	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,3 +72,96 @@ func TestDecoder_Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoder_DecodeWithReaderSchemasDifferingInDefault(t *testing.T) {
+	type record struct {
+		A int    `avro:"a"`
+		B string `avro:"b"`
+	}
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	id, err := srv.Register("test", `{"type":"record","name":"test","fields":[{"name":"a","type":"int"}]}`)
+	require.NoError(t, err)
+	data := binary.BigEndian.AppendUint32([]byte{0x0}, uint32(id))
+	data = append(data, 0x2)
+	decoder := registry.NewDecoder(srv.Client())
+
+	for _, def := range []string{"one", "two"} {
+		reader := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"b","type":"string","default":"` + def + `"}]}`)
+
+		var got record
+		err = decoder.DecodeWithReaderSchema(context.Background(), data, reader, &got)
+
+		require.NoError(t, err)
+		assert.Equal(t, record{A: 1, B: def}, got)
+	}
+}
+
+func TestDecoder_DecodeWithReaderSchema(t *testing.T) {
+	type record struct {
+		A int    `avro:"a"`
+		B string `avro:"b"`
+		C string `avro:"c"`
+	}
+
+	writer := `{"type":"record","name":"test","fields":[{"name":"a","type":"int"}]}`
+	compatible := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"b","type":"string","default":"none"}]}`)
+	incompatible := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"c","type":"string"}]}`)
+
+	tests := []struct {
+		name    string
+		reader  avro.Schema
+		opts    []registry.DecoderFunc
+		want    record
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "resolves schema",
+			reader:  compatible,
+			want:    record{A: 1, B: "none"},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "handles incompatible schema",
+			reader:  incompatible,
+			wantErr: require.Error,
+		},
+		{
+			name:    "falls back to writer schema",
+			reader:  incompatible,
+			opts:    []registry.DecoderFunc{registry.WithWriterSchemaFallback()},
+			want:    record{A: 1},
+			wantErr: require.NoError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := registrytest.NewServer()
+			t.Cleanup(srv.Close)
+			id, err := srv.Register("test", writer)
+			require.NoError(t, err)
+
+			data := binary.BigEndian.AppendUint32([]byte{0x0}, uint32(id))
+			data = append(data, 0x2)
+
+			opts := append([]registry.DecoderFunc{registry.WithReaderSchema(test.reader)}, test.opts...)
+			decoder := registry.NewDecoder(srv.Client(), opts...)
+
+			for range 2 {
+				var got record
+				err = decoder.Decode(context.Background(), data, &got)
+
+				test.wantErr(t, err)
+				assert.Equal(t, test.want, got)
+			}
+
+			var got record
+			err = registry.NewDecoder(srv.Client(), test.opts...).
+				DecodeWithReaderSchema(context.Background(), data, test.reader, &got)
+
+			test.wantErr(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/aryehlev/avro/v2"
 	"github.com/aryehlev/avro/v2/registry"
 )
 
@@ -33,3 +34,26 @@ func Example() {
 	fmt.Println("id: ", id)
 	fmt.Println("schema: ", schema)
 }
+
+func ExampleSerializer() {
+	reg, err := registry.NewClient("http://example.com")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	schema := avro.MustParse(`{"type":"record","name":"order","fields":[{"name":"id","type":"long"}]}`)
+	serializer, err := registry.NewSerializer(reg, schema, registry.WithAutoRegister())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	type order struct {
+		ID int64 `avro:"id"`
+	}
+	b, err := serializer.Serialize(context.Background(), "orders", order{ID: 1})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("payload: ", b)
+}
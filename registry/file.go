@@ -0,0 +1,481 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/aryehlev/avro/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Registry error codes returned by FileRegistry.
+const (
+	codeSubjectNotFound       = 40401
+	codeVersionNotFound       = 40402
+	codeSchemaNotFound        = 40403
+	codeConfigNotFound        = 40408
+	codeInvalidVersion        = 42202
+	codeOperationNotPermitted = 42205
+)
+
+// errReadOnly is returned when changing a FileRegistry.
+var errReadOnly = Error{
+	StatusCode: http.StatusUnprocessableEntity,
+	Code:       codeOperationNotPermitted,
+	Message:    "file registry is read only",
+}
+
+type fileSchema struct {
+	refs   []SchemaReference
+	schema avro.Schema
+	// form is the full form of the schema, by which schemas are matched.
+	form string
+}
+
+type fileSubject struct {
+	compatibility string
+	versions      []ManifestVersion
+}
+
+// FileRegistry is a read only Registry serving a snapshot written by ExportSnapshot.
+//
+// Changing the registry returns an error, except creating schemas that are
+// already registered.
+type FileRegistry struct {
+	compatibility string
+	schemas       map[int]*fileSchema
+	subjects      map[string]*fileSubject
+	snap          *snapshot
+}
+
+// NewFileRegistry returns a registry serving the snapshot in dir.
+func NewFileRegistry(dir string) (*FileRegistry, error) {
+	snap, err := readSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &FileRegistry{
+		compatibility: snap.manifest.Compatibility,
+		schemas:       map[int]*fileSchema{},
+		subjects:      map[string]*fileSubject{},
+		snap:          snap,
+	}
+	if r.compatibility == "" {
+		r.compatibility = BackwardCL
+	}
+	for _, sub := range snap.manifest.Subjects {
+		versions := slices.Clone(sub.Versions)
+		slices.SortFunc(versions, func(a, b ManifestVersion) int { return a.Version - b.Version })
+		r.subjects[sub.Subject] = &fileSubject{compatibility: sub.Compatibility, versions: versions}
+	}
+
+	for id, s := range snap.schemas {
+		schema, err := r.parse(s.schema, s.refs)
+		if err != nil {
+			return nil, fmt.Errorf("parsing schema %d: %w", id, err)
+		}
+		r.schemas[id] = &fileSchema{refs: s.refs, schema: schema, form: schemaForm(schema)}
+	}
+	return r, nil
+}
+
+// parse parses the schema in a cache isolated from other schemas, after the
+// schemas it references.
+func (r *FileRegistry) parse(schema string, refs []SchemaReference) (avro.Schema, error) {
+	cache := &avro.SchemaCache{}
+	seen := map[SchemaReference]bool{}
+
+	var resolve func(refs []SchemaReference) error
+	resolve = func(refs []SchemaReference) error {
+		for _, ref := range refs {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			v, err := r.version(ref.Subject, ref.Version)
+			if err != nil {
+				return fmt.Errorf("resolving reference %q: %w", ref.Name, err)
+			}
+			s := r.snap.schemas[v.ID]
+			if err = resolve(s.refs); err != nil {
+				return err
+			}
+			if _, err = avro.ParseWithCache(s.schema, "", cache); err != nil {
+				return fmt.Errorf("parsing reference %q: %w", ref.Name, err)
+			}
+		}
+		return nil
+	}
+	if err := resolve(refs); err != nil {
+		return nil, err
+	}
+	return avro.ParseWithCache(schema, "", cache)
+}
+
+func notFound(code int, format string, args ...any) error {
+	return Error{StatusCode: http.StatusNotFound, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// version returns the version of the subject, or its latest version if version is -1.
+func (r *FileRegistry) version(subject string, version int) (ManifestVersion, error) {
+	sub, ok := r.subjects[subject]
+	if !ok || len(sub.versions) == 0 {
+		return ManifestVersion{}, notFound(codeSubjectNotFound, "Subject '%s' not found.", subject)
+	}
+
+	if version == -1 {
+		return sub.versions[len(sub.versions)-1], nil
+	}
+	if version < 1 {
+		return ManifestVersion{}, Error{
+			StatusCode: http.StatusUnprocessableEntity,
+			Code:       codeInvalidVersion,
+			Message:    fmt.Sprintf("The specified version '%d' is not a valid version id.", version),
+		}
+	}
+	for _, v := range sub.versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return ManifestVersion{}, notFound(codeVersionNotFound, "Version %d not found.", version)
+}
+
+func (r *FileRegistry) schemaInfo(subject string, version int) (SchemaInfo, error) {
+	v, err := r.version(subject, version)
+	if err != nil {
+		return SchemaInfo{}, err
+	}
+	return SchemaInfo{Schema: r.schemas[v.ID].schema, ID: v.ID, Version: v.Version}, nil
+}
+
+// GetSchema returns the schema with the given id.
+func (r *FileRegistry) GetSchema(_ context.Context, id int) (avro.Schema, error) {
+	s, ok := r.schemas[id]
+	if !ok {
+		return nil, notFound(codeSchemaNotFound, "Schema %d not found", id)
+	}
+	return s.schema, nil
+}
+
+// ResolveSchema returns the schema with the given id. Schemas are only
+// identified by numeric ids.
+func (r *FileRegistry) ResolveSchema(ctx context.Context, id SchemaID) (avro.Schema, error) {
+	if id.IsGUID() {
+		return nil, notFound(codeSchemaNotFound, "Schema %s not found", id)
+	}
+	return r.GetSchema(ctx, int(id.ID))
+}
+
+// DeleteSubject returns an error, as the registry is read only.
+func (r *FileRegistry) DeleteSubject(context.Context, string) ([]int, error) {
+	return nil, errReadOnly
+}
+
+// GetSubjects gets the registry subjects.
+func (r *FileRegistry) GetSubjects(context.Context) ([]string, error) {
+	subjects := make([]string, 0, len(r.subjects))
+	for name, sub := range r.subjects {
+		if len(sub.versions) > 0 {
+			subjects = append(subjects, name)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+// GetVersions gets the schema versions for a subject.
+func (r *FileRegistry) GetVersions(_ context.Context, subject string) ([]int, error) {
+	sub, ok := r.subjects[subject]
+	if !ok || len(sub.versions) == 0 {
+		return nil, notFound(codeSubjectNotFound, "Subject '%s' not found.", subject)
+	}
+
+	versions := make([]int, 0, len(sub.versions))
+	for _, v := range sub.versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// GetSchemaByVersion gets the schema by version.
+func (r *FileRegistry) GetSchemaByVersion(_ context.Context, subject string, version int) (avro.Schema, error) {
+	info, err := r.schemaInfo(subject, version)
+	return info.Schema, err
+}
+
+// GetLatestSchema gets the latest schema for a subject.
+func (r *FileRegistry) GetLatestSchema(_ context.Context, subject string) (avro.Schema, error) {
+	info, err := r.schemaInfo(subject, -1)
+	return info.Schema, err
+}
+
+// GetSchemaInfo gets the schema and schema metadata for a subject and version.
+func (r *FileRegistry) GetSchemaInfo(_ context.Context, subject string, version int) (SchemaInfo, error) {
+	return r.schemaInfo(subject, version)
+}
+
+// GetLatestSchemaInfo gets the latest schema and schema metadata for a subject.
+func (r *FileRegistry) GetLatestSchemaInfo(_ context.Context, subject string) (SchemaInfo, error) {
+	return r.schemaInfo(subject, -1)
+}
+
+// CreateSchema returns the id of the schema if it is already registered under
+// the subject, otherwise an error, as the registry is read only.
+func (r *FileRegistry) CreateSchema(
+	ctx context.Context,
+	subject, schema string,
+	references ...SchemaReference,
+) (int, avro.Schema, error) {
+	id, sch, err := r.IsRegisteredWithRefs(ctx, subject, schema, references...)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, nil, errReadOnly
+		}
+		return 0, nil, err
+	}
+	return id, sch, nil
+}
+
+// IsRegistered determines if the schema is registered.
+func (r *FileRegistry) IsRegistered(ctx context.Context, subject, schema string) (int, avro.Schema, error) {
+	return r.IsRegisteredWithRefs(ctx, subject, schema)
+}
+
+// IsRegisteredWithRefs determines if the schema is registered, with optional referenced schemas.
+func (r *FileRegistry) IsRegisteredWithRefs(
+	_ context.Context,
+	subject, schema string,
+	references ...SchemaReference,
+) (int, avro.Schema, error) {
+	sub, ok := r.subjects[subject]
+	if !ok || len(sub.versions) == 0 {
+		return 0, nil, notFound(codeSubjectNotFound, "Subject '%s' not found.", subject)
+	}
+	sch, err := r.parse(schema, references)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	form := schemaForm(sch)
+	for _, v := range sub.versions {
+		s := r.schemas[v.ID]
+		if s.form == form && slices.Equal(s.refs, references) {
+			return v.ID, s.schema, nil
+		}
+	}
+	return 0, nil, notFound(codeSchemaNotFound, "Schema not found")
+}
+
+// schemaForm returns the full form of the schema. Unlike the canonical form, it
+// keeps the defaults, docs and properties of the schema, so schemas differing
+// only in those are not matched.
+func schemaForm(schema avro.Schema) string {
+	b, _ := jsoniter.Marshal(schema)
+	return string(b)
+}
+
+// IsCompatible determines if the schema is compatible with all schemas in the subject.
+func (r *FileRegistry) IsCompatible(ctx context.Context, subject, schema string) (bool, error) {
+	return r.IsCompatibleWithRefs(ctx, subject, schema)
+}
+
+// IsCompatibleWithRefs determines if the schema is compatible with all schemas in the subject,
+// with optional referenced schemas.
+func (r *FileRegistry) IsCompatibleWithRefs(
+	ctx context.Context,
+	subject, schema string,
+	references ...SchemaReference,
+) (bool, error) {
+	res, err := r.CheckCompatibility(ctx, subject, 0, schema, references...)
+	return res.IsCompatible, err
+}
+
+// CheckCompatibility checks if the schema is compatible with a version of the
+// subject, returning the messages explaining any incompatibility. A version of
+// zero checks against all versions, following the compatibility level of the
+// subject, and a version of -1 checks against the latest version.
+func (r *FileRegistry) CheckCompatibility(
+	_ context.Context,
+	subject string,
+	version int,
+	schema string,
+	references ...SchemaReference,
+) (CompatibilityResult, error) {
+	sch, err := r.parse(schema, references)
+	if err != nil {
+		return CompatibilityResult{}, err
+	}
+
+	level := r.compatibility
+	var versions []ManifestVersion
+	if sub, ok := r.subjects[subject]; ok {
+		versions = sub.versions
+		if sub.compatibility != "" {
+			level = sub.compatibility
+		}
+	}
+	if version != 0 {
+		v, err := r.version(subject, version)
+		if err != nil {
+			return CompatibilityResult{}, err
+		}
+		versions = []ManifestVersion{v}
+	}
+
+	switch level {
+	case NoneCL:
+		versions = nil
+	case BackwardCL, ForwardCL, FullCL:
+		if len(versions) > 0 {
+			versions = versions[len(versions)-1:]
+		}
+	}
+
+	compat := avro.NewSchemaCompatibility()
+	for _, v := range versions {
+		existing := r.schemas[v.ID].schema
+
+		var err error
+		switch level {
+		case BackwardCL, BackwardTransitiveCL:
+			err = compat.Compatible(sch, existing)
+		case ForwardCL, ForwardTransitiveCL:
+			err = compat.Compatible(existing, sch)
+		default:
+			if err = compat.Compatible(sch, existing); err == nil {
+				err = compat.Compatible(existing, sch)
+			}
+		}
+		if err != nil {
+			return CompatibilityResult{
+				Messages: []string{fmt.Sprintf("incompatible with version %d: %v", v.Version, err)},
+			}, nil
+		}
+	}
+	return CompatibilityResult{IsCompatible: true}, nil
+}
+
+// GetSubjectVersionsByID gets the subjects and versions of the schema with the given id.
+func (r *FileRegistry) GetSubjectVersionsByID(ctx context.Context, id int) ([]SubjectVersion, error) {
+	if _, ok := r.schemas[id]; !ok {
+		return nil, notFound(codeSchemaNotFound, "Schema %d not found", id)
+	}
+
+	subjects, _ := r.GetSubjects(ctx)
+	versions := []SubjectVersion{}
+	for _, subject := range subjects {
+		for _, v := range r.subjects[subject].versions {
+			if v.ID == id {
+				versions = append(versions, SubjectVersion{Subject: subject, Version: v.Version})
+			}
+		}
+	}
+	return versions, nil
+}
+
+// GetSubjectsByID gets the subjects of the schema with the given id.
+func (r *FileRegistry) GetSubjectsByID(ctx context.Context, id int) ([]string, error) {
+	versions, err := r.GetSubjectVersionsByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := []string{}
+	for _, v := range versions {
+		if !slices.Contains(subjects, v.Subject) {
+			subjects = append(subjects, v.Subject)
+		}
+	}
+	return subjects, nil
+}
+
+// GetSubjectsIncludingDeleted gets the registry subjects. Snapshots have no
+// deleted subjects.
+func (r *FileRegistry) GetSubjectsIncludingDeleted(ctx context.Context) ([]string, error) {
+	return r.GetSubjects(ctx)
+}
+
+// GetVersionsIncludingDeleted gets the schema versions for a subject.
+// Snapshots have no deleted versions.
+func (r *FileRegistry) GetVersionsIncludingDeleted(ctx context.Context, subject string) ([]int, error) {
+	return r.GetVersions(ctx, subject)
+}
+
+// DeleteSubjectPermanently returns an error, as the registry is read only.
+func (r *FileRegistry) DeleteSubjectPermanently(context.Context, string) ([]int, error) {
+	return nil, errReadOnly
+}
+
+// DeleteSubjectVersion returns an error, as the registry is read only.
+func (r *FileRegistry) DeleteSubjectVersion(context.Context, string, int) (int, error) {
+	return 0, errReadOnly
+}
+
+// DeleteSubjectVersionPermanently returns an error, as the registry is read only.
+func (r *FileRegistry) DeleteSubjectVersionPermanently(context.Context, string, int) (int, error) {
+	return 0, errReadOnly
+}
+
+// GetContexts gets the schema contexts of the registry.
+func (r *FileRegistry) GetContexts(ctx context.Context) ([]string, error) {
+	subjects, _ := r.GetSubjects(ctx)
+	contexts := []string{DefaultContext}
+	for _, subject := range subjects {
+		if c, _ := SplitContextSubject(subject); !slices.Contains(contexts, c) {
+			contexts = append(contexts, c)
+		}
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// SetGlobalMode returns an error, as the registry is read only.
+func (r *FileRegistry) SetGlobalMode(context.Context, string, bool) error {
+	return errReadOnly
+}
+
+// SetMode returns an error, as the registry is read only.
+func (r *FileRegistry) SetMode(context.Context, string, string, bool) error {
+	return errReadOnly
+}
+
+// GetGlobalMode returns the read only mode.
+func (r *FileRegistry) GetGlobalMode(context.Context) (string, error) {
+	return ReadOnlyMode, nil
+}
+
+// GetMode returns the read only mode.
+func (r *FileRegistry) GetMode(context.Context, string) (string, error) {
+	return ReadOnlyMode, nil
+}
+
+// SetGlobalConfig returns an error, as the registry is read only.
+func (r *FileRegistry) SetGlobalConfig(context.Context, Config) error {
+	return errReadOnly
+}
+
+// SetConfig returns an error, as the registry is read only.
+func (r *FileRegistry) SetConfig(context.Context, string, Config) error {
+	return errReadOnly
+}
+
+// GetGlobalConfig gets the global config of the registry.
+func (r *FileRegistry) GetGlobalConfig(context.Context) (Config, error) {
+	return Config{Compatibility: r.compatibility}, nil
+}
+
+// GetConfig gets the config of a subject.
+func (r *FileRegistry) GetConfig(_ context.Context, subject string) (Config, error) {
+	sub, ok := r.subjects[subject]
+	if !ok || sub.compatibility == "" {
+		return Config{}, notFound(codeConfigNotFound,
+			"Subject '%s' does not have subject-level compatibility configured", subject)
+	}
+	return Config{Compatibility: sub.compatibility}, nil
+}
@@ -0,0 +1,234 @@
+package registry_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileRegistry(t *testing.T) *registry.FileRegistry {
+	t.Helper()
+
+	reg, err := registry.NewFileRegistry(newSnapshot(t))
+	require.NoError(t, err)
+	return reg
+}
+
+func TestNewFileRegistry(t *testing.T) {
+	reg := newFileRegistry(t)
+
+	assert.Implements(t, (*registry.Registry)(nil), reg)
+	assert.Implements(t, (*registry.SchemaResolver)(nil), reg)
+}
+
+func TestNewFileRegistry_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		files    map[string]string
+	}{
+		{
+			name: "missing manifest",
+		},
+		{
+			name:     "invalid manifest",
+			manifest: `{`,
+		},
+		{
+			name:     "missing schema file",
+			manifest: `{"schemas":[{"id":1,"file":"1.avsc"}]}`,
+		},
+		{
+			name:     "unknown schema id",
+			manifest: `{"subjects":[{"subject":"foo","versions":[{"version":1,"id":1}]}]}`,
+		},
+		{
+			name:     "invalid schema",
+			manifest: `{"schemas":[{"id":1,"file":"1.avsc"}]}`,
+			files:    map[string]string{"1.avsc": `{"type":"unknown"}`},
+		},
+		{
+			name:     "missing reference",
+			manifest: `{"schemas":[{"id":1,"file":"1.avsc","references":[{"name":"Item","subject":"item","version":1}]}]}`,
+			files:    map[string]string{"1.avsc": snapshotOrder},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if test.manifest != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(test.manifest), 0o600))
+			}
+			for name, content := range test.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			}
+
+			_, err := registry.NewFileRegistry(dir)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewFileRegistry_SchemaFileOutsideSnapshot(t *testing.T) {
+	parent := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "1.avsc"), []byte(`"string"`), 0o600))
+	dir := filepath.Join(parent, "snapshot")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+
+	for _, file := range []string{"../1.avsc", filepath.ToSlash(filepath.Join(parent, "1.avsc"))} {
+		manifest := `{"schemas":[{"id":1,"file":"` + file + `"}]}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(manifest), 0o600))
+
+		_, err := registry.NewFileRegistry(dir)
+
+		assert.EqualError(t, err, `schema 1 has file "`+file+`" outside the snapshot`)
+	}
+}
+
+func TestFileRegistry_Lookups(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	subjects, err := reg.GetSubjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo", "item", "order"}, subjects)
+
+	versions, err := reg.GetVersions(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+
+	schema, err := reg.GetSchema(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "Order", schema.(avro.NamedSchema).FullName())
+
+	schema, err = reg.GetSchemaByVersion(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, avro.MustParse(cacheSchemaV1).Fingerprint(), schema.Fingerprint())
+
+	info, err := reg.GetLatestSchemaInfo(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.ID)
+	assert.Equal(t, 2, info.Version)
+
+	svs, err := reg.GetSubjectVersionsByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []registry.SubjectVersion{{Subject: "bar", Version: 1}, {Subject: "foo", Version: 1}}, svs)
+
+	cfg, err := reg.GetConfig(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, registry.NoneCL, cfg.Compatibility)
+	_, err = reg.GetConfig(ctx, "bar")
+	assert.Error(t, err)
+
+	mode, err := reg.GetGlobalMode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.ReadOnlyMode, mode)
+}
+
+func TestFileRegistry_NotFound(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	_, err := reg.GetSchema(ctx, 42)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+
+	_, err = reg.GetLatestSchema(ctx, "baz")
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40401, regErr.Code)
+
+	_, err = reg.GetSchemaInfo(ctx, "foo", 3)
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40402, regErr.Code)
+}
+
+func TestFileRegistry_IsRegistered(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	id, _, err := reg.IsRegistered(ctx, "foo", `"string"`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, id)
+
+	ref := registry.SchemaReference{Name: "Item", Subject: "item", Version: 1}
+	id, _, err = reg.CreateSchema(ctx, "order", snapshotOrder, ref)
+	require.NoError(t, err)
+	assert.Equal(t, 4, id)
+
+	_, _, err = reg.IsRegistered(ctx, "foo", `"int"`)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+
+	_, _, err = reg.CreateSchema(ctx, "foo", `"int"`)
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 42205, regErr.Code)
+}
+
+func TestFileRegistry_IsRegisteredMatchesFullSchema(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	id, _, err := reg.IsRegistered(ctx, "item", `{"type": "record", "name": "Item", "fields": [{"name": "id", "type": "int"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, id)
+
+	_, _, err = reg.IsRegistered(ctx, "item", `{"type":"record","name":"Item","fields":[{"name":"id","type":"int","default":1}]}`)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+}
+
+func TestFileRegistry_IsReadOnly(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	_, err := reg.DeleteSubject(ctx, "foo")
+	assert.Error(t, err)
+	_, err = reg.DeleteSubjectVersion(ctx, "foo", 1)
+	assert.Error(t, err)
+	assert.Error(t, reg.SetGlobalMode(ctx, registry.ReadWriteMode, false))
+	assert.Error(t, reg.SetConfig(ctx, "foo", registry.Config{Compatibility: registry.FullCL}))
+
+	versions, err := reg.GetVersions(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+}
+
+func TestFileRegistry_CheckCompatibility(t *testing.T) {
+	reg := newFileRegistry(t)
+	ctx := context.Background()
+
+	ok, err := reg.IsCompatible(ctx, "bar", cacheSchemaV2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	res, err := reg.CheckCompatibility(ctx, "bar", -1, `"string"`)
+	require.NoError(t, err)
+	assert.False(t, res.IsCompatible)
+	assert.Len(t, res.Messages, 1)
+
+	ok, err = reg.IsCompatible(ctx, "foo", `"int"`)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestFileRegistry_Decoder(t *testing.T) {
+	reg := newFileRegistry(t)
+
+	var got string
+	err := registry.NewDecoder(nil, registry.WithSchemaResolver(reg)).
+		Decode(context.Background(), []byte{0x0, 0x0, 0x0, 0x0, 0x2, 0x6, 0x66, 0x6f, 0x6f}, &got)
+
+	require.NoError(t, err)
+	assert.Equal(t, "foo", got)
+}
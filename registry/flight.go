@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightGroup coalesces concurrent calls with the same key into a single call.
+type flightGroup struct {
+	// timeout bounds the calls, which are not canceled with the contexts of
+	// their callers.
+	timeout time.Duration
+
+	mu    sync.Mutex
+	calls map[any]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// do calls fn, unless a call with the same key is in flight, in which case its
+// result is waited for and returned.
+//
+// The call is made with a context detached from the cancellation of ctx and
+// bounded by the timeout of the group, as its result is shared by all callers. Each caller
+// stops waiting when its own context is done.
+func (g *flightGroup) do(ctx context.Context, key any, fn func(ctx context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = map[any]*flightCall{}
+		}
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.call(ctx, key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+		return call.val, call.err
+	}
+}
+
+func (g *flightGroup) call(ctx context.Context, key any, call *flightCall, fn func(ctx context.Context) (any, error)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), g.timeout)
+	defer cancel()
+
+	call.val, call.err = fn(ctx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,987 @@
+// Package registrytest implements an in-memory schema registry server for tests.
+//
+// The server implements the REST endpoints used by registry.Client, checking the
+// compatibility of schemas with avro.SchemaCompatibility.
+package registrytest
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Registry error codes.
+const (
+	codeSubjectNotFound       = 40401
+	codeVersionNotFound       = 40402
+	codeSchemaNotFound        = 40403
+	codeSubjectNotSoftDeleted = 40405
+	codeVersionNotSoftDeleted = 40407
+	codeConfigNotFound        = 40408
+	codeModeNotFound          = 40409
+	codeIncompatibleSchema    = 409
+	codeInvalidSchema         = 42201
+	codeInvalidVersion        = 42202
+	codeInvalidCompatLevel    = 42203
+	codeInvalidMode           = 42204
+	codeOperationNotPermitted = 42205
+	codeInvalidSchemaID       = 42207
+	codeInternalServerError   = 50001
+)
+
+type storedSchema struct {
+	id     int
+	schema string
+	refs   []registry.SchemaReference
+	parsed avro.Schema
+	// form is the full form of the schema, by which schemas are matched.
+	form string
+}
+
+type subjectVersion struct {
+	version int
+	id      int
+	deleted bool
+}
+
+type subject struct {
+	versions []subjectVersion
+	config   configPayload
+	mode     string
+}
+
+// live returns the versions of the subject that are not deleted.
+func (s *subject) live() []subjectVersion {
+	if s == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(s.versions), func(v subjectVersion) bool {
+		return v.deleted
+	})
+}
+
+// Server is an in-memory schema registry server.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	schemas  map[int]*storedSchema
+	nextID   int
+	subjects map[string]*subject
+	config   configPayload
+	mode     string
+	requests []string
+}
+
+// NewServer starts and returns a new in-memory schema registry server.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		schemas:  map[int]*storedSchema{},
+		nextID:   1,
+		subjects: map[string]*subject{},
+		config:   configPayload{Compatibility: registry.BackwardCL},
+		mode:     registry.ReadWriteMode,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /schemas/ids/{id}", s.handleGetSchema)
+	mux.HandleFunc("GET /schemas/ids/{id}/versions", s.handleGetSchemaVersions)
+	mux.HandleFunc("GET /schemas/ids/{id}/subjects", s.handleGetSchemaSubjects)
+	mux.HandleFunc("GET /subjects", s.handleGetSubjects)
+	mux.HandleFunc("POST /subjects/{subject}", s.handleLookup)
+	mux.HandleFunc("DELETE /subjects/{subject}", s.handleDeleteSubject)
+	mux.HandleFunc("GET /subjects/{subject}/versions", s.handleGetVersions)
+	mux.HandleFunc("POST /subjects/{subject}/versions", s.handleRegister)
+	mux.HandleFunc("GET /subjects/{subject}/versions/{version}", s.handleGetVersion)
+	mux.HandleFunc("DELETE /subjects/{subject}/versions/{version}", s.handleDeleteVersion)
+	mux.HandleFunc("GET /contexts", s.handleGetContexts)
+	mux.HandleFunc("POST /compatibility/subjects/{subject}/versions", s.handleCompatibility)
+	mux.HandleFunc("POST /compatibility/subjects/{subject}/versions/{version}", s.handleCompatibility)
+	mux.HandleFunc("GET /config", s.handleGetConfig)
+	mux.HandleFunc("PUT /config", s.handleSetConfig)
+	mux.HandleFunc("GET /config/{subject}", s.handleGetConfig)
+	mux.HandleFunc("PUT /config/{subject}", s.handleSetConfig)
+	mux.HandleFunc("GET /mode", s.handleGetMode)
+	mux.HandleFunc("PUT /mode", s.handleSetMode)
+	mux.HandleFunc("GET /mode/{subject}", s.handleGetMode)
+	mux.HandleFunc("PUT /mode/{subject}", s.handleSetMode)
+
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", contentType)
+		mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// URL returns the base URL of the server.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns a registry client of the server.
+func (s *Server) Client(opts ...registry.ClientFunc) *registry.Client {
+	// The URL of the server is always valid.
+	client, _ := registry.NewClient(s.srv.URL, opts...)
+	return client
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Register registers the schema under the subject, returning its id. The
+// schema must be compatible with the schemas already registered under the subject.
+func (s *Server) Register(subject, schema string, refs ...registry.SchemaReference) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.register(subject, schema, refs)
+}
+
+// SetCompatibility sets the compatibility level of the subject, or the global
+// compatibility level if the subject is empty.
+func (s *Server) SetCompatibility(subject, level string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subject == "" {
+		s.config.Compatibility = level
+		return
+	}
+	s.subject(subject).config.Compatibility = level
+}
+
+// Subjects returns the sorted subjects with schemas that are not deleted.
+func (s *Server) Subjects() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.subjectNames(false)
+}
+
+// Versions returns the versions of the subject that are not deleted.
+func (s *Server) Versions(subject string) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var versions []int
+	for _, v := range s.subjects[subject].live() {
+		versions = append(versions, v.version)
+	}
+	return versions
+}
+
+// Schema returns the schema with the given id.
+func (s *Server) Schema(id int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.schemas[id]
+	if !ok {
+		return "", false
+	}
+	return stored.schema, true
+}
+
+// Requests returns the method and path of the requests made to the server, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// httpError is an error returned to clients.
+type httpError struct {
+	status int
+	code   int
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func newError(status, code int, format string, args ...any) error {
+	return &httpError{status: status, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) subject(name string) *subject {
+	sub, ok := s.subjects[name]
+	if !ok {
+		sub = &subject{}
+		s.subjects[name] = sub
+	}
+	return sub
+}
+
+// subjectNames returns the sorted subjects with schemas that are not deleted,
+// or with any schemas if deleted is true.
+func (s *Server) subjectNames(deleted bool) []string {
+	var subjects []string
+	for name, sub := range s.subjects {
+		if len(sub.live()) > 0 || (deleted && len(sub.versions) > 0) {
+			subjects = append(subjects, name)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+// parse parses the schema in an isolated cache, after the schemas it references.
+func (s *Server) parse(schema string, refs []registry.SchemaReference) (avro.Schema, error) {
+	cache := &avro.SchemaCache{}
+	seen := map[registry.SchemaReference]bool{}
+
+	var resolve func(refs []registry.SchemaReference) error
+	resolve = func(refs []registry.SchemaReference) error {
+		for _, ref := range refs {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			v, err := s.version(ref.Subject, strconv.Itoa(ref.Version))
+			if err != nil {
+				return newError(http.StatusUnprocessableEntity, codeInvalidSchema, "invalid reference %q: %v", ref.Name, err)
+			}
+			stored := s.schemas[v.id]
+			if err = resolve(stored.refs); err != nil {
+				return err
+			}
+			if _, err = avro.ParseWithCache(stored.schema, "", cache); err != nil {
+				return newError(http.StatusUnprocessableEntity, codeInvalidSchema, "invalid reference %q: %v", ref.Name, err)
+			}
+		}
+		return nil
+	}
+	if err := resolve(refs); err != nil {
+		return nil, err
+	}
+
+	parsed, err := avro.ParseWithCache(schema, "", cache)
+	if err != nil {
+		return nil, newError(http.StatusUnprocessableEntity, codeInvalidSchema, "invalid schema: %v", err)
+	}
+	return parsed, nil
+}
+
+// schemaForm returns the full form of the schema. Unlike the canonical form, it
+// keeps the defaults, docs and properties of the schema, so schemas differing
+// only in those are not matched.
+func schemaForm(parsed avro.Schema) string {
+	b, _ := jsoniter.Marshal(parsed)
+	return string(b)
+}
+
+// find returns the stored schema identical to the given schema.
+func (s *Server) find(parsed avro.Schema, refs []registry.SchemaReference) *storedSchema {
+	form := schemaForm(parsed)
+	for _, id := range slices.Sorted(maps.Keys(s.schemas)) {
+		stored := s.schemas[id]
+		if stored.form == form && slices.Equal(stored.refs, refs) {
+			return stored
+		}
+	}
+	return nil
+}
+
+// version returns the version of the subject, which may be "latest" or -1 for the latest version.
+func (s *Server) version(subject, version string) (subjectVersion, error) {
+	versions := s.subjects[subject].live()
+	if len(versions) == 0 {
+		return subjectVersion{}, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject)
+	}
+
+	if version == "latest" || version == "-1" {
+		return versions[len(versions)-1], nil
+	}
+	n, err := strconv.Atoi(version)
+	if err != nil || n < 1 {
+		return subjectVersion{}, newError(http.StatusUnprocessableEntity, codeInvalidVersion, "The specified version '%s' is not a valid version id.", version)
+	}
+	for _, v := range versions {
+		if v.version == n {
+			return v, nil
+		}
+	}
+	return subjectVersion{}, newError(http.StatusNotFound, codeVersionNotFound, "Version %d not found.", n)
+}
+
+// lookup returns the version of the subject with the given schema.
+func (s *Server) lookup(subject string, parsed avro.Schema, refs []registry.SchemaReference) (subjectVersion, bool) {
+	stored := s.find(parsed, refs)
+	if stored == nil {
+		return subjectVersion{}, false
+	}
+	for _, v := range s.subjects[subject].live() {
+		if v.id == stored.id {
+			return v, true
+		}
+	}
+	return subjectVersion{}, false
+}
+
+func (s *Server) register(subject, schema string, refs []registry.SchemaReference) (int, error) {
+	parsed, err := s.parse(schema, refs)
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := s.lookup(subject, parsed, refs); ok {
+		return v.id, nil
+	}
+
+	if err = s.checkCompatibility(subject, parsed, s.subjects[subject].live()); err != nil {
+		return 0, err
+	}
+
+	stored := s.find(parsed, refs)
+	if stored == nil {
+		stored = &storedSchema{id: s.nextID, schema: schema, refs: refs, parsed: parsed, form: schemaForm(parsed)}
+		s.schemas[stored.id] = stored
+		s.nextID++
+	}
+
+	sub := s.subject(subject)
+	version := 1
+	if n := len(sub.versions); n > 0 {
+		version = sub.versions[n-1].version + 1
+	}
+	sub.versions = append(sub.versions, subjectVersion{version: version, id: stored.id})
+	return stored.id, nil
+}
+
+// importSchema registers the schema under the subject with the given id and
+// version, without checking its compatibility. The subject must be in import mode.
+func (s *Server) importSchema(subject, schema string, refs []registry.SchemaReference, id, version int) error {
+	if mode := s.subjectMode(subject); mode != registry.ImportMode {
+		return newError(http.StatusUnprocessableEntity, codeOperationNotPermitted,
+			"Subject %s is not in import mode", subject)
+	}
+	parsed, err := s.parse(schema, refs)
+	if err != nil {
+		return err
+	}
+
+	stored, ok := s.schemas[id]
+	switch {
+	case !ok:
+		stored = &storedSchema{id: id, schema: schema, refs: refs, parsed: parsed, form: schemaForm(parsed)}
+		s.schemas[id] = stored
+		s.nextID = max(s.nextID, id+1)
+	case stored.form != schemaForm(parsed) || !slices.Equal(stored.refs, refs):
+		return newError(http.StatusUnprocessableEntity, codeInvalidSchemaID,
+			"Overwrite new schema with id %d is not permitted.", id)
+	}
+
+	sub := s.subject(subject)
+	if version == 0 {
+		version = 1
+		if n := len(sub.versions); n > 0 {
+			version = sub.versions[n-1].version + 1
+		}
+	}
+	i, found := slices.BinarySearchFunc(sub.versions, version, func(v subjectVersion, version int) int {
+		return v.version - version
+	})
+	if found {
+		if sub.versions[i].id != id {
+			return newError(http.StatusUnprocessableEntity, codeInvalidSchemaID,
+				"Version %d of subject %s already exists with a different id.", version, subject)
+		}
+		return nil
+	}
+	sub.versions = slices.Insert(sub.versions, i, subjectVersion{version: version, id: id})
+	return nil
+}
+
+func (s *Server) compatibility(subject string) string {
+	if sub, ok := s.subjects[subject]; ok && sub.config.Compatibility != "" {
+		return sub.config.Compatibility
+	}
+	return s.config.Compatibility
+}
+
+func (s *Server) subjectMode(subject string) string {
+	if sub, ok := s.subjects[subject]; ok && sub.mode != "" {
+		return sub.mode
+	}
+	return s.mode
+}
+
+// checkWritable checks the mode of the subject allows changing it.
+func (s *Server) checkWritable(subject string) error {
+	switch s.subjectMode(subject) {
+	case registry.ReadOnlyMode, registry.ReadOnlyOverrideMode:
+		return newError(http.StatusUnprocessableEntity, codeOperationNotPermitted,
+			"Subject %s is in read-only mode", subject)
+	}
+	return nil
+}
+
+// checkCompatibility checks the schema against the versions, following the
+// compatibility level of the subject.
+func (s *Server) checkCompatibility(subject string, parsed avro.Schema, versions []subjectVersion) error {
+	level := s.compatibility(subject)
+	switch level {
+	case registry.NoneCL:
+		return nil
+	case registry.BackwardCL, registry.ForwardCL, registry.FullCL:
+		if len(versions) > 0 {
+			versions = versions[len(versions)-1:]
+		}
+	}
+
+	compat := avro.NewSchemaCompatibility()
+	for _, v := range versions {
+		existing := s.schemas[v.id].parsed
+
+		var err error
+		switch level {
+		case registry.BackwardCL, registry.BackwardTransitiveCL:
+			err = compat.Compatible(parsed, existing)
+		case registry.ForwardCL, registry.ForwardTransitiveCL:
+			err = compat.Compatible(existing, parsed)
+		default:
+			if err = compat.Compatible(parsed, existing); err == nil {
+				err = compat.Compatible(existing, parsed)
+			}
+		}
+		if err != nil {
+			return newError(http.StatusConflict, codeIncompatibleSchema,
+				"Schema being registered is incompatible with version %d: %v", v.version, err)
+		}
+	}
+	return nil
+}
+
+type schemaRequest struct {
+	Schema     string                     `json:"schema"`
+	References []registry.SchemaReference `json:"references,omitempty"`
+	ID         int                        `json:"id,omitempty"`
+	Version    int                        `json:"version,omitempty"`
+}
+
+type schemaResponse struct {
+	Subject    string                     `json:"subject,omitempty"`
+	ID         int                        `json:"id,omitempty"`
+	Version    int                        `json:"version,omitempty"`
+	Schema     string                     `json:"schema"`
+	References []registry.SchemaReference `json:"references,omitempty"`
+}
+
+type metadataPayload struct {
+	Properties map[string]string `json:"properties"`
+}
+
+type configPayload struct {
+	Compatibility      string           `json:"compatibility,omitempty"`
+	CompatibilityLevel string           `json:"compatibilityLevel,omitempty"`
+	Normalize          *bool            `json:"normalize,omitempty"`
+	Alias              string           `json:"alias,omitempty"`
+	CompatibilityGroup string           `json:"compatibilityGroup,omitempty"`
+	DefaultMetadata    *metadataPayload `json:"defaultMetadata,omitempty"`
+	OverrideMetadata   *metadataPayload `json:"overrideMetadata,omitempty"`
+}
+
+// merge sets the fields of the config that are set in other.
+func (c *configPayload) merge(other configPayload) {
+	if other.Compatibility != "" {
+		c.Compatibility = other.Compatibility
+	}
+	if other.Normalize != nil {
+		c.Normalize = other.Normalize
+	}
+	if other.Alias != "" {
+		c.Alias = other.Alias
+	}
+	if other.CompatibilityGroup != "" {
+		c.CompatibilityGroup = other.CompatibilityGroup
+	}
+	if other.DefaultMetadata != nil {
+		c.DefaultMetadata = other.DefaultMetadata
+	}
+	if other.OverrideMetadata != nil {
+		c.OverrideMetadata = other.OverrideMetadata
+	}
+}
+
+type modePayload struct {
+	Mode string `json:"mode"`
+}
+
+func (s *Server) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	stored, ok := s.schemas[id]
+	if !ok {
+		writeError(w, newError(http.StatusNotFound, codeSchemaNotFound, "Schema %s not found", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, schemaResponse{Schema: stored.schema, References: stored.refs})
+}
+
+func (s *Server) handleGetSchemaVersions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	if _, ok := s.schemas[id]; !ok {
+		writeError(w, newError(http.StatusNotFound, codeSchemaNotFound, "Schema %s not found", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, s.schemaVersions(id))
+}
+
+func (s *Server) handleGetSchemaSubjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	if _, ok := s.schemas[id]; !ok {
+		writeError(w, newError(http.StatusNotFound, codeSchemaNotFound, "Schema %s not found", r.PathValue("id")))
+		return
+	}
+
+	subjects := []string{}
+	for _, v := range s.schemaVersions(id) {
+		if !slices.Contains(subjects, v.Subject) {
+			subjects = append(subjects, v.Subject)
+		}
+	}
+	writeJSON(w, subjects)
+}
+
+// schemaVersions returns the versions that are not deleted of the schema with the given id.
+func (s *Server) schemaVersions(id int) []registry.SubjectVersion {
+	versions := []registry.SubjectVersion{}
+	for _, name := range s.subjectNames(false) {
+		for _, v := range s.subjects[name].live() {
+			if v.id == id {
+				versions = append(versions, registry.SubjectVersion{Subject: name, Version: v.version})
+			}
+		}
+	}
+	return versions
+}
+
+func (s *Server) handleGetSubjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subjects := s.subjectNames(r.URL.Query().Get("deleted") == "true")
+	if subjects == nil {
+		subjects = []string{}
+	}
+	writeJSON(w, subjects)
+}
+
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	if len(s.subjects[subject].live()) == 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject))
+		return
+	}
+	parsed, err := s.parse(req.Schema, req.References)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	v, ok := s.lookup(subject, parsed, req.References)
+	if !ok {
+		writeError(w, newError(http.StatusNotFound, codeSchemaNotFound, "Schema not found"))
+		return
+	}
+	stored := s.schemas[v.id]
+	writeJSON(w, schemaResponse{
+		Subject:    subject,
+		ID:         v.id,
+		Version:    v.version,
+		Schema:     stored.schema,
+		References: stored.refs,
+	})
+}
+
+func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	if err := s.checkWritable(subject); err != nil {
+		writeError(w, err)
+		return
+	}
+	sub := s.subjects[subject]
+	if r.URL.Query().Get("permanent") == "true" {
+		s.deleteSubjectPermanently(w, subject, sub)
+		return
+	}
+	versions := sub.live()
+	if len(versions) == 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject))
+		return
+	}
+
+	deleted := make([]int, 0, len(versions))
+	for i := range sub.versions {
+		if !sub.versions[i].deleted {
+			sub.versions[i].deleted = true
+			deleted = append(deleted, sub.versions[i].version)
+		}
+	}
+	writeJSON(w, deleted)
+}
+
+func (s *Server) deleteSubjectPermanently(w http.ResponseWriter, subject string, sub *subject) {
+	if sub == nil || len(sub.versions) == 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject))
+		return
+	}
+	if len(sub.live()) > 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotSoftDeleted,
+			"Subject '%s' was not deleted first before being permanently deleted", subject))
+		return
+	}
+
+	deleted := make([]int, 0, len(sub.versions))
+	for _, v := range sub.versions {
+		deleted = append(deleted, v.version)
+	}
+	sub.versions = nil
+	writeJSON(w, deleted)
+}
+
+func (s *Server) handleDeleteVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	if err := s.checkWritable(subject); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("permanent") != "true" {
+		v, err := s.version(subject, r.PathValue("version"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		sub := s.subjects[subject]
+		i := slices.IndexFunc(sub.versions, func(sv subjectVersion) bool { return sv.version == v.version })
+		sub.versions[i].deleted = true
+		writeJSON(w, v.version)
+		return
+	}
+
+	sub := s.subjects[subject]
+	if sub == nil || len(sub.versions) == 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject))
+		return
+	}
+	n, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || n < 1 {
+		writeError(w, newError(http.StatusUnprocessableEntity, codeInvalidVersion,
+			"The specified version '%s' is not a valid version id.", r.PathValue("version")))
+		return
+	}
+	i := slices.IndexFunc(sub.versions, func(sv subjectVersion) bool { return sv.version == n })
+	switch {
+	case i < 0:
+		writeError(w, newError(http.StatusNotFound, codeVersionNotFound, "Version %d not found.", n))
+		return
+	case !sub.versions[i].deleted:
+		writeError(w, newError(http.StatusNotFound, codeVersionNotSoftDeleted,
+			"Subject '%s' Version %d was not deleted first before being permanently deleted", subject, n))
+		return
+	}
+	sub.versions = slices.Delete(sub.versions, i, i+1)
+	writeJSON(w, n)
+}
+
+func (s *Server) handleGetContexts(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contexts := []string{registry.DefaultContext}
+	for _, name := range s.subjectNames(true) {
+		if ctx, _ := registry.SplitContextSubject(name); !slices.Contains(contexts, ctx) {
+			contexts = append(contexts, ctx)
+		}
+	}
+	sort.Strings(contexts)
+	writeJSON(w, contexts)
+}
+
+func (s *Server) handleGetVersions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	versions := s.subjects[subject].live()
+	if r.URL.Query().Get("deleted") == "true" && s.subjects[subject] != nil {
+		versions = s.subjects[subject].versions
+	}
+	if len(versions) == 0 {
+		writeError(w, newError(http.StatusNotFound, codeSubjectNotFound, "Subject '%s' not found.", subject))
+		return
+	}
+
+	resp := make([]int, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, v.version)
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	if err := s.checkWritable(subject); err != nil {
+		writeError(w, err)
+		return
+	}
+	id := req.ID
+	var err error
+	if id > 0 {
+		err = s.importSchema(subject, req.Schema, req.References, id, req.Version)
+	} else {
+		id, err = s.register(subject, req.Schema, req.References)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, struct {
+		ID int `json:"id"`
+	}{ID: id})
+}
+
+func (s *Server) handleGetVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	v, err := s.version(subject, r.PathValue("version"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	stored := s.schemas[v.id]
+	writeJSON(w, schemaResponse{
+		Subject:    subject,
+		ID:         v.id,
+		Version:    v.version,
+		Schema:     stored.schema,
+		References: stored.refs,
+	})
+}
+
+func (s *Server) handleCompatibility(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	parsed, err := s.parse(req.Schema, req.References)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	versions := s.subjects[subject].live()
+	if version := r.PathValue("version"); version != "" {
+		v, err := s.version(subject, version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		versions = []subjectVersion{v}
+	}
+
+	err = s.checkCompatibility(subject, parsed, versions)
+	if err != nil && !isIncompatible(err) {
+		writeError(w, err)
+		return
+	}
+	resp := struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages,omitempty"`
+	}{IsCompatible: err == nil}
+	if err != nil && r.URL.Query().Get("verbose") == "true" {
+		resp.Messages = []string{err.Error()}
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.config
+	if subject := r.PathValue("subject"); subject != "" {
+		sub, ok := s.subjects[subject]
+		switch {
+		case ok && sub.config != configPayload{}:
+			cfg = sub.config
+			if r.URL.Query().Get("defaultToGlobal") == "true" && cfg.Compatibility == "" {
+				cfg.Compatibility = s.config.Compatibility
+			}
+		case r.URL.Query().Get("defaultToGlobal") != "true":
+			writeError(w, newError(http.StatusNotFound, codeConfigNotFound,
+				"Subject '%s' does not have subject-level compatibility configured", subject))
+			return
+		}
+	}
+	cfg.CompatibilityLevel = cfg.Compatibility
+	writeJSON(w, cfg)
+}
+
+func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	var req configPayload
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	switch req.Compatibility {
+	case "", registry.BackwardCL, registry.BackwardTransitiveCL, registry.ForwardCL, registry.ForwardTransitiveCL,
+		registry.FullCL, registry.FullTransitiveCL, registry.NoneCL:
+	default:
+		writeError(w, newError(http.StatusUnprocessableEntity, codeInvalidCompatLevel,
+			"Invalid compatibility level %q", req.Compatibility))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req.CompatibilityLevel = ""
+	if subject := r.PathValue("subject"); subject != "" {
+		s.subject(subject).config.merge(req)
+	} else {
+		s.config.merge(req)
+	}
+	writeJSON(w, req)
+}
+
+func (s *Server) handleGetMode(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mode := s.mode
+	if subject := r.PathValue("subject"); subject != "" {
+		sub, ok := s.subjects[subject]
+		switch {
+		case ok && sub.mode != "":
+			mode = sub.mode
+		case r.URL.Query().Get("defaultToGlobal") != "true":
+			writeError(w, newError(http.StatusNotFound, codeModeNotFound,
+				"Subject '%s' does not have subject-level mode configured", subject))
+			return
+		}
+	}
+	writeJSON(w, modePayload{Mode: mode})
+}
+
+func (s *Server) handleSetMode(w http.ResponseWriter, r *http.Request) {
+	var req modePayload
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	switch req.Mode {
+	case registry.ReadWriteMode, registry.ReadOnlyMode, registry.ReadOnlyOverrideMode, registry.ImportMode:
+	default:
+		writeError(w, newError(http.StatusUnprocessableEntity, codeInvalidMode, "Invalid mode %q", req.Mode))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject := r.PathValue("subject")
+	if req.Mode == registry.ImportMode && r.URL.Query().Get("force") != "true" {
+		hasSchemas := len(s.subjectNames(true)) > 0
+		if subject != "" {
+			hasSchemas = s.subjects[subject] != nil && len(s.subjects[subject].versions) > 0
+		}
+		if hasSchemas {
+			writeError(w, newError(http.StatusUnprocessableEntity, codeOperationNotPermitted,
+				"Cannot import since found existing subjects"))
+			return
+		}
+	}
+
+	if subject != "" {
+		s.subject(subject).mode = req.Mode
+	} else {
+		s.mode = req.Mode
+	}
+	writeJSON(w, req)
+}
+
+func isIncompatible(err error) bool {
+	var httpErr *httpError
+	return errors.As(err, &httpErr) && httpErr.code == codeIncompatibleSchema
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := jsoniter.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, newError(http.StatusUnprocessableEntity, codeInvalidSchema, "invalid request: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	_ = jsoniter.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) {
+		httpErr = &httpError{status: http.StatusInternalServerError, code: codeInternalServerError, msg: err.Error()}
+	}
+
+	w.WriteHeader(httpErr.status)
+	writeJSON(w, registry.Error{Code: httpErr.code, Message: httpErr.msg})
+}
@@ -0,0 +1,492 @@
+package registrytest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	schemaV1 = `{"type":"record","name":"test","fields":[{"name":"a","type":"int"}]}`
+	schemaV2 = `{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"b","type":"string","default":""}]}`
+	schemaV3 = `{"type":"record","name":"test","fields":[{"name":"a","type":"int"},{"name":"c","type":"string"}]}`
+)
+
+func newServer(t *testing.T) (*registrytest.Server, *registry.Client) {
+	t.Helper()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, srv.Client()
+}
+
+func TestServer_Subjects(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	id1, _, err := client.CreateSchema(ctx, "foo", schemaV1)
+	require.NoError(t, err)
+	id2, _, err := client.CreateSchema(ctx, "foo", schemaV2)
+	require.NoError(t, err)
+	id3, _, err := client.CreateSchema(ctx, "bar", schemaV1)
+	require.NoError(t, err)
+	again, _, err := client.CreateSchema(ctx, "foo", schemaV2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, id1)
+	assert.Equal(t, 2, id2)
+	assert.Equal(t, id1, id3)
+	assert.Equal(t, id2, again)
+
+	subjects, err := client.GetSubjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, subjects)
+	assert.Equal(t, subjects, srv.Subjects())
+
+	versions, err := client.GetVersions(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+	assert.Equal(t, versions, srv.Versions("foo"))
+
+	info, err := client.GetSchemaInfo(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, id1, info.ID)
+	assert.Equal(t, 1, info.Version)
+
+	info, err = client.GetLatestSchemaInfo(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, id2, info.ID)
+	assert.Equal(t, 2, info.Version)
+
+	schema, err := client.GetSchema(ctx, id2)
+	require.NoError(t, err)
+	assert.Equal(t, avro.MustParse(schemaV2).Fingerprint(), schema.Fingerprint())
+
+	raw, ok := srv.Schema(id2)
+	assert.True(t, ok)
+	assert.Equal(t, schemaV2, raw)
+}
+
+func TestServer_IsRegistered(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+
+	id, _, err := client.IsRegistered(ctx, "foo", schemaV1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	_, _, err = client.IsRegistered(ctx, "foo", schemaV2)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 404, regErr.StatusCode)
+	assert.Equal(t, 40403, regErr.Code)
+
+	_, _, err = client.IsRegistered(ctx, "bar", schemaV1)
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40401, regErr.Code)
+}
+
+func TestServer_MatchesFullSchema(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+	srv.SetCompatibility("foo", registry.NoneCL)
+	schema := `{"type":"record","name":"test","fields":[{"name":"a","type":"int","default":1}]}`
+
+	id, err := srv.Register("foo", schema)
+	require.NoError(t, err)
+
+	got, _, err := client.IsRegistered(ctx, "foo", `{"type": "record", "name": "test", "fields": [{"name": "a", "type": "int", "default": 1}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	withDefault := `{"type":"record","name":"test","fields":[{"name":"a","type":"int","default":2}]}`
+	_, _, err = client.IsRegistered(ctx, "foo", withDefault)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+
+	got, _, err = client.CreateSchema(ctx, "foo", withDefault)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, got)
+}
+
+func TestServer_DeleteSubject(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+	_, err = srv.Register("foo", schemaV2)
+	require.NoError(t, err)
+
+	versions, err := client.DeleteSubject(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+
+	assert.Empty(t, srv.Subjects())
+	_, err = client.GetLatestSchema(ctx, "foo")
+	assert.Error(t, err)
+	_, err = client.DeleteSubject(ctx, "foo")
+	assert.Error(t, err)
+
+	// Versions keep counting after a delete.
+	_, err = srv.Register("foo", schemaV3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, srv.Versions("foo"))
+}
+
+func TestServer_Compatibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		schema string
+		want   bool
+	}{
+		{
+			name:   "backward compatible",
+			level:  registry.BackwardCL,
+			schema: schemaV2,
+			want:   true,
+		},
+		{
+			name:   "backward incompatible",
+			level:  registry.BackwardCL,
+			schema: schemaV3,
+			want:   false,
+		},
+		{
+			name:   "forward compatible",
+			level:  registry.ForwardCL,
+			schema: schemaV3,
+			want:   true,
+		},
+		{
+			name:   "full",
+			level:  registry.FullCL,
+			schema: schemaV3,
+			want:   false,
+		},
+		{
+			name:   "none",
+			level:  registry.NoneCL,
+			schema: `"string"`,
+			want:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, client := newServer(t)
+			ctx := context.Background()
+
+			_, err := srv.Register("foo", schemaV1)
+			require.NoError(t, err)
+			require.NoError(t, client.SetCompatibilityLevel(ctx, "foo", test.level))
+
+			got, err := client.IsCompatible(ctx, "foo", test.schema)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+
+			_, _, err = client.CreateSchema(ctx, "foo", test.schema)
+			if test.want {
+				assert.NoError(t, err)
+				return
+			}
+			var regErr registry.Error
+			require.ErrorAs(t, err, &regErr)
+			assert.Equal(t, 409, regErr.StatusCode)
+		})
+	}
+}
+
+func TestServer_TransitiveCompatibility(t *testing.T) {
+	srv, _ := newServer(t)
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+	srv.SetCompatibility("foo", registry.NoneCL)
+	_, err = srv.Register("foo", `{"type":"record","name":"test","fields":[{"name":"c","type":"string","default":""}]}`)
+	require.NoError(t, err)
+
+	srv.SetCompatibility("", registry.BackwardTransitiveCL)
+	srv.SetCompatibility("foo", "")
+	_, err = srv.Register("foo", `{"type":"record","name":"test","fields":[{"name":"c","type":"string","default":""},{"name":"d","type":"int"}]}`)
+
+	assert.Error(t, err)
+}
+
+func TestServer_Config(t *testing.T) {
+	_, client := newServer(t)
+	ctx := context.Background()
+
+	lvl, err := client.GetGlobalCompatibilityLevel(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.BackwardCL, lvl)
+
+	_, err = client.GetCompatibilityLevel(ctx, "foo")
+	assert.Error(t, err)
+
+	require.NoError(t, client.SetGlobalCompatibilityLevel(ctx, registry.FullCL))
+	require.NoError(t, client.SetCompatibilityLevel(ctx, "foo", registry.NoneCL))
+
+	lvl, err = client.GetGlobalCompatibilityLevel(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.FullCL, lvl)
+	lvl, err = client.GetCompatibilityLevel(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, registry.NoneCL, lvl)
+}
+
+func TestServer_References(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("item", `{"type":"record","name":"Item","fields":[{"name":"id","type":"int"}]}`)
+	require.NoError(t, err)
+
+	ref := registry.SchemaReference{Name: "Item", Subject: "item", Version: 1}
+	order := `{"type":"record","name":"Order","fields":[{"name":"item","type":"Item"}]}`
+	id, err := srv.Register("order", order, ref)
+	require.NoError(t, err)
+
+	_, err = srv.Register("other", order)
+	assert.Error(t, err)
+
+	schema, err := client.GetSchema(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Order", schema.(avro.NamedSchema).FullName())
+
+	got, err := srv.Register("order", order, ref)
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+}
+
+func TestServer_Requests(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	serializer, err := registry.NewSerializer(client, avro.MustParse(`"string"`), registry.WithAutoRegister())
+	require.NoError(t, err)
+	b, err := serializer.Serialize(ctx, "foo", "hello")
+	require.NoError(t, err)
+	_, err = serializer.Serialize(ctx, "foo", "world")
+	require.NoError(t, err)
+
+	var got string
+	require.NoError(t, registry.NewDecoder(client).Decode(ctx, b, &got))
+	assert.Equal(t, "hello", got)
+
+	want := []string{
+		"POST /subjects/foo-value",
+		"POST /subjects/foo-value/versions",
+		"GET /schemas/ids/1",
+	}
+	assert.Equal(t, want, srv.Requests())
+}
+
+func TestServer_SchemaLookups(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	id, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+	_, err = srv.Register("bar", schemaV1)
+	require.NoError(t, err)
+
+	versions, err := client.GetSubjectVersionsByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []registry.SubjectVersion{{Subject: "bar", Version: 1}, {Subject: "foo", Version: 1}}, versions)
+
+	subjects, err := client.GetSubjectsByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, subjects)
+
+	_, err = client.GetSubjectsByID(ctx, 42)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+}
+
+func TestServer_DeleteVersions(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+	_, err = srv.Register("foo", schemaV2)
+	require.NoError(t, err)
+
+	_, err = client.DeleteSubjectVersionPermanently(ctx, "foo", 1)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40407, regErr.Code)
+
+	deleted, err := client.DeleteSubjectVersion(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	versions, err := client.GetVersions(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions)
+	versions, err = client.GetVersionsIncludingDeleted(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+
+	deleted, err = client.DeleteSubjectVersionPermanently(ctx, "foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	versions, err = client.GetVersionsIncludingDeleted(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions)
+}
+
+func TestServer_DeleteSubjectPermanently(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+
+	_, err = client.DeleteSubjectPermanently(ctx, "foo")
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40405, regErr.Code)
+
+	_, err = client.DeleteSubject(ctx, "foo")
+	require.NoError(t, err)
+	subjects, err := client.GetSubjectsIncludingDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, subjects)
+
+	versions, err := client.DeleteSubjectPermanently(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions)
+	subjects, err = client.GetSubjectsIncludingDeleted(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, subjects)
+}
+
+func TestServer_Contexts(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+	_, _, err = client.CreateSchema(ctx, registry.ContextSubject(".orders", "foo"), schemaV1)
+	require.NoError(t, err)
+
+	contexts, err := client.GetContexts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".", ".orders"}, contexts)
+
+	versions, err := client.GetVersions(ctx, ":.orders:foo")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions)
+}
+
+func TestServer_Mode(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	mode, err := client.GetGlobalMode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.ReadWriteMode, mode)
+	_, err = client.GetMode(ctx, "foo")
+	assert.Error(t, err)
+
+	require.NoError(t, client.SetMode(ctx, "foo", registry.ReadOnlyMode, false))
+	mode, err = client.GetMode(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, registry.ReadOnlyMode, mode)
+
+	_, _, err = client.CreateSchema(ctx, "foo", schemaV1)
+	var regErr registry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 42205, regErr.Code)
+	_, _, err = client.CreateSchema(ctx, "bar", schemaV1)
+	require.NoError(t, err)
+
+	err = client.SetGlobalMode(ctx, registry.ImportMode, false)
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 42205, regErr.Code)
+	require.NoError(t, client.SetGlobalMode(ctx, registry.ImportMode, true))
+	mode, err = client.GetGlobalMode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.ImportMode, mode)
+	assert.Equal(t, []string{"bar"}, srv.Subjects())
+}
+
+func TestServer_ConfigFields(t *testing.T) {
+	_, client := newServer(t)
+	ctx := context.Background()
+	normalize := true
+
+	err := client.SetConfig(ctx, "foo", registry.Config{
+		Normalize:       &normalize,
+		DefaultMetadata: &registry.SchemaMetadata{Properties: map[string]string{"owner": "team"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.SetConfig(ctx, "foo", registry.Config{Compatibility: registry.FullCL}))
+
+	cfg, err := client.GetConfig(ctx, "foo")
+	require.NoError(t, err)
+	want := registry.Config{
+		Compatibility:   registry.FullCL,
+		Normalize:       &normalize,
+		DefaultMetadata: &registry.SchemaMetadata{Properties: map[string]string{"owner": "team"}},
+	}
+	assert.Equal(t, want, cfg)
+
+	cfg, err = client.GetGlobalConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.Config{Compatibility: registry.BackwardCL}, cfg)
+}
+
+func TestServer_CheckCompatibility(t *testing.T) {
+	srv, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Register("foo", schemaV1)
+	require.NoError(t, err)
+
+	res, err := client.CheckCompatibility(ctx, "foo", 1, schemaV2)
+	require.NoError(t, err)
+	assert.True(t, res.IsCompatible)
+	assert.Empty(t, res.Messages)
+
+	res, err = client.CheckCompatibility(ctx, "foo", -1, schemaV3)
+	require.NoError(t, err)
+	assert.False(t, res.IsCompatible)
+	assert.Len(t, res.Messages, 1)
+}
+
+func TestServer_ImportMode(t *testing.T) {
+	srv, _ := newServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	manifest := `{"schemas":[{"id":42,"file":"s.avsc"}],"subjects":[{"subject":"foo","versions":[{"version":5,"id":42}]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(manifest), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s.avsc"), []byte(schemaV1), 0o600))
+
+	require.NoError(t, registry.ImportSnapshot(ctx, srv.Client(), dir))
+
+	assert.Equal(t, []int{5}, srv.Versions("foo"))
+	raw, ok := srv.Schema(42)
+	assert.True(t, ok)
+	assert.Equal(t, schemaV1, raw)
+
+	id, err := srv.Register("foo", schemaV2)
+	require.NoError(t, err)
+	assert.Equal(t, 43, id)
+	assert.Equal(t, []int{5, 6}, srv.Versions("foo"))
+}
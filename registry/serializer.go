@@ -0,0 +1,292 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aryehlev/avro/v2"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// SubjectNameStrategy returns the subject the schema of keys or values written
+// to a topic is registered under.
+type SubjectNameStrategy func(topic string, isKey bool, schema avro.Schema) (string, error)
+
+// TopicNameStrategy registers schemas under the topic name, suffixed with
+// "-key" or "-value". It is the default strategy.
+func TopicNameStrategy(topic string, isKey bool, _ avro.Schema) (string, error) {
+	if isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
+// RecordNameStrategy registers schemas under their full name. The schema must be named.
+func RecordNameStrategy(_ string, _ bool, schema avro.Schema) (string, error) {
+	n, ok := schema.(avro.NamedSchema)
+	if !ok {
+		return "", errors.New("record name strategy requires a named schema")
+	}
+	return n.FullName(), nil
+}
+
+// TopicRecordNameStrategy registers schemas under the topic name and their
+// full name, separated by "-". The schema must be named.
+func TopicRecordNameStrategy(topic string, isKey bool, schema avro.Schema) (string, error) {
+	name, err := RecordNameStrategy(topic, isKey, schema)
+	if err != nil {
+		return "", err
+	}
+	return topic + "-" + name, nil
+}
+
+type serializerMode int
+
+const (
+	modeLookup serializerMode = iota
+	modeAutoRegister
+	modeUseLatest
+	modeUseID
+)
+
+// SerializerFunc is a function used to customize the Serializer.
+type SerializerFunc func(*Serializer)
+
+// WithSerializerAPI sets the avro configuration on the serializer.
+func WithSerializerAPI(api avro.API) SerializerFunc {
+	return func(s *Serializer) {
+		s.api = api
+	}
+}
+
+// WithSerializerWireFormat sets the wire format payloads are framed in. By
+// default, payloads are framed in the Confluent wire format. The Glue wire
+// format is decode only, as it identifies schemas by UUIDs registries do not
+// return, and cannot be set.
+func WithSerializerWireFormat(format WireFormat) SerializerFunc {
+	return func(s *Serializer) {
+		s.format = format
+	}
+}
+
+// WithSubjectNameStrategy sets the strategy naming the subject of the schema.
+func WithSubjectNameStrategy(strategy SubjectNameStrategy) SerializerFunc {
+	return func(s *Serializer) {
+		s.strategy = strategy
+	}
+}
+
+// WithKeySerializer makes the serializer serialize keys rather than values.
+func WithKeySerializer() SerializerFunc {
+	return func(s *Serializer) {
+		s.isKey = true
+	}
+}
+
+// WithAutoRegister registers the schema of the serializer under the subject
+// if it is not already registered.
+func WithAutoRegister() SerializerFunc {
+	return func(s *Serializer) {
+		s.mode = modeAutoRegister
+	}
+}
+
+// WithUseLatestVersion serializes values with the latest schema registered
+// under the subject, rather than the schema of the serializer. The latest schema
+// is looked up once per subject, and kept for the life of the serializer unless
+// a TTL is set with WithLatestVersionTTL.
+func WithUseLatestVersion() SerializerFunc {
+	return func(s *Serializer) {
+		s.mode = modeUseLatest
+	}
+}
+
+// WithLatestVersionTTL sets how long the latest schema of a subject is kept
+// when serializing with WithUseLatestVersion, after which it is looked up again
+// so that new versions are picked up.
+func WithLatestVersionTTL(ttl time.Duration) SerializerFunc {
+	return func(s *Serializer) {
+		s.latestTTL = ttl
+	}
+}
+
+// WithUseSchemaID serializes values with the schema with the given id, rather
+// than the schema of the serializer.
+func WithUseSchemaID(id int) SerializerFunc {
+	return func(s *Serializer) {
+		s.mode = modeUseID
+		s.id = id
+	}
+}
+
+type subjectSchema struct {
+	id      int
+	schema  avro.Schema
+	expires time.Time
+}
+
+// expired determines if the schema must be looked up again.
+func (s subjectSchema) expired() bool {
+	return !s.expires.IsZero() && !time.Now().Before(s.expires)
+}
+
+// Serializer encodes values in its wire format, the Confluent wire format by default.
+//
+// By default, the schema of the serializer must be registered under the subject.
+// The schema and id of each subject are cached once they are looked up.
+type Serializer struct {
+	client    Registry
+	api       avro.API
+	schema    avro.Schema
+	strategy  SubjectNameStrategy
+	format    WireFormat
+	isKey     bool
+	mode      serializerMode
+	id        int
+	latestTTL time.Duration
+
+	locks sync.Map // map[string]*sync.Mutex
+	cache sync.Map // map[string]subjectSchema
+}
+
+// NewSerializer returns a serializer of values with the given schema, getting
+// schema ids from client. The schema may be nil when the serializer uses the
+// latest version or a specific schema id.
+func NewSerializer(client Registry, schema avro.Schema, opts ...SerializerFunc) (*Serializer, error) {
+	s := &Serializer{
+		client:   client,
+		api:      avro.DefaultConfig,
+		schema:   schema,
+		strategy: TopicNameStrategy,
+		format:   ConfluentFormat(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if _, ok := s.format.(glueFormat); ok {
+		return nil, errors.New("serializer cannot use the glue wire format, as registries do not return the uuids of schema versions")
+	}
+	return s, nil
+}
+
+// Serialize returns v encoded in the wire format of the serializer, for the given topic.
+// See:
+// https://docs.confluent.io/3.2.0/schema-registry/docs/serializer-formatter.html#wire-format.
+func (s *Serializer) Serialize(ctx context.Context, topic string, v any) ([]byte, error) {
+	return s.AppendWithHeaders(ctx, nil, nil, topic, v)
+}
+
+// Append appends v encoded in the wire format of the serializer, for the given
+// topic, to dst and returns the extended buffer.
+func (s *Serializer) Append(ctx context.Context, dst []byte, topic string, v any) ([]byte, error) {
+	return s.AppendWithHeaders(ctx, dst, nil, topic, v)
+}
+
+// AppendWithHeaders is like Append, also setting the headers of the message
+// for formats carrying the schema id in a header.
+func (s *Serializer) AppendWithHeaders(
+	ctx context.Context,
+	dst []byte,
+	headers map[string][]byte,
+	topic string,
+	v any,
+) ([]byte, error) {
+	sch, err := s.subjectSchema(ctx, topic)
+	if err != nil {
+		return dst, err
+	}
+
+	data, err := s.api.Marshal(sch.schema, v)
+	if err != nil {
+		return dst, err
+	}
+
+	return s.format.Append(dst, headers, SchemaID{ID: int64(sch.id)}, data)
+}
+
+func (s *Serializer) subjectSchema(ctx context.Context, topic string) (subjectSchema, error) {
+	var subject string
+	if s.mode != modeUseID {
+		var err error
+		if subject, err = s.strategy(topic, s.isKey, s.schema); err != nil {
+			return subjectSchema{}, fmt.Errorf("naming subject: %w", err)
+		}
+	}
+
+	if sch, ok := s.cache.Load(subject); ok && !sch.(subjectSchema).expired() {
+		return sch.(subjectSchema), nil
+	}
+
+	// Lookups of a subject are serialized so that concurrent producers do not
+	// register the same schema more than once.
+	mu, _ := s.locks.LoadOrStore(subject, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	if sch, ok := s.cache.Load(subject); ok && !sch.(subjectSchema).expired() {
+		return sch.(subjectSchema), nil
+	}
+
+	sch, err := s.lookup(ctx, subject)
+	if err != nil {
+		return subjectSchema{}, err
+	}
+	if s.mode == modeUseLatest && s.latestTTL > 0 {
+		sch.expires = time.Now().Add(s.latestTTL)
+	}
+	s.cache.Store(subject, sch)
+	return sch, nil
+}
+
+func (s *Serializer) lookup(ctx context.Context, subject string) (subjectSchema, error) {
+	switch s.mode {
+	case modeUseID:
+		schema, err := s.client.GetSchema(ctx, s.id)
+		if err != nil {
+			return subjectSchema{}, fmt.Errorf("getting schema: %w", err)
+		}
+		return subjectSchema{id: s.id, schema: schema}, nil
+
+	case modeUseLatest:
+		info, err := s.client.GetLatestSchemaInfo(ctx, subject)
+		if err != nil {
+			return subjectSchema{}, fmt.Errorf("getting latest schema: %w", err)
+		}
+		return subjectSchema{id: info.ID, schema: info.Schema}, nil
+
+	default:
+		if s.schema == nil {
+			return subjectSchema{}, errors.New("a schema is required to look up its id")
+		}
+		// The schema is registered in full, rather than in canonical form, so that
+		// its defaults, docs and properties are kept.
+		text, err := jsoniter.Marshal(s.schema)
+		if err != nil {
+			return subjectSchema{}, fmt.Errorf("encoding schema: %w", err)
+		}
+		id, _, err := s.client.IsRegistered(ctx, subject, string(text))
+		if err == nil {
+			return subjectSchema{id: id, schema: s.schema}, nil
+		}
+		if s.mode != modeAutoRegister || !isNotFound(err) {
+			return subjectSchema{}, fmt.Errorf("looking up schema: %w", err)
+		}
+
+		id, _, err = s.client.CreateSchema(ctx, subject, string(text))
+		if err != nil {
+			return subjectSchema{}, fmt.Errorf("registering schema: %w", err)
+		}
+		return subjectSchema{id: id, schema: s.schema}, nil
+	}
+}
+
+// isNotFound determines if the error is a registry error for a missing subject or schema.
+func isNotFound(err error) bool {
+	var regErr Error
+	return errors.As(err, &regErr) && regErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,325 @@
+package registry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectNameStrategies(t *testing.T) {
+	named := avro.MustParse(`{"type":"record","name":"test","namespace":"org.hamba","fields":[{"name":"a","type":"int"}]}`)
+
+	tests := []struct {
+		name     string
+		strategy registry.SubjectNameStrategy
+		isKey    bool
+		schema   avro.Schema
+		want     string
+		wantErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:     "topic name value",
+			strategy: registry.TopicNameStrategy,
+			schema:   avro.MustParse(`"int"`),
+			want:     "orders-value",
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "topic name key",
+			strategy: registry.TopicNameStrategy,
+			isKey:    true,
+			schema:   avro.MustParse(`"int"`),
+			want:     "orders-key",
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "record name",
+			strategy: registry.RecordNameStrategy,
+			schema:   named,
+			want:     "org.hamba.test",
+			wantErr:  require.NoError,
+		},
+		{
+			name:     "record name unnamed schema",
+			strategy: registry.RecordNameStrategy,
+			schema:   avro.MustParse(`"int"`),
+			wantErr:  require.Error,
+		},
+		{
+			name:     "topic record name",
+			strategy: registry.TopicRecordNameStrategy,
+			schema:   named,
+			want:     "orders-org.hamba.test",
+			wantErr:  require.NoError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.strategy("orders", test.isKey, test.schema)
+
+			test.wantErr(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSerializer_Serialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema avro.Schema
+		opts   []registry.SerializerFunc
+		want   []string
+	}{
+		{
+			name:   "looks up schema",
+			schema: avro.MustParse(`"int"`),
+			want:   []string{"POST /subjects/orders-value"},
+		},
+		{
+			name:   "registers schema",
+			schema: avro.MustParse(`"int"`),
+			opts:   []registry.SerializerFunc{registry.WithAutoRegister(), registry.WithKeySerializer()},
+			want:   []string{"POST /subjects/orders-key", "POST /subjects/orders-key/versions"},
+		},
+		{
+			name: "uses latest version",
+			opts: []registry.SerializerFunc{registry.WithUseLatestVersion()},
+			want: []string{"GET /subjects/orders-value/versions/latest"},
+		},
+		{
+			name: "uses schema id",
+			opts: []registry.SerializerFunc{registry.WithUseSchemaID(42)},
+			want: []string{"GET /schemas/ids/42"},
+		},
+		{
+			name:   "uses subject name strategy",
+			schema: avro.MustParse(`"int"`),
+			opts: []registry.SerializerFunc{registry.WithSubjectNameStrategy(
+				func(topic string, _ bool, _ avro.Schema) (string, error) {
+					return "custom-" + topic, nil
+				},
+			)},
+			want: []string{"POST /subjects/custom-orders"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = append(got, r.Method+" "+r.URL.Path)
+
+				switch {
+				case r.URL.Path == "/subjects/orders-key":
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/subjects/orders-value/versions/latest":
+					_, _ = w.Write([]byte(`{"subject":"orders-value","version":1,"id":42,"schema":"\"int\""}`))
+				case r.Method == http.MethodGet:
+					_, _ = w.Write([]byte(`{"schema":"\"int\""}`))
+				default:
+					_, _ = w.Write([]byte(`{"id":42}`))
+				}
+			}))
+			t.Cleanup(s.Close)
+			client, _ := registry.NewClient(s.URL)
+			serializer, err := registry.NewSerializer(client, test.schema, test.opts...)
+			require.NoError(t, err)
+
+			b, err := serializer.Serialize(context.Background(), "orders", 128)
+			require.NoError(t, err)
+			b, err = serializer.Append(context.Background(), b, "orders", 1)
+			require.NoError(t, err)
+
+			assert.Equal(t, []byte{0x0, 0x0, 0x0, 0x0, 0x2a, 0x80, 0x2, 0x0, 0x0, 0x0, 0x0, 0x2a, 0x2}, b)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSerializer_RegistersFullSchema(t *testing.T) {
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	schema := `{"type":"record","name":"Order","doc":"An order.","fields":[{"name":"id","type":"int","default":1}]}`
+	serializer, err := registry.NewSerializer(srv.Client(), avro.MustParse(schema), registry.WithAutoRegister())
+	require.NoError(t, err)
+
+	_, err = serializer.Serialize(context.Background(), "orders", map[string]any{"id": 2})
+	require.NoError(t, err)
+
+	got, ok := srv.Schema(1)
+	require.True(t, ok)
+	assert.JSONEq(t, schema, got)
+}
+
+func TestSerializer_LatestVersionTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []registry.SerializerFunc
+		wantID byte
+	}{
+		{
+			name:   "keeps latest version",
+			wantID: 1,
+		},
+		{
+			name:   "looks up latest version after ttl",
+			opts:   []registry.SerializerFunc{registry.WithLatestVersionTTL(20 * time.Millisecond)},
+			wantID: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := registrytest.NewServer()
+			t.Cleanup(srv.Close)
+			srv.SetCompatibility("orders-value", registry.NoneCL)
+			_, err := srv.Register("orders-value", `"int"`)
+			require.NoError(t, err)
+			opts := append([]registry.SerializerFunc{registry.WithUseLatestVersion()}, test.opts...)
+			serializer, err := registry.NewSerializer(srv.Client(), nil, opts...)
+			require.NoError(t, err)
+
+			b, err := serializer.Serialize(context.Background(), "orders", 1)
+			require.NoError(t, err)
+			assert.Equal(t, byte(1), b[4])
+
+			_, err = srv.Register("orders-value", `"long"`)
+			require.NoError(t, err)
+			time.Sleep(30 * time.Millisecond)
+
+			b, err = serializer.Serialize(context.Background(), "orders", 1)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantID, b[4])
+		})
+	}
+}
+
+func TestSerializer_LookupsOfSubjectsDoNotBlockEachOther(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/subjects/slow-value" {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(release) })
+	client, _ := registry.NewClient(s.URL)
+	serializer, err := registry.NewSerializer(client, avro.MustParse(`"int"`))
+	require.NoError(t, err)
+
+	go func() { _, _ = serializer.Serialize(context.Background(), "slow", 1) }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = serializer.Serialize(ctx, "orders", 1)
+
+	assert.NoError(t, err)
+}
+
+func TestSerializer_SerializeDecodes(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"schema":"\"string\""}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":7}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+	serializer, err := registry.NewSerializer(client, avro.MustParse(`"string"`))
+	require.NoError(t, err)
+
+	b, err := serializer.Serialize(context.Background(), "orders", "hello")
+	require.NoError(t, err)
+
+	var got string
+	err = registry.NewDecoder(client).Decode(context.Background(), b, &got)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+}
+
+func TestSerializer_SerializeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema avro.Schema
+		opts   []registry.SerializerFunc
+		value  any
+	}{
+		{
+			name:   "schema not registered",
+			schema: avro.MustParse(`"int"`),
+		},
+		{
+			name:   "registration fails",
+			schema: avro.MustParse(`"int"`),
+			opts:   []registry.SerializerFunc{registry.WithAutoRegister()},
+		},
+		{
+			name: "no schema",
+		},
+		{
+			name:   "invalid subject",
+			schema: avro.MustParse(`"int"`),
+			opts:   []registry.SerializerFunc{registry.WithSubjectNameStrategy(registry.RecordNameStrategy)},
+		},
+		{
+			name: "latest version not found",
+			opts: []registry.SerializerFunc{registry.WithUseLatestVersion()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+			}))
+			t.Cleanup(s.Close)
+			client, _ := registry.NewClient(s.URL)
+			serializer, err := registry.NewSerializer(client, test.schema, test.opts...)
+			require.NoError(t, err)
+
+			dst := []byte{0x1}
+			got, err := serializer.Append(context.Background(), dst, "orders", 1)
+
+			assert.Error(t, err)
+			assert.Equal(t, dst, got)
+		})
+	}
+}
+
+func TestNewSerializer_GlueFormat(t *testing.T) {
+	client, _ := registry.NewClient("http://example.com")
+
+	_, err := registry.NewSerializer(client, avro.MustParse(`"int"`),
+		registry.WithSerializerWireFormat(registry.GlueFormat()),
+	)
+
+	assert.EqualError(t, err, "serializer cannot use the glue wire format, as registries do not return the uuids of schema versions")
+}
+
+func TestSerializer_SerializeMarshalError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	t.Cleanup(s.Close)
+	client, _ := registry.NewClient(s.URL)
+	serializer, err := registry.NewSerializer(client, avro.MustParse(`"int"`))
+	require.NoError(t, err)
+
+	_, err = serializer.Serialize(context.Background(), "orders", "not an int")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,279 @@
+package registry
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ManifestFile is the name of the manifest file of a snapshot.
+const ManifestFile = "manifest.json"
+
+// Manifest describes the subjects and schemas of a registry snapshot.
+type Manifest struct {
+	// Compatibility is the global compatibility level.
+	Compatibility string `json:"compatibility,omitempty"`
+	// Schemas are the schemas of the snapshot.
+	Schemas []ManifestSchema `json:"schemas"`
+	// Subjects are the subjects of the snapshot.
+	Subjects []ManifestSubject `json:"subjects"`
+}
+
+// ManifestSchema describes a schema of a snapshot.
+type ManifestSchema struct {
+	// ID is the id of the schema.
+	ID int `json:"id"`
+	// File is the path of the schema file, relative to the snapshot directory.
+	File string `json:"file"`
+	// References are the schemas referenced by the schema.
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+// ManifestSubject describes a subject of a snapshot.
+type ManifestSubject struct {
+	// Subject is the name of the subject.
+	Subject string `json:"subject"`
+	// Compatibility is the compatibility level of the subject, if it is set.
+	Compatibility string `json:"compatibility,omitempty"`
+	// Versions are the versions of the subject, in order.
+	Versions []ManifestVersion `json:"versions"`
+}
+
+// ManifestVersion describes a version of a subject.
+type ManifestVersion struct {
+	Version int `json:"version"`
+	ID      int `json:"id"`
+}
+
+// ExportSnapshot exports the subjects, versions and schemas of the registry to
+// dir, writing each schema to an .avsc file named after its id, and the
+// manifest describing them to ManifestFile.
+func ExportSnapshot(ctx context.Context, client *Client, dir string) error {
+	compat, err := client.GetGlobalCompatibilityLevel(ctx)
+	if err != nil {
+		return fmt.Errorf("getting compatibility level: %w", err)
+	}
+	subjects, err := client.GetSubjects(ctx)
+	if err != nil {
+		return fmt.Errorf("getting subjects: %w", err)
+	}
+	slices.Sort(subjects)
+
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	manifest := Manifest{Compatibility: compat, Schemas: []ManifestSchema{}, Subjects: []ManifestSubject{}}
+	exported := map[int]bool{}
+	for _, subject := range subjects {
+		sub := ManifestSubject{Subject: subject}
+		sub.Compatibility, err = client.GetCompatibilityLevel(ctx, subject)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("getting compatibility level of %s: %w", subject, err)
+		}
+
+		versions, err := client.GetVersions(ctx, subject)
+		if err != nil {
+			return fmt.Errorf("getting versions of %s: %w", subject, err)
+		}
+		for _, version := range versions {
+			var resp schemaInfoPayload
+			p := path.Join("subjects", subject, "versions", strconv.Itoa(version))
+			if err = client.request(ctx, http.MethodGet, p, nil, &resp); err != nil {
+				return fmt.Errorf("getting version %d of %s: %w", version, subject, err)
+			}
+			sub.Versions = append(sub.Versions, ManifestVersion{Version: version, ID: resp.ID})
+
+			if exported[resp.ID] {
+				continue
+			}
+			exported[resp.ID] = true
+
+			file := strconv.Itoa(resp.ID) + ".avsc"
+			if err = os.WriteFile(filepath.Join(dir, file), []byte(resp.Schema), 0o600); err != nil {
+				return err
+			}
+			manifest.Schemas = append(manifest.Schemas, ManifestSchema{
+				ID:         resp.ID,
+				File:       file,
+				References: resp.References,
+			})
+		}
+		manifest.Subjects = append(manifest.Subjects, sub)
+	}
+
+	slices.SortFunc(manifest.Schemas, func(a, b ManifestSchema) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	b, err := jsoniter.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFile), b, 0o600)
+}
+
+// snapshot is a snapshot read from a directory.
+type snapshot struct {
+	manifest Manifest
+	schemas  map[int]snapshotSchema
+}
+
+type snapshotSchema struct {
+	schema string
+	refs   []SchemaReference
+}
+
+// readSnapshot reads the manifest and schemas of the snapshot in dir.
+func readSnapshot(dir string) (*snapshot, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{schemas: map[int]snapshotSchema{}}
+	if err = jsoniter.Unmarshal(b, &snap.manifest); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	for _, s := range snap.manifest.Schemas {
+		if _, ok := snap.schemas[s.ID]; ok {
+			return nil, fmt.Errorf("duplicate schema id %d", s.ID)
+		}
+		// Schema files must be within the directory of the snapshot.
+		file := filepath.FromSlash(s.File)
+		if !filepath.IsLocal(file) {
+			return nil, fmt.Errorf("schema %d has file %q outside the snapshot", s.ID, s.File)
+		}
+		b, err = os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		snap.schemas[s.ID] = snapshotSchema{schema: string(b), refs: s.References}
+	}
+
+	for _, sub := range snap.manifest.Subjects {
+		for _, v := range sub.Versions {
+			if _, ok := snap.schemas[v.ID]; !ok {
+				return nil, fmt.Errorf("version %d of %s has unknown schema id %d", v.Version, sub.Subject, v.ID)
+			}
+		}
+	}
+	return snap, nil
+}
+
+type importPayload struct {
+	Schema     string            `json:"schema"`
+	References []SchemaReference `json:"references,omitempty"`
+	ID         int               `json:"id"`
+	Version    int               `json:"version"`
+}
+
+type importEntry struct {
+	subject string
+	version ManifestVersion
+}
+
+// ImportSnapshot replays the snapshot in dir into the registry, preserving the
+// ids and versions of its schemas. Schemas are imported after the schemas they
+// reference.
+//
+// The registry is put in import mode for the import, and its mode is restored
+// afterwards. Registries with subjects must be put in import mode beforehand,
+// as it can only be set on them by force.
+func ImportSnapshot(ctx context.Context, client *Client, dir string) (err error) {
+	snap, err := readSnapshot(dir)
+	if err != nil {
+		return err
+	}
+
+	mode, err := client.GetGlobalMode(ctx)
+	if err != nil {
+		return fmt.Errorf("getting mode: %w", err)
+	}
+	if mode != ImportMode {
+		if err = client.SetGlobalMode(ctx, ImportMode, false); err != nil {
+			return fmt.Errorf("setting import mode: %w", err)
+		}
+		defer func() {
+			if restoreErr := client.SetGlobalMode(ctx, mode, false); restoreErr != nil && err == nil {
+				err = fmt.Errorf("restoring mode: %w", restoreErr)
+			}
+		}()
+	}
+
+	if snap.manifest.Compatibility != "" {
+		if err = client.SetGlobalCompatibilityLevel(ctx, snap.manifest.Compatibility); err != nil {
+			return fmt.Errorf("setting compatibility level: %w", err)
+		}
+	}
+
+	var entries []importEntry
+	for _, sub := range snap.manifest.Subjects {
+		if sub.Compatibility != "" {
+			if err = client.SetCompatibilityLevel(ctx, sub.Subject, sub.Compatibility); err != nil {
+				return fmt.Errorf("setting compatibility level of %s: %w", sub.Subject, err)
+			}
+		}
+		for _, v := range sub.Versions {
+			entries = append(entries, importEntry{subject: sub.Subject, version: v})
+		}
+	}
+	slices.SortFunc(entries, func(a, b importEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.version.ID, b.version.ID),
+			cmp.Compare(a.subject, b.subject),
+			cmp.Compare(a.version.Version, b.version.Version),
+		)
+	})
+
+	imported := map[importEntry]bool{}
+	var importFn func(e importEntry) error
+	importFn = func(e importEntry) error {
+		if imported[e] {
+			return nil
+		}
+		imported[e] = true
+
+		s := snap.schemas[e.version.ID]
+		for _, ref := range s.refs {
+			i := slices.IndexFunc(entries, func(e importEntry) bool {
+				return e.subject == ref.Subject && e.version.Version == ref.Version
+			})
+			// References outside the snapshot must already be in the registry.
+			if i < 0 {
+				continue
+			}
+			if err := importFn(entries[i]); err != nil {
+				return err
+			}
+		}
+
+		req := importPayload{Schema: s.schema, References: s.refs, ID: e.version.ID, Version: e.version.Version}
+		var resp idPayload
+		p := path.Join("subjects", e.subject, "versions")
+		if err := client.request(ctx, http.MethodPost, p, req, &resp); err != nil {
+			return fmt.Errorf("importing version %d of %s: %w", e.version.Version, e.subject, err)
+		}
+		if resp.ID != e.version.ID {
+			return fmt.Errorf("importing version %d of %s: got id %d, expected %d",
+				e.version.Version, e.subject, resp.ID, e.version.ID)
+		}
+		return nil
+	}
+
+	for _, e := range entries {
+		if err = importFn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
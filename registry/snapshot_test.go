@@ -0,0 +1,201 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	snapshotItem  = `{"type":"record","name":"Item","fields":[{"name":"id","type":"int"}]}`
+	snapshotOrder = `{"type":"record","name":"Order","fields":[{"name":"item","type":"Item"}]}`
+)
+
+// newSnapshot exports a registry with referenced schemas to a directory.
+func newSnapshot(t *testing.T) string {
+	t.Helper()
+
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetCompatibility("foo", registry.NoneCL)
+	_, err := srv.Register("foo", cacheSchemaV1)
+	require.NoError(t, err)
+	_, err = srv.Register("foo", `"string"`)
+	require.NoError(t, err)
+	_, err = srv.Register("item", snapshotItem)
+	require.NoError(t, err)
+	ref := registry.SchemaReference{Name: "Item", Subject: "item", Version: 1}
+	_, err = srv.Register("order", snapshotOrder, ref)
+	require.NoError(t, err)
+	_, err = srv.Register("bar", cacheSchemaV1)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, registry.ExportSnapshot(context.Background(), srv.Client(), dir))
+	return dir
+}
+
+func TestExportSnapshot(t *testing.T) {
+	dir := newSnapshot(t)
+
+	b, err := os.ReadFile(filepath.Join(dir, registry.ManifestFile))
+	require.NoError(t, err)
+	var got registry.Manifest
+	require.NoError(t, json.Unmarshal(b, &got))
+
+	want := registry.Manifest{
+		Compatibility: registry.BackwardCL,
+		Schemas: []registry.ManifestSchema{
+			{ID: 1, File: "1.avsc"},
+			{ID: 2, File: "2.avsc"},
+			{ID: 3, File: "3.avsc"},
+			{
+				ID:         4,
+				File:       "4.avsc",
+				References: []registry.SchemaReference{{Name: "Item", Subject: "item", Version: 1}},
+			},
+		},
+		Subjects: []registry.ManifestSubject{
+			{Subject: "bar", Versions: []registry.ManifestVersion{{Version: 1, ID: 1}}},
+			{
+				Subject:       "foo",
+				Compatibility: registry.NoneCL,
+				Versions:      []registry.ManifestVersion{{Version: 1, ID: 1}, {Version: 2, ID: 2}},
+			},
+			{Subject: "item", Versions: []registry.ManifestVersion{{Version: 1, ID: 3}}},
+			{Subject: "order", Versions: []registry.ManifestVersion{{Version: 1, ID: 4}}},
+		},
+	}
+	assert.Equal(t, want, got)
+
+	schema, err := os.ReadFile(filepath.Join(dir, "4.avsc"))
+	require.NoError(t, err)
+	assert.Equal(t, snapshotOrder, string(schema))
+}
+
+func TestImportSnapshot(t *testing.T) {
+	dir := newSnapshot(t)
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	ctx := context.Background()
+
+	err := registry.ImportSnapshot(ctx, client, dir)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo", "item", "order"}, srv.Subjects())
+	assert.Equal(t, []int{1, 2}, srv.Versions("foo"))
+	info, err := client.GetLatestSchemaInfo(ctx, "order")
+	require.NoError(t, err)
+	assert.Equal(t, 4, info.ID)
+	assert.Equal(t, "Order", info.Schema.(avro.NamedSchema).FullName())
+	lvl, err := client.GetCompatibilityLevel(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, registry.NoneCL, lvl)
+
+	mode, err := client.GetGlobalMode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, registry.ReadWriteMode, mode)
+
+	// New schemas get ids after the imported ids.
+	id, _, err := client.CreateSchema(ctx, "baz", `"int"`)
+	require.NoError(t, err)
+	assert.Equal(t, 5, id)
+}
+
+func TestImportSnapshot_PreservesIDs(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `{
+		"schemas": [{"id": 100, "file": "item.avsc"}, {"id": 7, "file": "order.avsc", "references": [{"name": "Item", "subject": "item", "version": 3}]}],
+		"subjects": [
+			{"subject": "order", "versions": [{"version": 2, "id": 7}]},
+			{"subject": "item", "versions": [{"version": 3, "id": 100}]}
+		]
+	}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(manifest), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "item.avsc"), []byte(snapshotItem), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order.avsc"), []byte(snapshotOrder), 0o600))
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	ctx := context.Background()
+
+	err := registry.ImportSnapshot(ctx, client, dir)
+
+	require.NoError(t, err)
+	info, err := client.GetSchemaInfo(ctx, "order", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 7, info.ID)
+	info, err = client.GetSchemaInfo(ctx, "item", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 100, info.ID)
+}
+
+func TestImportSnapshot_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing manifest", func(t *testing.T) {
+		srv := registrytest.NewServer()
+		t.Cleanup(srv.Close)
+
+		err := registry.ImportSnapshot(ctx, srv.Client(), t.TempDir())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("registry with subjects", func(t *testing.T) {
+		dir := newSnapshot(t)
+		srv := registrytest.NewServer()
+		t.Cleanup(srv.Close)
+		_, err := srv.Register("existing", `"int"`)
+		require.NoError(t, err)
+
+		err = registry.ImportSnapshot(ctx, srv.Client(), dir)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("conflicting id", func(t *testing.T) {
+		dir := newSnapshot(t)
+		srv := registrytest.NewServer()
+		t.Cleanup(srv.Close)
+		client := srv.Client()
+		_, err := srv.Register("existing", `"int"`)
+		require.NoError(t, err)
+		require.NoError(t, client.SetGlobalMode(ctx, registry.ImportMode, true))
+
+		err = registry.ImportSnapshot(ctx, client, dir)
+
+		var regErr registry.Error
+		require.ErrorAs(t, err, &regErr)
+		assert.Equal(t, 42207, regErr.Code)
+	})
+
+	t.Run("restores mode", func(t *testing.T) {
+		dir := t.TempDir()
+		manifest := `{
+			"schemas": [{"id": 1, "file": "order.avsc", "references": [{"name": "Item", "subject": "item", "version": 1}]}],
+			"subjects": [{"subject": "order", "versions": [{"version": 1, "id": 1}]}]
+		}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(manifest), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "order.avsc"), []byte(snapshotOrder), 0o600))
+		srv := registrytest.NewServer()
+		t.Cleanup(srv.Close)
+		client := srv.Client()
+
+		err := registry.ImportSnapshot(ctx, client, dir)
+
+		assert.Error(t, err)
+		mode, err := client.GetGlobalMode(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, registry.ReadWriteMode, mode)
+	})
+}
@@ -0,0 +1,306 @@
+package registry
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/aryehlev/avro/v2"
+)
+
+// SchemaID identifies a schema in a registry, either by a numeric id or by a GUID.
+type SchemaID struct {
+	// ID is the numeric id of the schema.
+	ID int64
+	// GUID is the GUID of the schema. It is used instead of ID when it is not zero.
+	GUID [16]byte
+}
+
+// IsGUID determines if the schema is identified by its GUID.
+func (id SchemaID) IsGUID() bool {
+	return id.GUID != [16]byte{}
+}
+
+// String returns the id, or the GUID in its canonical text form.
+func (id SchemaID) String() string {
+	if !id.IsGUID() {
+		return strconv.FormatInt(id.ID, 10)
+	}
+
+	var b [36]byte
+	hex.Encode(b[0:8], id.GUID[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], id.GUID[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], id.GUID[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], id.GUID[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], id.GUID[10:])
+	return string(b[:])
+}
+
+// SchemaResolver resolves schemas by their id.
+type SchemaResolver interface {
+	// ResolveSchema returns the schema with the given id.
+	ResolveSchema(ctx context.Context, id SchemaID) (avro.Schema, error)
+}
+
+// SchemaResolverFunc is a function resolving schemas by their id.
+type SchemaResolverFunc func(ctx context.Context, id SchemaID) (avro.Schema, error)
+
+// ResolveSchema returns the schema with the given id.
+func (f SchemaResolverFunc) ResolveSchema(ctx context.Context, id SchemaID) (avro.Schema, error) {
+	return f(ctx, id)
+}
+
+// WireFormat frames avro payloads with the id of their schema.
+type WireFormat interface {
+	// Name returns the name of the format.
+	Name() string
+
+	// Detect determines if the message is framed in the format.
+	Detect(data []byte, headers map[string][]byte) bool
+
+	// Extract returns the schema id and the avro payload of the message.
+	Extract(data []byte, headers map[string][]byte) (SchemaID, []byte, error)
+
+	// Append appends the framed payload to dst, setting any headers of the message.
+	Append(dst []byte, headers map[string][]byte, id SchemaID, payload []byte) ([]byte, error)
+}
+
+// numericID returns the schema id as a 4 byte id.
+func numericID(format string, id SchemaID) (uint32, error) {
+	if id.IsGUID() || id.ID < 0 || id.ID > math.MaxUint32 {
+		return 0, fmt.Errorf("%s format requires a 4 byte schema id, got %s", format, id)
+	}
+	return uint32(id.ID), nil
+}
+
+type confluentFormat struct{}
+
+// ConfluentFormat returns the Confluent wire format, framing payloads with a zero
+// magic byte and a 4 byte big-endian schema id.
+// See:
+// https://docs.confluent.io/3.2.0/schema-registry/docs/serializer-formatter.html#wire-format.
+func ConfluentFormat() WireFormat {
+	return confluentFormat{}
+}
+
+func (confluentFormat) Name() string {
+	return "confluent"
+}
+
+func (confluentFormat) Detect(data []byte, _ map[string][]byte) bool {
+	return len(data) >= 6 && data[0] == 0
+}
+
+func (confluentFormat) Extract(data []byte, _ map[string][]byte) (SchemaID, []byte, error) {
+	if len(data) < 6 {
+		return SchemaID{}, nil, errors.New("data too short")
+	}
+
+	id, err := extractSchemaID(data)
+	if err != nil {
+		return SchemaID{}, nil, fmt.Errorf("extracting schema id: %w", err)
+	}
+	return SchemaID{ID: int64(id)}, data[5:], nil
+}
+
+func (f confluentFormat) Append(dst []byte, _ map[string][]byte, id SchemaID, payload []byte) ([]byte, error) {
+	n, err := numericID(f.Name(), id)
+	if err != nil {
+		return dst, err
+	}
+
+	dst = append(dst, 0)
+	dst = binary.BigEndian.AppendUint32(dst, n)
+	return append(dst, payload...), nil
+}
+
+type apicurioFormat struct{}
+
+// ApicurioFormat returns the Apicurio wire format, framing payloads with a zero
+// magic byte and an 8 byte big-endian global schema id.
+//
+// Payloads in the Apicurio and Confluent formats cannot be told apart, so only
+// one of them can be detected.
+func ApicurioFormat() WireFormat {
+	return apicurioFormat{}
+}
+
+func (apicurioFormat) Name() string {
+	return "apicurio"
+}
+
+func (apicurioFormat) Detect(data []byte, _ map[string][]byte) bool {
+	return len(data) >= 9 && data[0] == 0
+}
+
+func (apicurioFormat) Extract(data []byte, _ map[string][]byte) (SchemaID, []byte, error) {
+	if len(data) < 9 {
+		return SchemaID{}, nil, errors.New("data too short")
+	}
+	if data[0] != 0 {
+		return SchemaID{}, nil, fmt.Errorf("invalid magic byte: %x", data[0])
+	}
+	return SchemaID{ID: int64(binary.BigEndian.Uint64(data[1:9]))}, data[9:], nil
+}
+
+func (apicurioFormat) Append(dst []byte, _ map[string][]byte, id SchemaID, payload []byte) ([]byte, error) {
+	if id.IsGUID() {
+		return dst, fmt.Errorf("apicurio format requires a global schema id, got %s", id)
+	}
+
+	dst = append(dst, 0)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(id.ID))
+	return append(dst, payload...), nil
+}
+
+const (
+	glueVersion         = 3
+	glueCompressionNone = 0
+	glueCompressionZlib = 5
+)
+
+type glueFormat struct{}
+
+// GlueFormat returns the AWS Glue wire format, framing payloads with a version
+// byte, a compression byte and the 16 byte UUID of the schema version. Payloads
+// compressed with zlib are decompressed.
+//
+// It is decode only. A Serializer cannot use it, as registries do not return
+// the UUIDs of schema versions.
+func GlueFormat() WireFormat {
+	return glueFormat{}
+}
+
+func (glueFormat) Name() string {
+	return "glue"
+}
+
+func (glueFormat) Detect(data []byte, _ map[string][]byte) bool {
+	return len(data) >= 18 && data[0] == glueVersion &&
+		(data[1] == glueCompressionNone || data[1] == glueCompressionZlib)
+}
+
+func (glueFormat) Extract(data []byte, _ map[string][]byte) (SchemaID, []byte, error) {
+	if len(data) < 18 {
+		return SchemaID{}, nil, errors.New("data too short")
+	}
+	if data[0] != glueVersion {
+		return SchemaID{}, nil, fmt.Errorf("invalid header version: %x", data[0])
+	}
+
+	var id SchemaID
+	copy(id.GUID[:], data[2:18])
+	payload := data[18:]
+
+	switch data[1] {
+	case glueCompressionNone:
+		return id, payload, nil
+	case glueCompressionZlib:
+		r, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return SchemaID{}, nil, fmt.Errorf("decompressing payload: %w", err)
+		}
+		if payload, err = io.ReadAll(r); err != nil {
+			return SchemaID{}, nil, fmt.Errorf("decompressing payload: %w", err)
+		}
+		return id, payload, nil
+	default:
+		return SchemaID{}, nil, fmt.Errorf("invalid compression byte: %x", data[1])
+	}
+}
+
+func (glueFormat) Append(dst []byte, _ map[string][]byte, id SchemaID, payload []byte) ([]byte, error) {
+	if !id.IsGUID() {
+		return dst, fmt.Errorf("glue format requires a schema version UUID, got %s", id)
+	}
+
+	dst = append(dst, glueVersion, glueCompressionNone)
+	dst = append(dst, id.GUID[:]...)
+	return append(dst, payload...), nil
+}
+
+// Schema id header magic bytes.
+const (
+	headerMagicID   = 0
+	headerMagicGUID = 1
+)
+
+type headerFormat struct {
+	header string
+}
+
+// HeaderFormat returns the format carrying the schema id in a message header,
+// such as "__value_schema_id", leaving the payload unframed. The header holds
+// a zero magic byte and a 4 byte big-endian id, or a magic byte of one and a
+// 16 byte GUID.
+func HeaderFormat(header string) WireFormat {
+	return headerFormat{header: header}
+}
+
+func (f headerFormat) Name() string {
+	return "header " + f.header
+}
+
+func (f headerFormat) Detect(_ []byte, headers map[string][]byte) bool {
+	_, ok := headers[f.header]
+	return ok
+}
+
+func (f headerFormat) Extract(data []byte, headers map[string][]byte) (SchemaID, []byte, error) {
+	h, ok := headers[f.header]
+	if !ok {
+		return SchemaID{}, nil, fmt.Errorf("missing header %s", f.header)
+	}
+
+	var id SchemaID
+	switch {
+	case len(h) == 5 && h[0] == headerMagicID:
+		id.ID = int64(binary.BigEndian.Uint32(h[1:]))
+	case len(h) == 17 && h[0] == headerMagicGUID:
+		copy(id.GUID[:], h[1:])
+	default:
+		return SchemaID{}, nil, fmt.Errorf("invalid schema id header %s", f.header)
+	}
+	return id, data, nil
+}
+
+func (f headerFormat) Append(dst []byte, headers map[string][]byte, id SchemaID, payload []byte) ([]byte, error) {
+	if headers == nil {
+		return dst, fmt.Errorf("%s format requires message headers", f.Name())
+	}
+
+	if id.IsGUID() {
+		headers[f.header] = append([]byte{headerMagicGUID}, id.GUID[:]...)
+	} else {
+		n, err := numericID(f.Name(), id)
+		if err != nil {
+			return dst, err
+		}
+		headers[f.header] = binary.BigEndian.AppendUint32([]byte{headerMagicID}, n)
+	}
+	return append(dst, payload...), nil
+}
+
+// detectFormat returns the first of the formats the message is framed in.
+func detectFormat(formats []WireFormat, data []byte, headers map[string][]byte) (WireFormat, error) {
+	if len(formats) == 1 {
+		return formats[0], nil
+	}
+	for _, f := range formats {
+		if f.Detect(data, headers) {
+			return f, nil
+		}
+	}
+	return nil, errors.New("unknown wire format")
+}
@@ -0,0 +1,330 @@
+package registry_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/aryehlev/avro/v2/registry"
+	"github.com/aryehlev/avro/v2/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGUID = [16]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}
+
+func TestSchemaID_String(t *testing.T) {
+	assert.Equal(t, "42", registry.SchemaID{ID: 42}.String())
+	assert.Equal(t, "12345678-9abc-def0-1234-56789abcdef0", registry.SchemaID{GUID: testGUID}.String())
+}
+
+func TestWireFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      registry.WireFormat
+		id          registry.SchemaID
+		wantData    []byte
+		wantHeaders map[string][]byte
+	}{
+		{
+			name:     "confluent",
+			format:   registry.ConfluentFormat(),
+			id:       registry.SchemaID{ID: 42},
+			wantData: []byte{0x0, 0x0, 0x0, 0x0, 0x2a, 0x80, 0x2},
+		},
+		{
+			name:     "apicurio",
+			format:   registry.ApicurioFormat(),
+			id:       registry.SchemaID{ID: 42},
+			wantData: []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2a, 0x80, 0x2},
+		},
+		{
+			name:     "glue",
+			format:   registry.GlueFormat(),
+			id:       registry.SchemaID{GUID: testGUID},
+			wantData: append(append([]byte{0x3, 0x0}, testGUID[:]...), 0x80, 0x2),
+		},
+		{
+			name:        "header id",
+			format:      registry.HeaderFormat("__value_schema_id"),
+			id:          registry.SchemaID{ID: 42},
+			wantData:    []byte{0x80, 0x2},
+			wantHeaders: map[string][]byte{"__value_schema_id": {0x0, 0x0, 0x0, 0x0, 0x2a}},
+		},
+		{
+			name:        "header guid",
+			format:      registry.HeaderFormat("__value_schema_id"),
+			id:          registry.SchemaID{GUID: testGUID},
+			wantData:    []byte{0x80, 0x2},
+			wantHeaders: map[string][]byte{"__value_schema_id": append([]byte{0x1}, testGUID[:]...)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := map[string][]byte{}
+			data, err := test.format.Append(nil, headers, test.id, []byte{0x80, 0x2})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.wantData, data)
+			if test.wantHeaders != nil {
+				assert.Equal(t, test.wantHeaders, headers)
+			}
+			assert.True(t, test.format.Detect(data, headers))
+
+			id, payload, err := test.format.Extract(data, headers)
+			require.NoError(t, err)
+			assert.Equal(t, test.id, id)
+			assert.Equal(t, []byte{0x80, 0x2}, payload)
+		})
+	}
+}
+
+func TestWireFormats_AppendErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  registry.WireFormat
+		headers map[string][]byte
+		id      registry.SchemaID
+	}{
+		{
+			name:   "confluent guid",
+			format: registry.ConfluentFormat(),
+			id:     registry.SchemaID{GUID: testGUID},
+		},
+		{
+			name:   "confluent id too large",
+			format: registry.ConfluentFormat(),
+			id:     registry.SchemaID{ID: 1 << 40},
+		},
+		{
+			name:   "apicurio guid",
+			format: registry.ApicurioFormat(),
+			id:     registry.SchemaID{GUID: testGUID},
+		},
+		{
+			name:   "glue id",
+			format: registry.GlueFormat(),
+			id:     registry.SchemaID{ID: 42},
+		},
+		{
+			name:   "header without headers",
+			format: registry.HeaderFormat("__value_schema_id"),
+			id:     registry.SchemaID{ID: 42},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := []byte{0x1}
+			got, err := test.format.Append(dst, test.headers, test.id, []byte{0x2})
+
+			assert.Error(t, err)
+			assert.Equal(t, dst, got)
+		})
+	}
+}
+
+func TestWireFormats_ExtractErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  registry.WireFormat
+		data    []byte
+		headers map[string][]byte
+	}{
+		{
+			name:   "confluent short data",
+			format: registry.ConfluentFormat(),
+			data:   []byte{0x0, 0x0, 0x0, 0x0, 0x2a},
+		},
+		{
+			name:   "apicurio short data",
+			format: registry.ApicurioFormat(),
+			data:   []byte{0x0, 0x0, 0x0, 0x0},
+		},
+		{
+			name:   "apicurio bad magic",
+			format: registry.ApicurioFormat(),
+			data:   []byte{0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2a},
+		},
+		{
+			name:   "glue bad version",
+			format: registry.GlueFormat(),
+			data:   append([]byte{0x2, 0x0}, testGUID[:]...),
+		},
+		{
+			name:   "glue bad compression",
+			format: registry.GlueFormat(),
+			data:   append([]byte{0x3, 0x1}, testGUID[:]...),
+		},
+		{
+			name:   "glue bad zlib data",
+			format: registry.GlueFormat(),
+			data:   append(append([]byte{0x3, 0x5}, testGUID[:]...), 0x1),
+		},
+		{
+			name:   "header missing",
+			format: registry.HeaderFormat("__value_schema_id"),
+		},
+		{
+			name:    "header invalid",
+			format:  registry.HeaderFormat("__value_schema_id"),
+			headers: map[string][]byte{"__value_schema_id": {0x1, 0x2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := test.format.Extract(test.data, test.headers)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWireFormats_DetectMatchesExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		format registry.WireFormat
+		data   []byte
+	}{
+		{
+			name:   "confluent without payload",
+			format: registry.ConfluentFormat(),
+			data:   []byte{0x0, 0x0, 0x0, 0x0, 0x2a},
+		},
+		{
+			name:   "confluent with payload",
+			format: registry.ConfluentFormat(),
+			data:   []byte{0x0, 0x0, 0x0, 0x0, 0x2a, 0x0},
+		},
+		{
+			name:   "apicurio without payload",
+			format: registry.ApicurioFormat(),
+			data:   []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2a},
+		},
+		{
+			name:   "glue without payload",
+			format: registry.GlueFormat(),
+			data:   append([]byte{0x3, 0x0}, testGUID[:]...),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := test.format.Extract(test.data, nil)
+
+			assert.Equal(t, err == nil, test.format.Detect(test.data, nil))
+		})
+	}
+}
+
+func TestGlueFormat_ExtractDecompresses(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zlib.NewWriter(buf)
+	_, err := zw.Write([]byte{0x80, 0x2})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	data := append(append([]byte{0x3, 0x5}, testGUID[:]...), buf.Bytes()...)
+
+	id, payload, err := registry.GlueFormat().Extract(data, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, registry.SchemaID{GUID: testGUID}, id)
+	assert.Equal(t, []byte{0x80, 0x2}, payload)
+}
+
+func TestDecoder_DecodeMixedWireFormats(t *testing.T) {
+	resolver := registry.SchemaResolverFunc(func(_ context.Context, id registry.SchemaID) (avro.Schema, error) {
+		switch id {
+		case registry.SchemaID{ID: 42}:
+			return avro.MustParse(`"long"`), nil
+		case registry.SchemaID{GUID: testGUID}:
+			return avro.MustParse(`"long"`), nil
+		}
+		return nil, errors.New("not found")
+	})
+	decoder := registry.NewDecoder(nil,
+		registry.WithSchemaResolver(resolver),
+		registry.WithWireFormats(
+			registry.HeaderFormat("__value_schema_id"),
+			registry.GlueFormat(),
+			registry.ConfluentFormat(),
+		),
+	)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		headers map[string][]byte
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "confluent",
+			data:    []byte{0x0, 0x0, 0x0, 0x0, 0x2a, 0x80, 0x2},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "glue",
+			data:    append(append([]byte{0x3, 0x0}, testGUID[:]...), 0x80, 0x2),
+			wantErr: require.NoError,
+		},
+		{
+			name:    "header",
+			data:    []byte{0x80, 0x2},
+			headers: map[string][]byte{"__value_schema_id": {0x0, 0x0, 0x0, 0x0, 0x2a}},
+			wantErr: require.NoError,
+		},
+		{
+			name:    "unknown format",
+			data:    []byte{0x80, 0x2},
+			wantErr: require.Error,
+		},
+		{
+			name:    "unknown schema",
+			data:    []byte{0x0, 0x0, 0x0, 0x0, 0x2b, 0x80, 0x2},
+			wantErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got int64
+			err := decoder.DecodeWithHeaders(context.Background(), test.data, test.headers, &got)
+
+			test.wantErr(t, err)
+			if err == nil {
+				assert.Equal(t, int64(128), got)
+			}
+		})
+	}
+}
+
+func TestSerializer_SerializeWithHeaderFormat(t *testing.T) {
+	srv := registrytest.NewServer()
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	format := registry.HeaderFormat("__value_schema_id")
+
+	serializer, err := registry.NewSerializer(client, avro.MustParse(`"string"`),
+		registry.WithAutoRegister(),
+		registry.WithSerializerWireFormat(format),
+	)
+	require.NoError(t, err)
+	headers := map[string][]byte{}
+	b, err := serializer.AppendWithHeaders(context.Background(), nil, headers, "orders", "hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{0x0, 0x0, 0x0, 0x0, 0x1}, headers["__value_schema_id"])
+	var got string
+	err = registry.NewDecoder(client, registry.WithWireFormats(format)).
+		DecodeWithHeaders(context.Background(), b, headers, &got)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+
+	_, err = serializer.Serialize(context.Background(), "orders", "hello")
+	assert.Error(t, err)
+}
@@ -137,6 +137,22 @@ func (c *SchemaCache) Get(name string) Schema {
 	return nil
 }
 
+// Remove removes the schema with the given name from the cache.
+func (c *SchemaCache) Remove(name string) {
+	c.cache.Delete(name)
+}
+
+// Names returns the sorted names of all schemas in the cache.
+func (c *SchemaCache) Names() []string {
+	var names []string
+	c.cache.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
 // AddAll adds all schemas from the given cache to the current cache.
 func (c *SchemaCache) AddAll(cache *SchemaCache) {
 	if cache == nil {
@@ -430,6 +446,8 @@ type schemaConfig struct {
 	order   Order
 	props   map[string]any
 	wfp     *[32]byte
+
+	unionDefaults UnionDefaultMode
 }
 
 // SchemaOption is a function that sets a schema option.
@@ -736,6 +754,10 @@ type Field struct {
 	def     any
 	order   Order
 
+	// encDef is the default in the form it is encoded from, where union values
+	// are keyed by the name of the type they match.
+	encDef any
+
 	// action mainly used when decoding data that lack the field for schema evolution purposes.
 	action Action
 	// encodedDef mainly used when decoding data that lack the field for schema evolution purposes.
@@ -782,11 +804,12 @@ func NewField(name string, typ Schema, opts ...SchemaOption) (*Field, error) {
 	}
 
 	if cfg.def != NoDefault {
-		def, err := validateDefault(name, typ, cfg.def)
+		def, encDef, err := validateDefault(name, typ, cfg.def, cfg.unionDefaults)
 		if err != nil {
 			return nil, err
 		}
 		f.def = def
+		f.encDef = encDef
 		f.hasDef = true
 	}
 
@@ -834,7 +857,11 @@ func (f *Field) encodeDefault(encode func(any) ([]byte, error)) ([]byte, error)
 	if encode == nil {
 		return nil, fmt.Errorf("avro: failed to encode '%s' default value", f.name)
 	}
-	b, err := encode(f.Default())
+	def := f.encDef
+	if def == nullDefault {
+		def = nil
+	}
+	b, err := encode(def)
 	if err != nil {
 		return nil, err
 	}
@@ -1647,146 +1674,6 @@ func validateName(name string) error {
 	return nil
 }
 
-func validateDefault(name string, schema Schema, def any) (any, error) {
-	def, ok := isValidDefault(schema, def)
-	if !ok {
-		return nil, fmt.Errorf("avro: invalid default for field %s. %+v not a %s", name, def, schema.Type())
-	}
-	return def, nil
-}
-
-func isValidDefault(schema Schema, def any) (any, bool) {
-	switch schema.Type() {
-	case Ref:
-		ref := schema.(*RefSchema)
-		return isValidDefault(ref.Schema(), def)
-	case Null:
-		return nullDefault, def == nil
-	case Enum:
-		v, ok := def.(string)
-		if !ok || len(v) == 0 {
-			return def, false
-		}
-
-		var found bool
-		for _, sym := range schema.(*EnumSchema).symbols {
-			if def == sym {
-				found = true
-				break
-			}
-		}
-		return def, found
-	case String:
-		if _, ok := def.(string); ok {
-			return def, true
-		}
-	case Bytes, Fixed:
-		// Spec: Default values for bytes and fixed fields are JSON strings,
-		// where Unicode code points 0-255 are mapped to unsigned 8-bit byte values 0-255.
-		if d, ok := def.(string); ok {
-			if b, ok := isValidDefaultBytes(d); ok {
-				if schema.Type() == Fixed {
-					return byteSliceToArray(b, schema.(*FixedSchema).Size()), true
-				}
-				return b, true
-			}
-		}
-	case Boolean:
-		if _, ok := def.(bool); ok {
-			return def, true
-		}
-	case Int:
-		if i, ok := def.(int8); ok {
-			return int(i), true
-		}
-		if i, ok := def.(int16); ok {
-			return int(i), true
-		}
-		if i, ok := def.(int32); ok {
-			return int(i), true
-		}
-		if _, ok := def.(int); ok {
-			return def, true
-		}
-		if f, ok := def.(float64); ok {
-			return int(f), true
-		}
-	case Long:
-		if _, ok := def.(int64); ok {
-			return def, true
-		}
-		if f, ok := def.(float64); ok {
-			return int64(f), true
-		}
-	case Float:
-		if _, ok := def.(float32); ok {
-			return def, true
-		}
-		if f, ok := def.(float64); ok {
-			return float32(f), true
-		}
-	case Double:
-		if _, ok := def.(float64); ok {
-			return def, true
-		}
-	case Array:
-		arr, ok := def.([]any)
-		if !ok {
-			return nil, false
-		}
-
-		as := schema.(*ArraySchema)
-		for i, v := range arr {
-			v, ok := isValidDefault(as.Items(), v)
-			if !ok {
-				return nil, false
-			}
-			arr[i] = v
-		}
-		return arr, true
-	case Map:
-		m, ok := def.(map[string]any)
-		if !ok {
-			return nil, false
-		}
-
-		ms := schema.(*MapSchema)
-		for k, v := range m {
-			v, ok := isValidDefault(ms.Values(), v)
-			if !ok {
-				return nil, false
-			}
-
-			m[k] = v
-		}
-		return m, true
-	case Union:
-		unionSchema := schema.(*UnionSchema)
-		return isValidDefault(unionSchema.Types()[0], def)
-	case Record:
-		m, ok := def.(map[string]any)
-		if !ok {
-			return nil, false
-		}
-
-		for _, field := range schema.(*RecordSchema).Fields() {
-			fieldDef := field.Default()
-			if newDef, ok := m[field.Name()]; ok {
-				fieldDef = newDef
-			}
-
-			v, ok := isValidDefault(field.Type(), fieldDef)
-			if !ok {
-				return nil, false
-			}
-
-			m[field.Name()] = v
-		}
-		return m, true
-	}
-	return nil, false
-}
-
 func schemaTypeName(schema Schema) string {
 	if schema.Type() == Ref {
 		schema = schema.(*RefSchema).Schema()
@@ -1802,16 +1689,3 @@ func schemaTypeName(schema Schema) string {
 	}
 	return sname
 }
-
-func isValidDefaultBytes(def string) ([]byte, bool) {
-	runes := []rune(def)
-	l := len(runes)
-	b := make([]byte, l)
-	for i := range l {
-		if runes[i] < 0 || runes[i] > 255 {
-			return nil, false
-		}
-		b[i] = byte(runes[i])
-	}
-	return b, true
-}
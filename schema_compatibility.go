@@ -425,6 +425,7 @@ func (c *SchemaCompatibility) resolveRecord(reader, writer Schema) (Schema, bool
 			// The field was not found in the reader schema, it should be ignored.
 			f, _ := NewField(wf.Name(), wf.Type(), WithAliases(wf.aliases), WithOrder(wf.order))
 			f.def = wf.def
+			f.encDef = wf.encDef
 			f.hasDef = wf.hasDef
 			f.action = FieldIgnore
 			fields = append(fields, f)
@@ -439,6 +440,7 @@ func (c *SchemaCompatibility) resolveRecord(reader, writer Schema) (Schema, bool
 		}
 		f, _ := NewField(rf.Name(), ft, WithAliases(rf.aliases), WithOrder(rf.order))
 		f.def = rf.def
+		f.encDef = rf.encDef
 		f.hasDef = rf.hasDef
 		fields = append(fields, f)
 		resolved = resolv || resolved
@@ -457,6 +459,7 @@ func (c *SchemaCompatibility) resolveRecord(reader, writer Schema) (Schema, bool
 
 		f, _ := NewField(rf.Name(), rf.Type(), WithAliases(rf.aliases), WithOrder(rf.order))
 		f.def = rf.def
+		f.encDef = rf.encDef
 		f.hasDef = rf.hasDef
 		f.action = FieldSetDefault
 		fields = append(fields, f)
@@ -0,0 +1,288 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// UnionDefaultMode determines which types of a union a field default may match.
+type UnionDefaultMode int
+
+// Union default modes.
+const (
+	// UnionDefaultFirstType requires the default of a union to match the first type
+	// of the union, as the Avro 1.11 specification and the Java implementation do.
+	UnionDefaultFirstType UnionDefaultMode = iota
+	// UnionDefaultAnyType allows the default of a union to match any type of the
+	// union, as the Avro 1.12 specification does. The first matching type is used.
+	UnionDefaultAnyType
+)
+
+// WithUnionDefaultMode sets how the default of a field is validated against unions.
+//
+// By default UnionDefaultFirstType is used.
+func WithUnionDefaultMode(mode UnionDefaultMode) SchemaOption {
+	return func(opts *schemaConfig) {
+		opts.unionDefaults = mode
+	}
+}
+
+// validateDefault validates the default of the named field, returning the default
+// converted to its Go form and the form it is encoded from.
+//
+// All violations are reported, each with the path of the offending value.
+func validateDefault(name string, schema Schema, def any, mode UnionDefaultMode) (val, enc any, err error) {
+	v := &defaultValidator{unions: mode}
+	val, enc, ok := v.validate(name, schema, def)
+	if !ok {
+		return nil, nil, errors.Join(v.errs...)
+	}
+	return val, enc, nil
+}
+
+type defaultValidator struct {
+	unions UnionDefaultMode
+	errs   []error
+}
+
+func (v *defaultValidator) errorf(path, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("avro: invalid default for field %s. %s", path, fmt.Sprintf(format, args...)))
+}
+
+// validate validates def against the schema, returning the Go form of the
+// default and its encoded form, in which union values are keyed by type name.
+func (v *defaultValidator) validate(path string, schema Schema, def any) (val, enc any, ok bool) {
+	switch schema.Type() {
+	case Ref:
+		return v.validate(path, schema.(*RefSchema).Schema(), def)
+	case Null:
+		if def == nil || def == nullDefault {
+			return nullDefault, nullDefault, true
+		}
+	case Boolean:
+		if _, ok = def.(bool); ok {
+			return def, def, true
+		}
+	case Int:
+		if i, ok := defaultInteger(def, math.MinInt32, math.MaxInt32); ok {
+			return int(i), int(i), true
+		}
+	case Long:
+		if i, ok := defaultInteger(def, math.MinInt64, math.MaxInt64); ok {
+			return i, i, true
+		}
+	case Float:
+		switch d := def.(type) {
+		case float32:
+			return d, d, true
+		case float64:
+			return float32(d), float32(d), true
+		}
+	case Double:
+		if _, ok = def.(float64); ok {
+			return def, def, true
+		}
+	case String:
+		if _, ok = def.(string); ok {
+			return def, def, true
+		}
+	case Enum:
+		s, ok := def.(string)
+		if !ok {
+			break
+		}
+		if !slices.Contains(schema.(*EnumSchema).Symbols(), s) {
+			v.errorf(path, "%q is not a symbol of enum %s", s, schema.(*EnumSchema).FullName())
+			return nil, nil, false
+		}
+		return def, def, true
+	case Bytes, Fixed:
+		return v.validateBytes(path, schema, def)
+	case Array:
+		return v.validateArray(path, schema.(*ArraySchema), def)
+	case Map:
+		return v.validateMap(path, schema.(*MapSchema), def)
+	case Record:
+		return v.validateRecord(path, schema.(*RecordSchema), def)
+	case Union:
+		return v.validateUnion(path, schema.(*UnionSchema), def)
+	}
+
+	v.errorf(path, "%+v not a %s", def, schema.Type())
+	return nil, nil, false
+}
+
+func (v *defaultValidator) validateBytes(path string, schema Schema, def any) (val, enc any, ok bool) {
+	var b []byte
+	switch d := def.(type) {
+	case []byte:
+		b = d
+	case string:
+		// Spec: Default values for bytes and fixed fields are JSON strings,
+		// where Unicode code points 0-255 are mapped to unsigned 8-bit byte values 0-255.
+		b = make([]byte, 0, len(d))
+		for i, r := range []rune(d) {
+			if r > 255 {
+				v.errorf(path, "code point %U at position %d is out of the byte range 0-255", r, i)
+				return nil, nil, false
+			}
+			b = append(b, byte(r))
+		}
+	default:
+		v.errorf(path, "%+v not a %s", def, schema.Type())
+		return nil, nil, false
+	}
+
+	fixed, ok := schema.(*FixedSchema)
+	if !ok {
+		return b, b, true
+	}
+	if len(b) != fixed.Size() {
+		v.errorf(path, "%d bytes not a fixed of size %d", len(b), fixed.Size())
+		return nil, nil, false
+	}
+	arr := byteSliceToArray(b, fixed.Size())
+	return arr, arr, true
+}
+
+func (v *defaultValidator) validateArray(path string, schema *ArraySchema, def any) (val, enc any, ok bool) {
+	arr, ok := def.([]any)
+	if !ok {
+		v.errorf(path, "%+v not a %s", def, Array)
+		return nil, nil, false
+	}
+
+	vals := make([]any, len(arr))
+	encs := make([]any, len(arr))
+	ok = true
+	for i, item := range arr {
+		var itemOK bool
+		vals[i], encs[i], itemOK = v.validate(fmt.Sprintf("%s[%d]", path, i), schema.Items(), item)
+		ok = ok && itemOK
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	return vals, encs, true
+}
+
+func (v *defaultValidator) validateMap(path string, schema *MapSchema, def any) (val, enc any, ok bool) {
+	m, ok := def.(map[string]any)
+	if !ok {
+		v.errorf(path, "%+v not a %s", def, Map)
+		return nil, nil, false
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	vals := make(map[string]any, len(m))
+	encs := make(map[string]any, len(m))
+	ok = true
+	for _, k := range keys {
+		var itemOK bool
+		vals[k], encs[k], itemOK = v.validate(fmt.Sprintf("%s[%q]", path, k), schema.Values(), m[k])
+		ok = ok && itemOK
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	return vals, encs, true
+}
+
+func (v *defaultValidator) validateRecord(path string, schema *RecordSchema, def any) (val, enc any, ok bool) {
+	m, ok := def.(map[string]any)
+	if !ok {
+		v.errorf(path, "%+v not a %s", def, schema.Type())
+		return nil, nil, false
+	}
+
+	vals := make(map[string]any, len(schema.Fields()))
+	encs := make(map[string]any, len(schema.Fields()))
+	ok = true
+	for _, field := range schema.Fields() {
+		fieldPath := path + "." + field.Name()
+
+		fieldDef, found := m[field.Name()]
+		if !found {
+			if !field.HasDefault() {
+				v.errorf(fieldPath, "value is required as the field has no default")
+				ok = false
+				continue
+			}
+
+			// The field default was validated when the field was created.
+			vals[field.Name()], encs[field.Name()] = field.def, field.encDef
+			continue
+		}
+
+		var fieldOK bool
+		vals[field.Name()], encs[field.Name()], fieldOK = v.validate(fieldPath, field.Type(), fieldDef)
+		ok = ok && fieldOK
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	return vals, encs, true
+}
+
+func (v *defaultValidator) validateUnion(path string, schema *UnionSchema, def any) (val, enc any, ok bool) {
+	types := schema.Types()
+	if v.unions == UnionDefaultFirstType {
+		val, enc, ok = v.validate(path, types[0], def)
+		if !ok {
+			return nil, nil, false
+		}
+		return val, unionDefault(types[0], enc), true
+	}
+
+	for _, typ := range types {
+		branch := &defaultValidator{unions: v.unions}
+		if val, enc, ok = branch.validate(path, typ, def); ok {
+			return val, unionDefault(typ, enc), true
+		}
+	}
+
+	v.errorf(path, "%+v matches no type of union %s", def, schema)
+	return nil, nil, false
+}
+
+// unionDefault returns the encoded form of a union default, keyed by the name of
+// the type it matched.
+func unionDefault(typ Schema, enc any) map[string]any {
+	if typ.Type() == Null {
+		enc = nil
+	}
+	return map[string]any{schemaTypeName(typ): enc}
+}
+
+// defaultInteger returns the integer value of a default within the given range.
+func defaultInteger(def any, minVal, maxVal int64) (int64, bool) {
+	var i int64
+	switch d := def.(type) {
+	case int:
+		i = int64(d)
+	case int8:
+		i = int64(d)
+	case int16:
+		i = int64(d)
+	case int32:
+		i = int64(d)
+	case int64:
+		i = d
+	case float64:
+		// JSON numbers are decoded as float64, they must be whole numbers.
+		if d != math.Trunc(d) || d < float64(minVal) || d >= -float64(minVal) {
+			return 0, false
+		}
+		i = int64(d)
+	default:
+		return 0, false
+	}
+	return i, i >= minVal && i <= maxVal
+}
@@ -0,0 +1,456 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type formatConfig struct {
+	noDocs     bool
+	noAliases  bool
+	noDefaults bool
+	noProps    bool
+	inline     bool
+	refs       map[string]struct{}
+	namespaces bool
+	sortKeys   bool
+	prefix     string
+	indent     string
+}
+
+// FormatOption is a function that sets a schema format option.
+type FormatOption func(*formatConfig)
+
+// WithoutDocs strips the docs of records, fields and enums.
+func WithoutDocs() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.noDocs = true
+	}
+}
+
+// WithoutAliases strips the aliases of named schemas and fields.
+func WithoutAliases() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.noAliases = true
+	}
+}
+
+// WithoutDefaults strips the defaults of fields and enums.
+func WithoutDefaults() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.noDefaults = true
+	}
+}
+
+// WithoutProps strips custom properties. Logical types are kept.
+func WithoutProps() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.noProps = true
+	}
+}
+
+// WithInlineNamedTypes writes the full definition of a named schema every time it is used,
+// instead of referencing it by name after its first definition.
+//
+// Recursive references are always written by name. As named schemas may only be
+// defined once, the output is meant for display and comparison rather than parsing.
+func WithInlineNamedTypes() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.inline = true
+	}
+}
+
+// WithNamedTypeRefs writes the given named schemas as references only, without
+// their definition. This is useful when the named schemas are defined elsewhere.
+func WithNamedTypeRefs(names ...string) FormatOption {
+	return func(cfg *formatConfig) {
+		if cfg.refs == nil {
+			cfg.refs = map[string]struct{}{}
+		}
+		for _, n := range names {
+			cfg.refs[n] = struct{}{}
+		}
+	}
+}
+
+// WithNamespaces writes named schemas with a short name and a namespace, and
+// references names relative to the enclosing namespace.
+//
+// By default all names are written fully qualified.
+func WithNamespaces() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.namespaces = true
+	}
+}
+
+// WithSortedKeys sorts the keys of every JSON object alphabetically.
+//
+// By default keys are written in the order they appear in the specification,
+// followed by the sorted custom properties.
+func WithSortedKeys() FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.sortKeys = true
+	}
+}
+
+// WithIndent indents the JSON output, as json.Indent does.
+func WithIndent(prefix, indent string) FormatOption {
+	return func(cfg *formatConfig) {
+		cfg.prefix = prefix
+		cfg.indent = indent
+	}
+}
+
+// Format returns the JSON form of the schema, shaped by the given options.
+//
+// Without options, the output matches the schema's MarshalJSON, except that bytes
+// and fixed defaults are written as strings, as the specification requires.
+func Format(schema Schema, opts ...FormatOption) ([]byte, error) {
+	var cfg formatConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	f := &formatter{
+		cfg:      cfg,
+		seen:     map[string]struct{}{},
+		defining: map[string]struct{}{},
+	}
+	v := f.schema(schema, "")
+
+	buf := new(bytes.Buffer)
+	if err := f.write(buf, v); err != nil {
+		return nil, err
+	}
+	if cfg.prefix == "" && cfg.indent == "" {
+		return buf.Bytes(), nil
+	}
+
+	out := new(bytes.Buffer)
+	if err := json.Indent(out, buf.Bytes(), cfg.prefix, cfg.indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Normalize returns the normalized JSON form of the schema.
+//
+// Unlike the Parsing Canonical Form, all attributes are kept. All names are fully
+// qualified without namespace attributes, named schemas are defined on first use
+// and referenced afterwards, and object keys are sorted.
+func Normalize(schema Schema) ([]byte, error) {
+	return Format(schema, WithSortedKeys())
+}
+
+type jsonMember struct {
+	key string
+	val any
+}
+
+// jsonObject is a JSON object that keeps the order of its members.
+type jsonObject []jsonMember
+
+func (o *jsonObject) add(key string, val any) {
+	*o = append(*o, jsonMember{key: key, val: val})
+}
+
+type formatter struct {
+	cfg formatConfig
+
+	seen     map[string]struct{}
+	defining map[string]struct{}
+}
+
+func (f *formatter) schema(schema Schema, namespace string) any {
+	switch s := schema.(type) {
+	case *RefSchema:
+		return f.named(s.Schema(), namespace)
+	case NamedSchema:
+		return f.named(s, namespace)
+	case *PrimitiveSchema:
+		return f.primitive(s)
+	case *NullSchema:
+		if len(s.props) == 0 || f.cfg.noProps {
+			return string(Null)
+		}
+		obj := jsonObject{{key: "type", val: string(Null)}}
+		f.props(&obj, s.properties)
+		return obj
+	case *ArraySchema:
+		obj := jsonObject{{key: "type", val: string(Array)}}
+		obj.add("items", f.schema(s.Items(), namespace))
+		f.props(&obj, s.properties)
+		return obj
+	case *MapSchema:
+		obj := jsonObject{{key: "type", val: string(Map)}}
+		obj.add("values", f.schema(s.Values(), namespace))
+		f.props(&obj, s.properties)
+		return obj
+	case *UnionSchema:
+		types := make([]any, len(s.Types()))
+		for i, typ := range s.Types() {
+			types[i] = f.schema(typ, namespace)
+		}
+		return types
+	}
+	return string(schema.Type())
+}
+
+func (f *formatter) primitive(s *PrimitiveSchema) any {
+	if s.logical == nil && (len(s.props) == 0 || f.cfg.noProps) {
+		return string(s.typ)
+	}
+
+	obj := jsonObject{{key: "type", val: string(s.typ)}}
+	f.logical(&obj, s.logical)
+	f.props(&obj, s.properties)
+	return obj
+}
+
+func (f *formatter) logical(obj *jsonObject, logical LogicalSchema) {
+	if logical == nil {
+		return
+	}
+	obj.add("logicalType", string(logical.Type()))
+	if d, ok := logical.(*DecimalLogicalSchema); ok {
+		obj.add("precision", d.prec)
+		if d.scale > 0 {
+			obj.add("scale", d.scale)
+		}
+	}
+}
+
+func (f *formatter) named(s NamedSchema, namespace string) any {
+	full := s.FullName()
+	_, isRef := f.cfg.refs[full]
+	_, isDefining := f.defining[full]
+	_, isSeen := f.seen[full]
+	if isRef || isDefining || (isSeen && !f.cfg.inline) {
+		return f.refName(s, namespace)
+	}
+	f.seen[full] = struct{}{}
+	f.defining[full] = struct{}{}
+	defer delete(f.defining, full)
+
+	var obj jsonObject
+	if f.cfg.namespaces {
+		obj.add("name", s.Name())
+		if s.Namespace() != namespace {
+			obj.add("namespace", s.Namespace())
+		}
+	} else {
+		obj.add("name", full)
+	}
+	if !f.cfg.noAliases && len(s.Aliases()) > 0 {
+		obj.add("aliases", f.aliases(s.Aliases(), s.Namespace()))
+	}
+
+	switch v := s.(type) {
+	case *RecordSchema:
+		f.doc(&obj, v.doc)
+		typ := Record
+		if v.isError {
+			typ = Error
+		}
+		obj.add("type", string(typ))
+		fields := make([]any, len(v.fields))
+		for i, field := range v.fields {
+			fields[i] = f.field(field, v.Namespace())
+		}
+		obj.add("fields", fields)
+		f.props(&obj, v.properties)
+	case *EnumSchema:
+		f.doc(&obj, v.doc)
+		obj.add("type", string(Enum))
+		obj.add("symbols", v.symbols)
+		if !f.cfg.noDefaults && v.def != "" {
+			obj.add("default", v.def)
+		}
+		f.props(&obj, v.properties)
+	case *FixedSchema:
+		obj.add("type", string(Fixed))
+		obj.add("size", v.size)
+		f.logical(&obj, v.logical)
+		f.props(&obj, v.properties)
+	}
+	return obj
+}
+
+func (f *formatter) refName(s NamedSchema, namespace string) string {
+	if f.cfg.namespaces && s.Namespace() == namespace {
+		return s.Name()
+	}
+	return s.FullName()
+}
+
+func (f *formatter) aliases(aliases []string, namespace string) []string {
+	if !f.cfg.namespaces || namespace == "" {
+		return aliases
+	}
+
+	out := make([]string, len(aliases))
+	for i, alias := range aliases {
+		out[i] = strings.TrimPrefix(alias, namespace+".")
+		if strings.ContainsRune(out[i], '.') {
+			out[i] = alias
+		}
+	}
+	return out
+}
+
+func (f *formatter) field(field *Field, namespace string) any {
+	obj := jsonObject{{key: "name", val: field.name}}
+	if !f.cfg.noAliases && len(field.aliases) > 0 {
+		obj.add("aliases", field.aliases)
+	}
+	f.doc(&obj, field.doc)
+	obj.add("type", f.schema(field.typ, namespace))
+	if !f.cfg.noDefaults && field.hasDef {
+		obj.add("default", formatDefault(field.typ, field.encDef))
+	}
+	if field.order != "" && field.order != Asc {
+		obj.add("order", string(field.order))
+	}
+	f.props(&obj, field.properties)
+	return obj
+}
+
+func (f *formatter) doc(obj *jsonObject, doc string) {
+	if f.cfg.noDocs || doc == "" {
+		return
+	}
+	obj.add("doc", doc)
+}
+
+func (f *formatter) props(obj *jsonObject, p properties) {
+	if f.cfg.noProps || len(p.props) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(p.props))
+	for k := range p.props {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		obj.add(k, p.props[k])
+	}
+}
+
+func (f *formatter) write(buf *bytes.Buffer, v any) error {
+	switch val := v.(type) {
+	case jsonObject:
+		if f.cfg.sortKeys {
+			val = slices.Clone(val)
+			slices.SortStableFunc(val, func(a, b jsonMember) int {
+				return strings.Compare(a.key, b.key)
+			})
+		}
+		buf.WriteByte('{')
+		for i, m := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, err := jsoniterAPI.Marshal(m.key)
+			if err != nil {
+				return err
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err = f.write(buf, m.val); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := f.write(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := jsoniterAPI.Marshal(val)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// formatDefault converts the encoded form of a validated default back to its JSON form,
+// where bytes and fixed values are strings of code points 0-255.
+func formatDefault(schema Schema, def any) any {
+	switch schema.Type() {
+	case Ref:
+		return formatDefault(schema.(*RefSchema).Schema(), def)
+	case Bytes, Fixed:
+		v := reflect.ValueOf(def)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return def
+		}
+		runes := make([]rune, v.Len())
+		for i := range runes {
+			runes[i] = rune(v.Index(i).Uint())
+		}
+		return string(runes)
+	case Union:
+		m, ok := def.(map[string]any)
+		if !ok {
+			return def
+		}
+		for name, v := range m {
+			if typ, _ := schema.(*UnionSchema).Types().Get(name); typ != nil {
+				return formatDefault(typ, v)
+			}
+		}
+		return nil
+	case Array:
+		arr, ok := def.([]any)
+		if !ok {
+			return def
+		}
+		out := make([]any, len(arr))
+		for i, v := range arr {
+			out[i] = formatDefault(schema.(*ArraySchema).Items(), v)
+		}
+		return out
+	case Map:
+		m, ok := def.(map[string]any)
+		if !ok {
+			return def
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = formatDefault(schema.(*MapSchema).Values(), v)
+		}
+		return out
+	case Record:
+		m, ok := def.(map[string]any)
+		if !ok {
+			return def
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = v
+		}
+		for _, field := range schema.(*RecordSchema).Fields() {
+			if v, ok := m[field.Name()]; ok {
+				out[field.Name()] = formatDefault(field.Type(), v)
+			}
+		}
+		return out
+	}
+	if def == nullDefault {
+		return nil
+	}
+	return def
+}
@@ -0,0 +1,194 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const formatTestSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "org.shop",
+	"doc": "An order.",
+	"aliases": ["Purchase"],
+	"owner": "sales",
+	"fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}, "doc": "The order id."},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 2}, "default": "ÿ\u0001"},
+		{"name": "previous", "type": ["null", "Hash"], "default": null},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "namespace": "org.common", "symbols": ["NEW", "DONE"], "default": "NEW"}, "aliases": ["state"]},
+		{"name": "history", "type": {"type": "array", "items": "org.common.Status"}, "order": "ignore", "x-pii": true},
+		{"name": "parent", "type": ["null", "Order"], "default": null}
+	]
+}`
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []avro.FormatOption
+		want string
+		// duplicates is set when named schemas are defined more than once, which cannot be parsed.
+		duplicates bool
+	}{
+		{
+			name: "default",
+			want: `{"name":"org.shop.Order","aliases":["org.shop.Purchase"],"doc":"An order.","type":"record","fields":[` +
+				`{"name":"id","doc":"The order id.","type":{"type":"string","logicalType":"uuid"}},` +
+				`{"name":"hash","type":{"name":"org.shop.Hash","type":"fixed","size":2},"default":"ÿ\u0001"},` +
+				`{"name":"previous","type":["null","org.shop.Hash"],"default":null},` +
+				`{"name":"status","aliases":["state"],"type":{"name":"org.common.Status","type":"enum","symbols":["NEW","DONE"],"default":"NEW"}},` +
+				`{"name":"history","type":{"type":"array","items":"org.common.Status"},"order":"ignore","x-pii":true},` +
+				`{"name":"parent","type":["null","org.shop.Order"],"default":null}` +
+				`],"owner":"sales"}`,
+		},
+		{
+			name: "stripped",
+			opts: []avro.FormatOption{avro.WithoutDocs(), avro.WithoutAliases(), avro.WithoutDefaults(), avro.WithoutProps()},
+			want: `{"name":"org.shop.Order","type":"record","fields":[` +
+				`{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+				`{"name":"hash","type":{"name":"org.shop.Hash","type":"fixed","size":2}},` +
+				`{"name":"previous","type":["null","org.shop.Hash"]},` +
+				`{"name":"status","type":{"name":"org.common.Status","type":"enum","symbols":["NEW","DONE"]}},` +
+				`{"name":"history","type":{"type":"array","items":"org.common.Status"},"order":"ignore"},` +
+				`{"name":"parent","type":["null","org.shop.Order"]}` +
+				`]}`,
+		},
+		{
+			name: "inline named types",
+			opts: []avro.FormatOption{avro.WithoutDocs(), avro.WithoutAliases(), avro.WithoutDefaults(), avro.WithoutProps(), avro.WithInlineNamedTypes()},
+			want: `{"name":"org.shop.Order","type":"record","fields":[` +
+				`{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+				`{"name":"hash","type":{"name":"org.shop.Hash","type":"fixed","size":2}},` +
+				`{"name":"previous","type":["null",{"name":"org.shop.Hash","type":"fixed","size":2}]},` +
+				`{"name":"status","type":{"name":"org.common.Status","type":"enum","symbols":["NEW","DONE"]}},` +
+				`{"name":"history","type":{"type":"array","items":{"name":"org.common.Status","type":"enum","symbols":["NEW","DONE"]}},"order":"ignore"},` +
+				`{"name":"parent","type":["null","org.shop.Order"]}` +
+				`]}`,
+			duplicates: true,
+		},
+		{
+			name: "named type refs",
+			opts: []avro.FormatOption{avro.WithoutDocs(), avro.WithoutAliases(), avro.WithoutDefaults(), avro.WithoutProps(), avro.WithNamedTypeRefs("org.common.Status")},
+			want: `{"name":"org.shop.Order","type":"record","fields":[` +
+				`{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+				`{"name":"hash","type":{"name":"org.shop.Hash","type":"fixed","size":2}},` +
+				`{"name":"previous","type":["null","org.shop.Hash"]},` +
+				`{"name":"status","type":"org.common.Status"},` +
+				`{"name":"history","type":{"type":"array","items":"org.common.Status"},"order":"ignore"},` +
+				`{"name":"parent","type":["null","org.shop.Order"]}` +
+				`]}`,
+		},
+		{
+			name: "namespaces",
+			opts: []avro.FormatOption{avro.WithoutDocs(), avro.WithoutDefaults(), avro.WithoutProps(), avro.WithNamespaces()},
+			want: `{"name":"Order","namespace":"org.shop","aliases":["Purchase"],"type":"record","fields":[` +
+				`{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+				`{"name":"hash","type":{"name":"Hash","type":"fixed","size":2}},` +
+				`{"name":"previous","type":["null","Hash"]},` +
+				`{"name":"status","aliases":["state"],"type":{"name":"Status","namespace":"org.common","type":"enum","symbols":["NEW","DONE"]}},` +
+				`{"name":"history","type":{"type":"array","items":"org.common.Status"},"order":"ignore"},` +
+				`{"name":"parent","type":["null","Order"]}` +
+				`]}`,
+		},
+		{
+			name: "sorted keys",
+			opts: []avro.FormatOption{avro.WithoutDocs(), avro.WithoutAliases(), avro.WithoutDefaults(), avro.WithSortedKeys()},
+			want: `{"fields":[` +
+				`{"name":"id","type":{"logicalType":"uuid","type":"string"}},` +
+				`{"name":"hash","type":{"name":"org.shop.Hash","size":2,"type":"fixed"}},` +
+				`{"name":"previous","type":["null","org.shop.Hash"]},` +
+				`{"name":"status","type":{"name":"org.common.Status","symbols":["NEW","DONE"],"type":"enum"}},` +
+				`{"name":"history","order":"ignore","type":{"items":"org.common.Status","type":"array"},"x-pii":true},` +
+				`{"name":"parent","type":["null","org.shop.Order"]}` +
+				`],"name":"org.shop.Order","owner":"sales","type":"record"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := avro.ParseWithCache(formatTestSchema, "", &avro.SchemaCache{})
+			require.NoError(t, err)
+
+			got, err := avro.Format(schema, test.opts...)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, string(got))
+			if test.duplicates {
+				return
+			}
+
+			cache := &avro.SchemaCache{}
+			_, err = avro.ParseWithCache(`{"type":"enum","name":"Status","namespace":"org.common","symbols":["NEW","DONE"]}`, "", cache)
+			require.NoError(t, err)
+			roundTrip, err := avro.ParseWithCache(string(got), "", cache)
+			require.NoError(t, err)
+			assert.Equal(t, schema.Fingerprint(), roundTrip.Fingerprint())
+		})
+	}
+}
+
+func TestFormat_Indent(t *testing.T) {
+	schema := avro.MustParse(`{"type":"map","values":{"type":"array","items":"int"}}`)
+
+	got, err := avro.Format(schema, avro.WithIndent("", "  "))
+
+	require.NoError(t, err)
+	want := `{
+  "type": "map",
+  "values": {
+    "type": "array",
+    "items": "int"
+  }
+}`
+	assert.Equal(t, want, string(got))
+}
+
+func TestFormat_MatchesMarshalJSON(t *testing.T) {
+	schema, err := avro.ParseWithCache(formatTestSchema, "", &avro.SchemaCache{})
+	require.NoError(t, err)
+	// Bytes and fixed defaults are not round-tripped by MarshalJSON.
+	schema.(*avro.RecordSchema).Fields()[1] = mustNewField(t, "hash", schema.(*avro.RecordSchema).Fields()[1].Type())
+
+	want, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	got, err := avro.Format(schema)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestNormalize(t *testing.T) {
+	s1, err := avro.ParseWithCache(`{"type":"record","name":"A","namespace":"x","fields":[
+		{"name":"b","type":{"type":"record","name":"B","fields":[{"name":"c","type":"int"}]}},
+		{"name":"d","type":"x.B"}
+	]}`, "", &avro.SchemaCache{})
+	require.NoError(t, err)
+	s2, err := avro.ParseWithCache(`{"name":"x.A","type":"record","fields":[
+		{"type":{"name":"B","namespace":"x","fields":[{"type":"int","name":"c"}],"type":"record"},"name":"b"},
+		{"name":"d","type":"B"}
+	]}`, "", &avro.SchemaCache{})
+	require.NoError(t, err)
+
+	got1, err := avro.Normalize(s1)
+	require.NoError(t, err)
+	got2, err := avro.Normalize(s2)
+	require.NoError(t, err)
+
+	want := `{"fields":[{"name":"b","type":{"fields":[{"name":"c","type":"int"}],"name":"x.B","type":"record"}},` +
+		`{"name":"d","type":"x.B"}],"name":"x.A","type":"record"}`
+	assert.Equal(t, want, string(got1))
+	assert.Equal(t, want, string(got2))
+}
+
+func mustNewField(t *testing.T, name string, typ avro.Schema, opts ...avro.SchemaOption) *avro.Field {
+	t.Helper()
+
+	f, err := avro.NewField(name, typ, opts...)
+	require.NoError(t, err)
+	return f
+}
@@ -360,6 +360,48 @@ func TestIsValidDefault(t *testing.T) {
 			def:    "test",
 			wantOk: false,
 		},
+		{
+			name: "Int Fractional Float64",
+			schemaFn: func() Schema {
+				return NewPrimitiveSchema(Int, nil)
+			},
+			def:    1.5,
+			wantOk: false,
+		},
+		{
+			name: "Int Out Of Range",
+			schemaFn: func() Schema {
+				return NewPrimitiveSchema(Int, nil)
+			},
+			def:    float64(1 << 31),
+			wantOk: false,
+		},
+		{
+			name: "Long Int",
+			schemaFn: func() Schema {
+				return NewPrimitiveSchema(Long, nil)
+			},
+			def:    1,
+			want:   int64(1),
+			wantOk: true,
+		},
+		{
+			name: "Bytes Code Point Out Of Range",
+			schemaFn: func() Schema {
+				return NewPrimitiveSchema(Bytes, nil)
+			},
+			def:    "\u0100",
+			wantOk: false,
+		},
+		{
+			name: "Fixed Wrong Size",
+			schemaFn: func() Schema {
+				s, _ := NewFixedSchema("foo", "", 4, nil)
+				return s
+			},
+			def:    "tes",
+			wantOk: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -367,8 +409,9 @@ func TestIsValidDefault(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, ok := isValidDefault(test.schemaFn(), test.def)
+			got, _, err := validateDefault("test", test.schemaFn(), test.def, UnionDefaultFirstType)
 
+			ok := err == nil
 			assert.Equal(t, test.wantOk, ok)
 			if ok {
 				assert.Equal(t, test.want, got)
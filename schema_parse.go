@@ -70,6 +70,14 @@ func ParseBytes(schema []byte) (Schema, error) {
 
 // ParseBytesWithCache parses a schema byte slice using the given namespace and schema cache.
 func ParseBytesWithCache(schema []byte, namespace string, cache *SchemaCache) (Schema, error) {
+	return parseBytes(schema, namespace, cache, true, UnionDefaultFirstType)
+}
+
+// parseBytes parses a schema byte slice, resolving names from cache. When register
+// is true, the named schemas found in the schema are added to cache.
+func parseBytes(
+	schema []byte, namespace string, cache *SchemaCache, register bool, unionDefaults UnionDefaultMode,
+) (Schema, error) {
 	var json any
 	if err := jsoniter.Unmarshal(schema, &json); err != nil {
 		json = string(schema)
@@ -78,13 +86,15 @@ func ParseBytesWithCache(schema []byte, namespace string, cache *SchemaCache) (S
 	internalCache := &SchemaCache{}
 	internalCache.AddAll(cache)
 
-	seen := seenCache{}
+	seen := newSeenCache(unionDefaults)
 	s, err := parseType(namespace, json, seen, internalCache)
 	if err != nil {
 		return nil, err
 	}
 
-	cache.AddAll(internalCache)
+	if register {
+		cache.AddAll(internalCache)
+	}
 
 	return derefSchema(s), nil
 }
@@ -336,6 +346,7 @@ func parseField(namespace string, m map[string]any, seen seenCache, cache *Schem
 
 	field, err := NewField(f.Name, typ,
 		WithDefault(f.Default), WithAliases(f.Aliases), WithDoc(f.Doc), WithOrder(f.Order), WithProps(f.Props),
+		WithUnionDefaultMode(seen.unionDefaults),
 	)
 	if err != nil {
 		return nil, err
@@ -624,13 +635,25 @@ func derefSchema(schema Schema) Schema {
 	})
 }
 
-type seenCache map[string]struct{}
+// seenCache tracks the names defined while parsing, along with the parse options.
+type seenCache struct {
+	names map[string]struct{}
+
+	unionDefaults UnionDefaultMode
+}
+
+func newSeenCache(unionDefaults UnionDefaultMode) seenCache {
+	return seenCache{
+		names:         map[string]struct{}{},
+		unionDefaults: unionDefaults,
+	}
+}
 
 func (c seenCache) Add(name string) error {
-	if _, ok := c[name]; ok {
+	if _, ok := c.names[name]; ok {
 		return fmt.Errorf("duplicate name %q", name)
 	}
-	c[name] = struct{}{}
+	c.names[name] = struct{}{}
 	return nil
 }
 
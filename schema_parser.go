@@ -0,0 +1,153 @@
+package avro
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ParserOption is a function that sets a schema parser option.
+type ParserOption func(*SchemaParser)
+
+// WithParserCache sets the schema cache used by the parser.
+//
+// Names are resolved from and registered into this cache. By default each
+// parser has its own empty cache.
+func WithParserCache(cache *SchemaCache) ParserOption {
+	return func(p *SchemaParser) {
+		p.cache = cache
+	}
+}
+
+// WithParserNamespace sets the default namespace applied to names without a namespace.
+func WithParserNamespace(namespace string) ParserOption {
+	return func(p *SchemaParser) {
+		p.namespace = namespace
+	}
+}
+
+// WithoutRegistration stops the parser from adding the named schemas it parses to its cache.
+//
+// Names already in the cache can still be referenced. Combined with
+// WithParserCache(DefaultSchemaCache) this allows parsing against the global
+// cache without modifying it.
+func WithoutRegistration() ParserOption {
+	return func(p *SchemaParser) {
+		p.noRegister = true
+	}
+}
+
+// WithParserUnionDefaultMode sets how field defaults are validated against unions.
+//
+// By default UnionDefaultFirstType is used, as the Java implementation does.
+func WithParserUnionDefaultMode(mode UnionDefaultMode) ParserOption {
+	return func(p *SchemaParser) {
+		p.unionDefaults = mode
+	}
+}
+
+// SchemaParser parses schemas using its own schema cache, isolated from DefaultSchemaCache.
+//
+// A SchemaParser is safe for concurrent use, however the order in which concurrently
+// parsed schemas are registered is undefined.
+type SchemaParser struct {
+	cache         *SchemaCache
+	namespace     string
+	noRegister    bool
+	unionDefaults UnionDefaultMode
+}
+
+// NewSchemaParser creates a new schema parser.
+func NewSchemaParser(opts ...ParserOption) *SchemaParser {
+	p := &SchemaParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.cache == nil {
+		p.cache = &SchemaCache{}
+	}
+
+	return p
+}
+
+// Cache returns the schema cache of the parser.
+func (p *SchemaParser) Cache() *SchemaCache {
+	return p.cache
+}
+
+// Parse parses a schema string.
+func (p *SchemaParser) Parse(schema string) (Schema, error) {
+	return p.ParseBytes([]byte(schema))
+}
+
+// MustParse parses a schema string, panicking if there is an error.
+func (p *SchemaParser) MustParse(schema string) Schema {
+	parsed, err := p.Parse(schema)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+// ParseBytes parses a schema byte slice.
+func (p *SchemaParser) ParseBytes(schema []byte) (Schema, error) {
+	return parseBytes(schema, p.namespace, p.cache, !p.noRegister, p.unionDefaults)
+}
+
+// ParseFiles parses the schemas in the files, in the order they appear, returning the last schema.
+func (p *SchemaParser) ParseFiles(paths ...string) (Schema, error) {
+	var schema Schema
+	for _, path := range paths {
+		s, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err = p.ParseBytes(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+// ParseProtocol parses an Avro protocol, resolving names from the parser cache.
+//
+// The types declared by the protocol are not registered in the parser cache.
+func (p *SchemaParser) ParseProtocol(protocol string) (*Protocol, error) {
+	cache := &SchemaCache{}
+	cache.AddAll(p.cache)
+
+	return parseProtocolBytes([]byte(protocol), cache, p.unionDefaults)
+}
+
+// Evict removes the named schemas, and any aliases of them, from the parser cache.
+//
+// Schemas that were parsed while the names were registered keep their references.
+func (p *SchemaParser) Evict(names ...string) {
+	for _, n := range names {
+		schema := p.cache.Get(n)
+		if schema == nil {
+			continue
+		}
+		p.cache.Remove(n)
+
+		named := namedSchemaOf(schema)
+		if named == nil {
+			continue
+		}
+		p.cache.Remove(named.FullName())
+		for _, alias := range named.Aliases() {
+			p.cache.Remove(alias)
+		}
+	}
+}
+
+func namedSchemaOf(schema Schema) NamedSchema {
+	if ref, ok := schema.(*RefSchema); ok {
+		return ref.Schema()
+	}
+	named, _ := schema.(NamedSchema)
+	return named
+}
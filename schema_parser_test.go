@@ -0,0 +1,112 @@
+package avro_test
+
+import (
+	"testing"
+
+	"github.com/aryehlev/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaParser_IsolatesCache(t *testing.T) {
+	p1 := avro.NewSchemaParser()
+	p2 := avro.NewSchemaParser()
+
+	_, err := p1.Parse(`{"type":"record","name":"Tenant","namespace":"isolated","fields":[{"name":"a","type":"int"}]}`)
+	require.NoError(t, err)
+
+	_, err = p1.Parse(`{"type":"array","items":"isolated.Tenant"}`)
+	require.NoError(t, err)
+
+	_, err = p2.Parse(`{"type":"array","items":"isolated.Tenant"}`)
+	assert.Error(t, err)
+
+	assert.Nil(t, avro.DefaultSchemaCache.Get("isolated.Tenant"))
+}
+
+func TestSchemaParser_WithParserNamespace(t *testing.T) {
+	p := avro.NewSchemaParser(avro.WithParserNamespace("org.acme"))
+
+	schema, err := p.Parse(`{"type":"fixed","name":"Hash","size":16}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "org.acme.Hash", schema.(*avro.FixedSchema).FullName())
+	assert.NotNil(t, p.Cache().Get("org.acme.Hash"))
+}
+
+func TestSchemaParser_WithoutRegistration(t *testing.T) {
+	cache := &avro.SchemaCache{}
+	shared := avro.NewSchemaParser(avro.WithParserCache(cache))
+	_, err := shared.Parse(`{"type":"enum","name":"Color","symbols":["RED","GREEN"]}`)
+	require.NoError(t, err)
+
+	p := avro.NewSchemaParser(avro.WithParserCache(cache), avro.WithoutRegistration())
+	schema, err := p.Parse(`{"type":"record","name":"Car","fields":[{"name":"color","type":"Color"}]}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Record, schema.Type())
+	assert.Nil(t, cache.Get("Car"))
+	assert.Equal(t, []string{"Color"}, cache.Names())
+}
+
+func TestSchemaParser_ParseFiles(t *testing.T) {
+	p := avro.NewSchemaParser()
+
+	s, err := p.ParseFiles("testdata/superhero-part1.avsc", "testdata/superhero-part2.avsc")
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Record, s.Type())
+	assert.NotNil(t, p.Cache().Get("com.model.Superhero"))
+}
+
+func TestSchemaParser_ParseProtocol(t *testing.T) {
+	p := avro.NewSchemaParser()
+	_, err := p.Parse(`{"type":"record","name":"Greeting","namespace":"org.hello","fields":[{"name":"message","type":"string"}]}`)
+	require.NoError(t, err)
+
+	proto, err := p.ParseProtocol(`{"protocol":"Hello","namespace":"org.hello","messages":{"hello":{"request":[{"name":"greeting","type":"Greeting"}],"response":"Greeting"}}}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, avro.Ref, proto.Message("hello").Response().Type())
+}
+
+func TestSchemaParser_Evict(t *testing.T) {
+	p := avro.NewSchemaParser()
+	_, err := p.Parse(`{"type":"record","name":"Old","namespace":"org","aliases":["Legacy"],"fields":[]}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"org.Legacy", "org.Old"}, p.Cache().Names())
+
+	p.Evict("org.Old")
+
+	assert.Empty(t, p.Cache().Names())
+	_, err = p.Parse(`{"type":"array","items":"org.Old"}`)
+	assert.Error(t, err)
+	_, err = p.Parse(`{"type":"record","name":"Old","namespace":"org","fields":[{"name":"a","type":"int"}]}`)
+	assert.NoError(t, err)
+}
+
+func TestSchemaParser_MustParse_PanicsOnError(t *testing.T) {
+	p := avro.NewSchemaParser()
+
+	assert.Panics(t, func() {
+		p.MustParse("123")
+	})
+}
+
+func TestSchemaParser_UnionDefaultMode(t *testing.T) {
+	schm := `{"type":"record", "name":"test", "fields":[{"name": "a", "type": ["null", "bytes"], "default": "\u00ff"}]}`
+
+	_, err := avro.NewSchemaParser().Parse(schm)
+	require.Error(t, err)
+
+	p := avro.NewSchemaParser(avro.WithParserUnionDefaultMode(avro.UnionDefaultAnyType))
+	schema, err := p.Parse(schm)
+	require.NoError(t, err)
+
+	field := schema.(*avro.RecordSchema).Fields()[0]
+	assert.Equal(t, []byte{0xff}, field.Default())
+
+	b, err := avro.Format(schema)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"test","type":"record","fields":[{"name":"a","type":["null","bytes"],"default":"ÿ"}]}`, string(b))
+}
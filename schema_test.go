@@ -443,6 +443,31 @@ func TestRecordSchema_ValidatesDefault(t *testing.T) {
 			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": {"type":"record", "name": "test2", "fields":[{"name": "b", "type": "int"},{"name": "c", "type": "int", "default": "test"}]}, "default": {"b": 1}}]}`,
 			wantErr: assert.Error,
 		},
+		{
+			name:    "Record Missing Field Without Default",
+			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": {"type":"record", "name": "test2", "fields":[{"name": "b", "type": "int"},{"name": "c", "type": "int", "default": 1}]}, "default": {"c": 2}}]}`,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Union Record",
+			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": [{"type":"record", "name": "test2", "fields":[{"name": "b", "type": "int"}]}, "null"], "default": {"b": 1}}]}`,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Union Non First Type",
+			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": ["null", "string"], "default": "test"}]}`,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Fixed Wrong Size",
+			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": {"type":"fixed", "name": "test2", "size": 4}, "default": "abc"}]}`,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Bytes Code Point Out Of Range",
+			schema:  `{"type":"record", "name":"test", "namespace": "org.hamba.avro", "fields":[{"name": "a", "type": "bytes", "default": "\u0100"}]}`,
+			wantErr: assert.Error,
+		},
 	}
 
 	for _, test := range tests {
@@ -467,6 +492,44 @@ func TestRecordSchema_ValidatesDefault(t *testing.T) {
 	}
 }
 
+func TestRecordSchema_ValidatesDefaultReportsPaths(t *testing.T) {
+	schm := `{"type":"record", "name":"test", "fields":[
+		{"name": "a", "type": {"type":"record", "name": "sub", "fields":[
+			{"name": "b", "type": {"type": "array", "items": "int"}},
+			{"name": "c", "type": {"type": "map", "values": {"type": "fixed", "name": "two", "size": 2}}},
+			{"name": "d", "type": "string"}
+		]}, "default": {"b": [1, "x", 2.5], "c": {"k": "\u00ff\u0100"}}}
+	]}`
+
+	_, err := avro.ParseWithCache(schm, "", &avro.SchemaCache{})
+
+	require.Error(t, err)
+	want := `avro: invalid default for field a.b[1]. x not a int
+avro: invalid default for field a.b[2]. 2.5 not a int
+avro: invalid default for field a.c["k"]. code point U+0100 at position 1 is out of the byte range 0-255
+avro: invalid default for field a.d. value is required as the field has no default`
+	assert.Equal(t, want, err.Error())
+}
+
+func TestNewField_UnionDefaultMode(t *testing.T) {
+	union, err := avro.NewUnionSchema([]avro.Schema{
+		avro.NewPrimitiveSchema(avro.Null, nil),
+		avro.NewPrimitiveSchema(avro.String, nil),
+		avro.NewPrimitiveSchema(avro.Bytes, nil),
+	})
+	require.NoError(t, err)
+
+	_, err = avro.NewField("a", union, avro.WithDefault("test"))
+	require.EqualError(t, err, "avro: invalid default for field a. test not a null")
+
+	field, err := avro.NewField("a", union, avro.WithDefault("test"), avro.WithUnionDefaultMode(avro.UnionDefaultAnyType))
+	require.NoError(t, err)
+	assert.Equal(t, "test", field.Default())
+
+	_, err = avro.NewField("a", union, avro.WithDefault(1), avro.WithUnionDefaultMode(avro.UnionDefaultAnyType))
+	assert.EqualError(t, err, `avro: invalid default for field a. 1 matches no type of union ["null","string","bytes"]`)
+}
+
 func TestRecordSchema_ValidatesOrder(t *testing.T) {
 	tests := []struct {
 		name    string